@@ -0,0 +1,211 @@
+package log
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// QueryRow is a row of the results of an aggregating query: the bucket of the entries (if the query has one), the values of the grouping fields, and the result of every aggregation
+type QueryRow struct {
+	Bucket time.Time
+	Group  []string
+	Values []float64
+}
+
+// Aggregator compute the aggregations of a query over the entries added to it. Only the state of the aggregations is kept in memory (percentiles being estimated within 1%), not the entries, so it can aggregate any number of them.
+type Aggregator struct {
+	query  Query
+	groups map[string]*aggregatorGroup
+}
+
+type aggregatorGroup struct {
+	row      QueryRow
+	states   []aggregationState
+	sketches []*quantileSketch
+}
+
+// aggregationState is the state of an aggregation other than percentiles
+type aggregationState struct {
+	count int
+	sum   float64
+	min   float64
+	max   float64
+}
+
+// NewAggregator create an aggregator for the query
+func NewAggregator(query Query) *Aggregator {
+	return &Aggregator{query: query, groups: map[string]*aggregatorGroup{}}
+}
+
+// Add aggregate the entry. It's expected to match the query (see Query.Match).
+func (a *Aggregator) Add(e Entry) {
+	var bucket time.Time
+	if 0 != a.query.Every && !e.Time.IsZero() {
+		bucket = e.Time.Truncate(a.query.Every)
+	}
+	group := make([]string, len(a.query.GroupBy))
+	for i, key := range a.query.GroupBy {
+		if value, ok := queryValue(e, key); ok {
+			group[i] = queryString(value)
+		}
+	}
+	id := bucket.Format(time.RFC3339Nano) + "\x00" + strings.Join(group, "\x00")
+
+	state, ok := a.groups[id]
+	if !ok {
+		state = &aggregatorGroup{
+			row:      QueryRow{Bucket: bucket, Group: group},
+			states:   make([]aggregationState, len(a.query.Aggregations)),
+			sketches: make([]*quantileSketch, len(a.query.Aggregations)),
+		}
+		a.groups[id] = state
+	}
+	for i, aggregation := range a.query.Aggregations {
+		if "count" == aggregation.Function {
+			state.states[i].count++
+			continue
+		}
+		value, ok := queryValue(e, aggregation.Field)
+		if !ok {
+			continue
+		}
+		number, ok := queryNumber(value)
+		if !ok {
+			continue
+		}
+		if "percentile" == aggregation.Function {
+			if nil == state.sketches[i] {
+				state.sketches[i] = newQuantileSketch()
+			}
+			state.sketches[i].add(number)
+			continue
+		}
+		current := &state.states[i]
+		if 0 == current.count || number < current.min {
+			current.min = number
+		}
+		if 0 == current.count || number > current.max {
+			current.max = number
+		}
+		current.count++
+		current.sum += number
+	}
+}
+
+// Rows send back the results, sorted by bucket then by group. Aggregations without values (averages of fields missing from every entry for example) are NaN.
+func (a *Aggregator) Rows() []QueryRow {
+	rows := make([]QueryRow, 0, len(a.groups))
+	for _, group := range a.groups {
+		row := group.row
+		row.Values = make([]float64, len(a.query.Aggregations))
+		for i, aggregation := range a.query.Aggregations {
+			state := group.states[i]
+			switch {
+			case "count" == aggregation.Function:
+				row.Values[i] = float64(state.count)
+			case "percentile" == aggregation.Function && nil != group.sketches[i]:
+				row.Values[i] = group.sketches[i].quantile(aggregation.Percentile / 100)
+			case 0 == state.count:
+				row.Values[i] = math.NaN()
+			case "avg" == aggregation.Function:
+				row.Values[i] = state.sum / float64(state.count)
+			case "sum" == aggregation.Function:
+				row.Values[i] = state.sum
+			case "min" == aggregation.Function:
+				row.Values[i] = state.min
+			case "max" == aggregation.Function:
+				row.Values[i] = state.max
+			}
+		}
+		rows = append(rows, row)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if !rows[i].Bucket.Equal(rows[j].Bucket) {
+			return rows[i].Bucket.Before(rows[j].Bucket)
+		}
+		for k := range rows[i].Group {
+			if rows[i].Group[k] != rows[j].Group[k] {
+				return rows[i].Group[k] < rows[j].Group[k]
+			}
+		}
+		return false
+	})
+	return rows
+}
+
+// quantileRelativeAccuracy is the maximum relative error of the estimated quantiles
+const quantileRelativeAccuracy = 0.01
+
+// quantileSketch estimate quantiles by counting the values in buckets growing exponentially, so the error on any quantile is relative to its value (like DDSketch). Its size depends on the range of the values, not on their number.
+type quantileSketch struct {
+	gamma    float64
+	positive map[int]int
+	negative map[int]int
+	zeros    int
+	count    int
+}
+
+func newQuantileSketch() *quantileSketch {
+	return &quantileSketch{
+		gamma:    (1 + quantileRelativeAccuracy) / (1 - quantileRelativeAccuracy),
+		positive: map[int]int{},
+		negative: map[int]int{},
+	}
+}
+
+func (s *quantileSketch) add(value float64) {
+	s.count++
+	switch {
+	case value > 0:
+		s.positive[s.index(value)]++
+	case value < 0:
+		s.negative[s.index(-value)]++
+	default:
+		s.zeros++
+	}
+}
+
+func (s *quantileSketch) index(value float64) int {
+	return int(math.Ceil(math.Log(value) / math.Log(s.gamma)))
+}
+
+// value send back the estimated value of the values in the bucket: the middle of the bucket, in relative terms
+func (s *quantileSketch) value(index int) float64 {
+	return 2 * math.Pow(s.gamma, float64(index)) / (s.gamma + 1)
+}
+
+// quantile send back the estimated value at the given quantile (between 0 and 1)
+func (s *quantileSketch) quantile(q float64) float64 {
+	if 0 == s.count {
+		return math.NaN()
+	}
+	rank := int(q * float64(s.count-1))
+
+	negative := sortedIndexes(s.negative)
+	for i := len(negative) - 1; i >= 0; i-- {
+		if rank -= s.negative[negative[i]]; rank < 0 {
+			return -s.value(negative[i])
+		}
+	}
+	if rank -= s.zeros; rank < 0 {
+		return 0
+	}
+	positive := sortedIndexes(s.positive)
+	for _, index := range positive {
+		if rank -= s.positive[index]; rank < 0 {
+			return s.value(index)
+		}
+	}
+	return s.value(positive[len(positive)-1])
+}
+
+func sortedIndexes(buckets map[int]int) []int {
+	indexes := make([]int, 0, len(buckets))
+	for index := range buckets {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+	return indexes
+}
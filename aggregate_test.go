@@ -0,0 +1,70 @@
+package log
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestAggregator(t *testing.T) {
+	query, err := ParseQuery("count, avg duration_ms, min duration_ms, max duration_ms, sum duration_ms, p50 duration_ms by service where level >= warn every 1h")
+	if nil != err {
+		t.Fatal(err)
+	}
+	start := time.Date(2016, 8, 13, 15, 0, 0, 0, time.UTC)
+	entries := []Entry{
+		{Time: start.Add(time.Minute), Level: ERROR, Structure: Structure{"service": "api", "duration_ms": int64(100)}},
+		{Time: start.Add(2 * time.Minute), Level: WARN, Structure: Structure{"service": "api", "duration_ms": 300.0}},
+		{Time: start.Add(3 * time.Minute), Level: ERROR, Structure: Structure{"service": "api", "duration_ms": "200"}},
+		{Time: start.Add(4 * time.Minute), Level: INFO, Structure: Structure{"service": "api", "duration_ms": int64(10000)}},
+		{Time: start.Add(5 * time.Minute), Level: ERROR, Structure: Structure{"service": "db", "duration_ms": "unknown"}},
+		{Time: start.Add(61 * time.Minute), Level: ERROR, Structure: Structure{"service": "api", "duration_ms": int64(50)}},
+		{Time: start.Add(62 * time.Minute), Level: ERROR, Structure: Structure{"duration_ms": int64(20)}},
+	}
+	aggregator := NewAggregator(query)
+	for _, entry := range entries {
+		if query.Match(entry) {
+			aggregator.Add(entry)
+		}
+	}
+
+	expected := []QueryRow{
+		{Bucket: start, Group: []string{"api"}, Values: []float64{3, 200, 100, 300, 600, 200}},
+		{Bucket: start, Group: []string{"db"}, Values: []float64{1, math.NaN(), math.NaN(), math.NaN(), math.NaN(), math.NaN()}},
+		{Bucket: start.Add(time.Hour), Group: []string{""}, Values: []float64{1, 20, 20, 20, 20, 20}},
+		{Bucket: start.Add(time.Hour), Group: []string{"api"}, Values: []float64{1, 50, 50, 50, 50, 50}},
+	}
+	rows := aggregator.Rows()
+	if len(expected) != len(rows) {
+		t.Fatalf("Error (Mismatched number of rows) [Expected: '%d'; Received: '%d'; Rows: '%v']", len(expected), len(rows), rows)
+	}
+	for i, row := range rows {
+		if !expected[i].Bucket.Equal(row.Bucket) || expected[i].Group[0] != row.Group[0] {
+			t.Errorf("Error (Mismatched rows) [Expected: '%v'; Received: '%v']", expected[i], row)
+		}
+		for j, value := range row.Values {
+			if math.IsNaN(expected[i].Values[j]) != math.IsNaN(value) || math.Abs(expected[i].Values[j]-value) > expected[i].Values[j]*quantileRelativeAccuracy {
+				t.Errorf("Error (Mismatched values for %s in row %d) [Expected: '%v'; Received: '%v']", query.Columns()[j+2], i, expected[i].Values[j], value)
+			}
+		}
+	}
+}
+
+func TestQuantileSketch(t *testing.T) {
+	sketch := newQuantileSketch()
+	for i := -1000; i <= 100000; i++ {
+		sketch.add(float64(i))
+	}
+	for _, q := range []float64{0, 0.01, 0.25, 0.5, 0.9, 0.99, 0.999, 1} {
+		expected := float64(int(q*101000) - 1000)
+		if received := sketch.quantile(q); math.Abs(expected-received) > math.Abs(expected)*quantileRelativeAccuracy {
+			t.Errorf("Error (Mismatched quantile %v) [Expected: '%v'; Received: '%v']", q, expected, received)
+		}
+	}
+	if 2000 < len(sketch.positive)+len(sketch.negative) {
+		t.Errorf("Error (Too many buckets) [Received: '%d']", len(sketch.positive)+len(sketch.negative))
+	}
+	if !math.IsNaN(newQuantileSketch().quantile(0.5)) {
+		t.Error("Error (Quantile of empty sketch isn't NaN)")
+	}
+}
@@ -2,20 +2,23 @@ package log
 
 import (
 	"log"
+	"strconv"
 	"strings"
 )
 
-// BasicLog decorate the go logger.
+// BasicLog decorate the go logger. Lines are formatted by Template when given, as "[LEVEL]message [fields]" otherwise.
 type BasicLog struct {
 	Logger    *log.Logger
 	Level     Level
+	Template  *LineTemplate
 	structure Structure
 }
 
 // Log log your message on the specified level, with a structure holding the fields you want to log and ending with the message
 func (l BasicLog) Log(lvl Level, str Structure, v ...interface{}) {
-	if nil != l.Logger && lvl >= l.Level {
-		l.structure = l.structure.With(str)
+	if l.Enabled(lvl) {
+		l.structure = resolveStructure(Structure{}.With(l.structure).With(str))
+		v = resolveValues(v)
 		switch lvl {
 		case PANIC:
 			l.Logger.Panic(l.toString(l.structure, lvl, v...)...)
@@ -27,13 +30,95 @@ func (l BasicLog) Log(lvl Level, str Structure, v ...interface{}) {
 	}
 }
 
+// LogFields log the message and the fields on the specified level, formatting the line without building a Structure when there's no Template. As with Log, fields override the keys of the logger (and the previous fields) they redefine.
+func (l BasicLog) LogFields(lvl Level, msg string, fields ...Field) {
+	l.logFields(2, lvl, msg, fields...)
+}
+
+// logFields implement LogFields, calldepth being the number of frames to skip to report the caller (1 being the caller of logFields), as for log.Logger.Output
+func (l BasicLog) logFields(calldepth int, lvl Level, msg string, fields ...Field) {
+	if !l.Enabled(lvl) {
+		return
+	}
+	if nil != l.Template || lvl >= FATAL || redefinesKeys(l.structure, fields) {
+		l.Log(lvl, Structure{}.WithFields(fields...), msg)
+		return
+	}
+
+	buffer := lineBuffers.Get().(*[]byte)
+	line := append((*buffer)[:0], '[')
+	line = appendUpper(line, lvl.String())
+	line = append(line, ']')
+	line = append(line, msg...)
+	if 0 != len(l.structure) || 0 != len(fields) {
+		line = append(line, " ["...)
+		if 0 != len(l.structure) {
+			structure := resolveStructure(l.structure).String()
+			line = append(line, structure[1:len(structure)-1]...)
+		}
+		for i, field := range fields {
+			if 0 != i || 0 != len(l.structure) {
+				line = append(line, ';')
+			}
+			line = appendTextField(line, field)
+		}
+		line = append(line, ']')
+	}
+	l.Logger.Output(calldepth+1, string(line))
+	if cap(line) <= maxPooledBuffer {
+		*buffer = line
+		lineBuffers.Put(buffer)
+	}
+}
+
+// appendTextField append the field as "key:value", as Structure.String does
+func appendTextField(line []byte, field Field) []byte {
+	switch field.Type {
+	case StringType:
+		return append(append(append(line, field.Key...), ':'), field.Text...)
+	case IntType:
+		return strconv.AppendInt(append(append(line, field.Key...), ':'), field.Integer, 10)
+	case FloatType:
+		return strconv.AppendFloat(append(append(line, field.Key...), ':'), field.float(), 'g', -1, 64)
+	case BoolType:
+		return strconv.AppendBool(append(append(line, field.Key...), ':'), 1 == field.Integer)
+	}
+	value, _ := resolveValue(field.Value())
+	structure := Structure{field.Key: value}.String()
+	return append(line, structure[1:len(structure)-1]...)
+}
+
+// appendUpper append the ASCII string in upper case
+func appendUpper(line []byte, value string) []byte {
+	for i := 0; i < len(value); i++ {
+		b := value[i]
+		if 'a' <= b && b <= 'z' {
+			b -= 'a' - 'A'
+		}
+		line = append(line, b)
+	}
+	return line
+}
+
+// Enabled send back true if entries of the level are written
+func (l BasicLog) Enabled(lvl Level) bool {
+	return nil != l.Logger && lvl >= l.Level
+}
+
 // With add some fields to a new logger created from the source and return it
 func (l BasicLog) With(str Structure) AgnosticLogger {
-	l.structure = l.structure.With(str)
+	l.structure = Structure{}.With(l.structure).With(str)
 	return l
 }
 
 func (l BasicLog) toString(str Structure, lvl Level, v ...interface{}) []interface{} {
+	if nil != l.Template {
+		line, err := l.Template.Format(NewEntry(lvl, str, v...))
+		if nil == err {
+			return []interface{}{line}
+		}
+		reportError("BasicLog template", err)
+	}
 	toLog := []interface{}{strings.Join([]string{"[", strings.ToUpper(lvl.String()), "]"}, "")}
 	toLog = append(toLog, v...)
 	if 0 != len(str) {
@@ -0,0 +1,171 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Default batching and retry parameters of the HTTP backends
+const (
+	defaultBatchSize  = 100
+	defaultBatchWait  = time.Second
+	defaultMaxRetries = 5
+	defaultMinBackoff = 500 * time.Millisecond
+	defaultMaxBackoff = 30 * time.Second
+	// maxPendingBatches is the number of batches that can be waiting to be sent, before the oldest entries are dropped
+	maxPendingBatches = 10
+)
+
+// batcher group entries into batches, sent in the background when they're full or after a while. Batches are sent one at a time, in order.
+type batcher struct {
+	name string
+	size int
+	wait time.Duration
+	send func([]Entry) error
+
+	once    sync.Once
+	mutex   sync.Mutex
+	entries []Entry
+	sending sync.Mutex
+	wakeup  chan struct{}
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+func newBatcher(name string, size int, wait time.Duration, send func([]Entry) error) *batcher {
+	if 0 >= size {
+		size = defaultBatchSize
+	}
+	if 0 >= wait {
+		wait = defaultBatchWait
+	}
+	return &batcher{name: name, size: size, wait: wait, send: send}
+}
+
+// add queue the entry, dropping the oldest ones if too many are waiting
+func (b *batcher) add(e Entry) {
+	b.start()
+	b.mutex.Lock()
+	b.entries = append(b.entries, e)
+	if overflow := len(b.entries) - maxPendingBatches*b.size; 0 < overflow {
+		b.entries = b.entries[overflow:]
+	}
+	full := len(b.entries) >= b.size
+	b.mutex.Unlock()
+
+	if full {
+		select {
+		case b.wakeup <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// flush send the queued entries, by batches
+func (b *batcher) flush() error {
+	b.sending.Lock()
+	defer b.sending.Unlock()
+	var errs []error
+	for {
+		b.mutex.Lock()
+		batch := b.entries
+		if len(batch) > b.size {
+			batch = batch[:b.size]
+		}
+		b.entries = b.entries[len(batch):]
+		b.mutex.Unlock()
+
+		if 0 == len(batch) {
+			return errors.Join(errs...)
+		}
+		if err := b.send(batch); nil != err {
+			errs = append(errs, err)
+		}
+	}
+}
+
+// close stop sending batches in the background and send the queued entries
+func (b *batcher) close() error {
+	b.start()
+	b.mutex.Lock()
+	select {
+	case <-b.done:
+	default:
+		close(b.done)
+	}
+	b.mutex.Unlock()
+	<-b.stopped
+	return b.flush()
+}
+
+func (b *batcher) start() {
+	b.once.Do(func() {
+		b.wakeup = make(chan struct{}, 1)
+		b.done = make(chan struct{})
+		b.stopped = make(chan struct{})
+		go b.loop()
+	})
+}
+
+func (b *batcher) loop() {
+	defer close(b.stopped)
+	ticker := time.NewTicker(b.wait)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-b.wakeup:
+		case <-b.done:
+			return
+		}
+		reportError(b.name, b.flush())
+	}
+}
+
+// permanentError is an error that retrying won't fix
+type permanentError struct {
+	error
+}
+
+func (e permanentError) Unwrap() error {
+	return e.error
+}
+
+// retry call the function until it succeed, waiting between each attempt (the waiting time doubling every time, from minBackoff to maxBackoff). Permanent errors aren't retried.
+func retry(maxRetries int, minBackoff, maxBackoff time.Duration, function func() error) error {
+	backoff := minBackoff
+	for attempt := 0; ; attempt++ {
+		err := function()
+		if nil == err {
+			return nil
+		}
+		var permanent permanentError
+		if attempt >= maxRetries || errors.As(err, &permanent) {
+			return err
+		}
+		time.Sleep(backoff)
+		if backoff *= 2; backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
+
+// checkResponse send back an error if the HTTP request failed. Client errors (except 429 Too Many Requests) are permanent.
+func checkResponse(resp *http.Response) error {
+	defer resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	err := fmt.Errorf("unexpected status %s: %s", resp.Status, bytes.TrimSpace(body))
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 && http.StatusTooManyRequests != resp.StatusCode {
+		return permanentError{err}
+	}
+	return err
+}
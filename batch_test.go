@@ -0,0 +1,115 @@
+package log
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBatcher_Size(t *testing.T) {
+	batches := make(chan []Entry, 10)
+	batcher := newBatcher("test", 2, time.Hour, func(entries []Entry) error {
+		batches <- entries
+		return nil
+	})
+	defer batcher.close()
+
+	for _, msg := range []string{"First", "Second", "Third"} {
+		batcher.add(testEntry(INFO, Structure{}, msg))
+	}
+	select {
+	case batch := <-batches:
+		if 2 != len(batch) || "First" != batch[0].Message() || "Second" != batch[1].Message() {
+			t.Errorf("Error (Mismatched batch) [Received: '%+v']", batch)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Error (Full batch not sent)")
+	}
+
+	if err := batcher.close(); nil != err {
+		t.Fatal(err)
+	}
+	if batch := <-batches; 1 != len(batch) || "Third" != batch[0].Message() {
+		t.Errorf("Error (Mismatched batch) [Received: '%+v']", batch)
+	}
+}
+
+func TestBatcher_Wait(t *testing.T) {
+	sent := make(chan []Entry, 1)
+	batcher := newBatcher("test", 100, 10*time.Millisecond, func(entries []Entry) error {
+		sent <- entries
+		return nil
+	})
+	defer batcher.close()
+
+	batcher.add(testEntry(INFO, Structure{}, "Message"))
+	select {
+	case batch := <-sent:
+		if 1 != len(batch) {
+			t.Errorf("Error (Mismatched batch) [Received: '%+v']", batch)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Error (Batch not sent after waiting)")
+	}
+}
+
+func TestBatcher_Overflow(t *testing.T) {
+	var mutex sync.Mutex
+	var received []Entry
+	block := make(chan struct{})
+	batcher := newBatcher("test", 1, time.Hour, func(entries []Entry) error {
+		<-block
+		mutex.Lock()
+		defer mutex.Unlock()
+		received = append(received, entries...)
+		return nil
+	})
+
+	for i := 0; i < 2*maxPendingBatches; i++ {
+		batcher.add(testEntry(INFO, Structure{}, i))
+	}
+	close(block)
+	batcher.close()
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	if len(received) > maxPendingBatches+1 {
+		t.Errorf("Error (Oldest entries not dropped) [Received: '%d' entries]", len(received))
+	}
+	if last := received[len(received)-1].Message(); "19" != last {
+		t.Errorf("Error (Mismatched last entry) [Expected: '%s'; Received: '%s']", "19", last)
+	}
+}
+
+func TestRetry(t *testing.T) {
+	attempts := 0
+	err := retry(3, time.Millisecond, 2*time.Millisecond, func() error {
+		attempts++
+		return errors.New("failure")
+	})
+	if nil == err || 4 != attempts {
+		t.Errorf("Error (Mismatched attempts) [Expected: '%d'; Received: '%d']", 4, attempts)
+	}
+
+	attempts = 0
+	err = retry(3, time.Millisecond, 2*time.Millisecond, func() error {
+		attempts++
+		if 2 == attempts {
+			return nil
+		}
+		return errors.New("failure")
+	})
+	if nil != err || 2 != attempts {
+		t.Errorf("Error (Mismatched attempts) [Expected: '%d'; Received: '%d']", 2, attempts)
+	}
+
+	attempts = 0
+	err = retry(3, time.Millisecond, 2*time.Millisecond, func() error {
+		attempts++
+		return permanentError{errors.New("failure")}
+	})
+	if nil == err || 1 != attempts {
+		t.Errorf("Error (Permanent error retried) [Attempts: '%d']", attempts)
+	}
+}
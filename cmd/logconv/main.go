@@ -0,0 +1,147 @@
+// Command logconv convert log lines written as JSON lines, logfmt, by BasicLog or by logrus into another format.
+//
+// Usage:
+//
+//	logconv [flags] [file...]
+//
+// Lines are read from the files, or from the standard input when none is given, and written to the standard output. Their format is detected line by line, unless -from is given. Lines without time are written without time (GELF servers then use the time they receive them), except in ECS where the time is required. Lines that can't be parsed or converted are reported on the standard error with their line number, and the command exits with status 1 once every line has been read.
+//
+// Flags:
+//
+//	-from auto        format of the lines: auto, json, logfmt, basic or logrus
+//	-to json          output format: json, logfmt, gelf or ecs
+//	-host name        host of the GELF messages (the hostname by default)
+//	-service name     name of the service in the ECS documents
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/normegil/log"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// encoder convert an entry into a line, without its line break
+type encoder func(e log.Entry) ([]byte, error)
+
+func newEncoder(name, host, service string) (encoder, error) {
+	switch name {
+	case "json":
+		return encodeJSON, nil
+	case "logfmt":
+		return func(e log.Entry) ([]byte, error) {
+			return []byte(e.Logfmt()), nil
+		}, nil
+	case "gelf":
+		return (&log.GELFWriter{Host: host}).Encode, nil
+	case "ecs":
+		formatter := log.ECSFormatter{ServiceName: service}
+		return func(e log.Entry) ([]byte, error) {
+			if e.Time.IsZero() {
+				return nil, errors.New("missing time, required by ECS")
+			}
+			document, err := formatter.Format(e)
+			if nil != err {
+				return nil, err
+			}
+			return json.Marshal(document)
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown output format '%s'", name)
+}
+
+// encodeJSON write the entry as JSONLog does: its document, with the time under TimeKey
+func encodeJSON(e log.Entry) ([]byte, error) {
+	document := e.Document()
+	if _, ok := document[log.TimeKey]; !ok && !e.Time.IsZero() {
+		document[log.TimeKey] = e.Time.Format(time.RFC3339Nano)
+	}
+	return json.Marshal(document)
+}
+
+// converter parse lines and write them back in another format
+type converter struct {
+	output io.Writer
+	errors io.Writer
+	format log.LineFormat
+	encode encoder
+	failed bool
+}
+
+// convert read the lines of the reader, named as given in the reports
+func (c *converter) convert(name string, reader io.Reader) error {
+	number := 0
+	return log.ReadLines(reader, func(line string) {
+		number++
+		line = strings.TrimRight(line, "\r\n")
+		if "" == strings.TrimSpace(line) {
+			return
+		}
+		entry, err := log.ParseLine(line, c.format)
+		if nil == err {
+			var encoded []byte
+			if encoded, err = c.encode(entry); nil == err {
+				c.output.Write(append(encoded, '\n'))
+				return
+			}
+		}
+		c.failed = true
+		fmt.Fprintf(c.errors, "%s:%d: %s: %s\n", name, number, err, line)
+	})
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("logconv", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	from := flags.String("from", "auto", "format of the lines: auto, json, logfmt, basic or logrus")
+	to := flags.String("to", "json", "output format: json, logfmt, gelf or ecs")
+	host := flags.String("host", "", "host of the GELF messages (the hostname by default)")
+	service := flags.String("service", "", "name of the service in the ECS documents")
+	if err := flags.Parse(args); nil != err {
+		return 2
+	}
+
+	c := &converter{output: stdout, errors: stderr}
+	var err error
+	if c.format, err = log.ParseLineFormat(*from); nil != err {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	if c.encode, err = newEncoder(*to, *host, *service); nil != err {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+
+	status := 0
+	if 0 == flags.NArg() {
+		if err := c.convert("-", stdin); nil != err {
+			fmt.Fprintln(stderr, err)
+			status = 1
+		}
+	}
+	for _, name := range flags.Args() {
+		file, err := os.Open(name)
+		if nil == err {
+			err = c.convert(name, file)
+			file.Close()
+		}
+		if nil != err {
+			fmt.Fprintln(stderr, err)
+			status = 1
+		}
+	}
+	if c.failed {
+		status = 1
+	}
+	return status
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const input = `2016/08/13 15:40:05 [WARN]Slow request [user:john]
+time="2016-08-13T15:40:06Z" level=error msg="Request failed" status=500
+{"level":"info","msg":"Started","time":"2016-08-13T15:40:07Z","port":8080}
+` + "\x1b[36mINFO\x1b[0m[2016-08-13T15:40:08Z] Listening                                     \x1b[36maddress\x1b[0m=localhost" + `
+not a log line
+
+`
+
+func TestRun(t *testing.T) {
+	cases := []struct {
+		Args     []string
+		Expected []string
+	}{
+		{
+			Args: []string{"-to", "logfmt"},
+			Expected: []string{
+				`level=warn message="Slow request" user=john`,
+				`time=2016-08-13T15:40:06Z level=error message="Request failed" status=500`,
+				`time=2016-08-13T15:40:07Z level=info message=Started port=8080`,
+				`time=2016-08-13T15:40:08Z level=info message=Listening address=localhost`,
+			},
+		},
+		{
+			Args: []string{"-to", "json"},
+			Expected: []string{
+				`"level":"warn","message":"Slow request"`,
+				`{"level":"error","message":"Request failed","status":"500","time":"2016-08-13T15:40:06Z"}`,
+				`{"level":"info","message":"Started","port":8080,"time":"2016-08-13T15:40:07Z"}`,
+				`{"address":"localhost","level":"info","message":"Listening","time":"2016-08-13T15:40:08Z"}`,
+			},
+		},
+		{
+			Args:     []string{"-to", "gelf", "-host", "example.org"},
+			Expected: []string{`"_status":"500"`, `"host":"example.org"`, `"level":3`, `"short_message":"Request failed"`, `"version":"1.1"`},
+		},
+		{
+			Args:     []string{"-to", "ecs", "-service", "api"},
+			Expected: []string{`"@timestamp":"2016-08-13T15:40:07Z"`, `"log":{"level":"info"}`, `"message":"Started"`, `"service":{"name":"api"}`},
+		},
+	}
+
+	for _, test := range cases {
+		stdout := &bytes.Buffer{}
+		stderr := &bytes.Buffer{}
+		if status := run(test.Args, strings.NewReader(input), stdout, stderr); 1 != status {
+			t.Errorf("Error (Mismatched status for %v) [Expected: '%d'; Received: '%d']", test.Args, 1, status)
+		}
+		if expected := "-:5: unknown line format: not a log line\n"; expected != stderr.String() {
+			t.Errorf("Error (Mismatched errors for %v) [Expected: '%s'; Received: '%s']", test.Args, expected, stderr)
+		}
+		lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+		if 4 != len(lines) {
+			t.Fatalf("Error (Mismatched number of lines for %v) [Expected: '%d'; Received: '%d'; Output: '%s']", test.Args, 4, len(lines), stdout)
+		}
+		for _, expected := range test.Expected {
+			if !strings.Contains(stdout.String(), expected) {
+				t.Errorf("Error (Missing output for %v) [Expected: '%s'; Received: '%s']", test.Args, expected, stdout)
+			}
+		}
+		if "logfmt" != test.Args[1] {
+			for _, line := range lines {
+				if !json.Valid([]byte(line)) {
+					t.Errorf("Error (Invalid JSON for %v) [Received: '%s']", test.Args, line)
+				}
+			}
+		}
+	}
+}
+
+func TestRun_From(t *testing.T) {
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	if status := run([]string{"-from", "basic", "-to", "logfmt"}, strings.NewReader(input), stdout, stderr); 1 != status {
+		t.Errorf("Error (Mismatched status) [Expected: '%d'; Received: '%d']", 1, status)
+	}
+	if 1 != strings.Count(stdout.String(), "\n") || 4 != strings.Count(stderr.String(), "\n") {
+		t.Errorf("Error (Lines in other formats not reported) [Output: '%s'; Errors: '%s']", stdout, stderr)
+	}
+	for _, expected := range []string{"-:2: ", "-:3: ", "-:4: ", "-:5: "} {
+		if !strings.Contains(stderr.String(), expected) {
+			t.Errorf("Error (Missing line number) [Expected: '%s'; Received: '%s']", expected, stderr)
+		}
+	}
+}
+
+func TestRun_WithoutTime(t *testing.T) {
+	for _, to := range []string{"json", "logfmt", "gelf"} {
+		stdout := &bytes.Buffer{}
+		stderr := &bytes.Buffer{}
+		if status := run([]string{"-to", to}, strings.NewReader("level=info msg=Started\n"), stdout, stderr); 0 != status {
+			t.Errorf("Error (Mismatched status for '%s') [Expected: '%d'; Received: '%d'; Errors: '%s']", to, 0, status, stderr)
+		}
+		if strings.Contains(stdout.String(), "time") || strings.Contains(stdout.String(), "0001") {
+			t.Errorf("Error (Zero time written for '%s') [Received: '%s']", to, stdout)
+		}
+	}
+
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	if status := run([]string{"-to", "ecs"}, strings.NewReader("level=info msg=Started\n"), stdout, stderr); 1 != status {
+		t.Errorf("Error (Mismatched status) [Expected: '%d'; Received: '%d']", 1, status)
+	}
+	if expected := "-:1: missing time, required by ECS: level=info msg=Started\n"; expected != stderr.String() || "" != stdout.String() {
+		t.Errorf("Error (Mismatched errors) [Expected: '%s'; Received: '%s'; Output: '%s']", expected, stderr, stdout)
+	}
+}
+
+func TestRun_InvalidFlags(t *testing.T) {
+	for _, args := range [][]string{{"-from", "xml"}, {"-to", "xml"}, {"-unknown"}} {
+		if status := run(args, strings.NewReader(""), &bytes.Buffer{}, &bytes.Buffer{}); 2 != status {
+			t.Errorf("Error (Mismatched status for %v) [Expected: '%d'; Received: '%d']", args, 2, status)
+		}
+	}
+}
+
+func TestRun_Files(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "app.log")
+	if err := os.WriteFile(name, []byte("level=info msg=Started\n"), 0644); nil != err {
+		t.Fatal(err)
+	}
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	if status := run([]string{"-to", "logfmt", name}, strings.NewReader(""), stdout, stderr); 0 != status {
+		t.Errorf("Error (Mismatched status) [Expected: '%d'; Received: '%d'; Errors: '%s']", 0, status, stderr)
+	}
+	if expected := "level=info message=Started\n"; expected != stdout.String() {
+		t.Errorf("Error (Mismatched output) [Expected: '%s'; Received: '%s']", expected, stdout)
+	}
+	if status := run([]string{name + ".missing"}, strings.NewReader(""), &bytes.Buffer{}, &bytes.Buffer{}); 1 != status {
+		t.Errorf("Error (Missing file not reported) [Status: '%d']", status)
+	}
+}
@@ -0,0 +1,194 @@
+// Command logquery query log files written as JSON lines, logfmt, by BasicLog or by logrus.
+//
+// Usage:
+//
+//	logquery [flags] query [file...]
+//
+// Lines are read from the files, or from the standard input when none is given, one at a time: only the state of the aggregations is kept in memory, whatever the size of the files. Queries without aggregation print the matching lines as they are. The others print a row per bucket and group, once every line has been read. See log.Query for the syntax of the queries, for example:
+//
+//	logquery 'count ERROR by service where duration_ms > 500 in the last hour' app.log
+//	logquery 'p50 duration_ms, p99 duration_ms by route every 5m' app.log
+//	logquery 'WARN ERROR where user.id = 42' app.log
+//
+// Flags:
+//
+//	-format auto      format of the lines: auto, json, logfmt, basic or logrus
+//	-output table     output of the aggregations: table or json
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/normegil/log"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// searcher run a query over lines
+type searcher struct {
+	output     io.Writer
+	format     log.LineFormat
+	query      log.Query
+	aggregator *log.Aggregator
+	invalid    int
+}
+
+func (s *searcher) search(line string) {
+	line = strings.TrimRight(line, "\r\n")
+	if "" == strings.TrimSpace(line) {
+		return
+	}
+	entry, err := log.ParseLine(line, s.format)
+	if nil != err {
+		s.invalid++
+		return
+	}
+	if !s.query.Match(entry) {
+		return
+	}
+	if nil == s.aggregator {
+		fmt.Fprintln(s.output, line)
+		return
+	}
+	s.aggregator.Add(entry)
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("logquery", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	formatName := flags.String("format", "auto", "format of the lines: auto, json, logfmt, basic or logrus")
+	output := flags.String("output", "table", "output of the aggregations: table or json")
+	if err := flags.Parse(args); nil != err {
+		return 2
+	}
+	if 0 == flags.NArg() {
+		fmt.Fprintln(stderr, "missing query")
+		return 2
+	}
+	if "table" != *output && "json" != *output {
+		fmt.Fprintf(stderr, "unknown output '%s'\n", *output)
+		return 2
+	}
+
+	s := &searcher{output: stdout}
+	var err error
+	if s.format, err = log.ParseLineFormat(*formatName); nil != err {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	if s.query, err = log.ParseQuery(flags.Arg(0)); nil != err {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	if s.query.Aggregated() {
+		s.aggregator = log.NewAggregator(s.query)
+	}
+
+	status := 0
+	if 1 == flags.NArg() {
+		if err := log.ReadLines(stdin, s.search); nil != err {
+			fmt.Fprintln(stderr, err)
+			status = 1
+		}
+	}
+	for _, name := range flags.Args()[1:] {
+		file, err := os.Open(name)
+		if nil == err {
+			err = log.ReadLines(file, s.search)
+			file.Close()
+		}
+		if nil != err {
+			fmt.Fprintln(stderr, err)
+			status = 1
+		}
+	}
+	if 0 != s.invalid {
+		fmt.Fprintf(stderr, "%d lines ignored: unknown format\n", s.invalid)
+	}
+
+	if nil != s.aggregator {
+		if "json" == *output {
+			err = writeJSON(stdout, s.query, s.aggregator.Rows())
+		} else {
+			err = writeTable(stdout, s.query, s.aggregator.Rows())
+		}
+		if nil != err {
+			fmt.Fprintln(stderr, err)
+			status = 1
+		}
+	}
+	return status
+}
+
+// cells send back the values of the row, in the order of the columns of the query
+func cells(query log.Query, row log.QueryRow) []interface{} {
+	var values []interface{}
+	if 0 != query.Every {
+		if row.Bucket.IsZero() {
+			values = append(values, nil)
+		} else {
+			values = append(values, row.Bucket.Format(time.RFC3339))
+		}
+	}
+	for _, group := range row.Group {
+		values = append(values, group)
+	}
+	for _, value := range row.Values {
+		if math.IsNaN(value) {
+			values = append(values, nil)
+		} else {
+			values = append(values, math.Round(value*1000)/1000)
+		}
+	}
+	return values
+}
+
+func writeTable(output io.Writer, query log.Query, rows []log.QueryRow) error {
+	table := tabwriter.NewWriter(output, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, strings.Join(query.Columns(), "\t"))
+	for _, row := range rows {
+		var texts []string
+		for _, value := range cells(query, row) {
+			switch value := value.(type) {
+			case nil:
+				texts = append(texts, "-")
+			case float64:
+				texts = append(texts, strconv.FormatFloat(value, 'f', -1, 64))
+			case string:
+				if "" == value {
+					value = "-"
+				}
+				texts = append(texts, value)
+			}
+		}
+		fmt.Fprintln(table, strings.Join(texts, "\t"))
+	}
+	return table.Flush()
+}
+
+// writeJSON write a JSON document per row, holding its columns
+func writeJSON(output io.Writer, query log.Query, rows []log.QueryRow) error {
+	encoder := json.NewEncoder(output)
+	columns := query.Columns()
+	for _, row := range rows {
+		document := make(map[string]interface{}, len(columns))
+		for i, value := range cells(query, row) {
+			document[columns[i]] = value
+		}
+		if err := encoder.Encode(document); nil != err {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const input = `{"level":"error","message":"Timeout","time":"2016-08-13T15:01:00Z","service":"api","duration_ms":900}
+{"level":"error","message":"Timeout","time":"2016-08-13T15:02:00Z","service":"api","duration_ms":700}
+{"level":"error","message":"Refused","time":"2016-08-13T15:03:00Z","service":"db","duration_ms":100}
+time=2016-08-13T15:04:00Z level=warn msg=Slow service=db duration_ms=650
+time=2016-08-13T16:05:00Z level=error msg=Timeout service=db duration_ms=1200
+not a log line
+`
+
+func TestRun(t *testing.T) {
+	cases := []struct {
+		Args     []string
+		Expected string
+	}{
+		{
+			Args:     []string{"count ERROR by service where duration_ms > 500"},
+			Expected: "service  count\napi      2\ndb       1\n",
+		},
+		{
+			Args:     []string{"count, avg duration_ms, max duration_ms where level >= warn every hour"},
+			Expected: "time                  count  avg(duration_ms)  max(duration_ms)\n2016-08-13T15:00:00Z  4      587.5             900\n2016-08-13T16:00:00Z  1      1200              1200\n",
+		},
+		{
+			Args:     []string{"-output", "json", "count, avg missing by service where message = Timeout"},
+			Expected: "{\"avg(missing)\":null,\"count\":2,\"service\":\"api\"}\n{\"avg(missing)\":null,\"count\":1,\"service\":\"db\"}\n",
+		},
+		{
+			Args:     []string{"WARN where service = db"},
+			Expected: "time=2016-08-13T15:04:00Z level=warn msg=Slow service=db duration_ms=650\n",
+		},
+		{
+			Args:     []string{"-format", "logfmt", "where duration_ms >= 1000"},
+			Expected: "time=2016-08-13T16:05:00Z level=error msg=Timeout service=db duration_ms=1200\n",
+		},
+	}
+
+	for _, test := range cases {
+		stdout := &bytes.Buffer{}
+		stderr := &bytes.Buffer{}
+		if status := run(test.Args, strings.NewReader(input), stdout, stderr); 0 != status {
+			t.Errorf("Error (Mismatched status for %v) [Expected: '%d'; Received: '%d'; Errors: '%s']", test.Args, 0, status, stderr)
+		}
+		if test.Expected != stdout.String() {
+			t.Errorf("Error (Mismatched output for %v) [Expected: '%s'; Received: '%s']", test.Args, test.Expected, stdout)
+		}
+		if !strings.Contains(stderr.String(), "lines ignored") {
+			t.Errorf("Error (Invalid lines not reported for %v) [Received: '%s']", test.Args, stderr)
+		}
+	}
+}
+
+func TestRun_InvalidFlags(t *testing.T) {
+	for _, args := range [][]string{{}, {"count by"}, {"-format", "xml", "count"}, {"-output", "csv", "count"}} {
+		if status := run(args, strings.NewReader(""), &bytes.Buffer{}, &bytes.Buffer{}); 2 != status {
+			t.Errorf("Error (Mismatched status for %v) [Expected: '%d'; Received: '%d']", args, 2, status)
+		}
+	}
+}
+
+func TestRun_Files(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "app.log")
+	if err := os.WriteFile(name, []byte(input), 0644); nil != err {
+		t.Fatal(err)
+	}
+	stdout := &bytes.Buffer{}
+	if status := run([]string{"count", name, name, name + ".missing"}, strings.NewReader(""), stdout, &bytes.Buffer{}); 1 != status {
+		t.Errorf("Error (Missing file not reported) [Status: '%d']", status)
+	}
+	if expected := "count\n10\n"; expected != stdout.String() {
+		t.Errorf("Error (Mismatched output) [Expected: '%s'; Received: '%s']", expected, stdout)
+	}
+}
@@ -0,0 +1,268 @@
+// Command logview pretty-print log files written as JSON lines, logfmt, by BasicLog or by logrus.
+//
+// Usage:
+//
+//	logview [flags] [file...]
+//
+// Lines are read from the files, or from the standard input when none is given. Their format is detected line by line, unless -format is given. Lines that can't be parsed are printed as they are.
+//
+// Flags:
+//
+//	-min-level warn       only show the entries at or above the level
+//	-where user_id=42     only show the entries whose field has the value (or not, with !=); can be repeated
+//	-columns user,status  only show these fields (keys of the flattened Structure, as -where)
+//	-follow               keep reading the files as they grow, like tail -f
+//	-format auto          format of the lines: auto, json, logfmt, basic or logrus
+//	-color auto           use colors: auto (when writing to a terminal), always or never
+//	-time layout          layout of the timestamps (Go time format)
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/normegil/log"
+)
+
+// followInterval is the time waited before checking if followed files grew
+const followInterval = 200 * time.Millisecond
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// condition is a filter given with -where
+type condition struct {
+	key    string
+	value  string
+	negate bool
+}
+
+func (c condition) match(e log.Entry) bool {
+	var value string
+	switch c.key {
+	case log.LevelKey:
+		value = strings.ToLower(e.Level.String())
+	case log.MessageKey:
+		value = e.Message()
+	default:
+		field, ok := e.Structure.Flatten()[c.key]
+		if !ok {
+			return c.negate
+		}
+		value = fmt.Sprint(field)
+	}
+	return (value == c.value) != c.negate
+}
+
+// conditions is a flag.Value collecting the -where flags
+type conditions []condition
+
+func (c *conditions) String() string {
+	return fmt.Sprint(*c)
+}
+
+func (c *conditions) Set(text string) error {
+	if index := strings.Index(text, "!="); -1 != index {
+		*c = append(*c, condition{key: text[:index], value: text[index+2:], negate: true})
+		return nil
+	}
+	index := strings.IndexByte(text, '=')
+	if index <= 0 {
+		return errors.New("expected key=value or key!=value")
+	}
+	*c = append(*c, condition{key: text[:index], value: text[index+1:]})
+	return nil
+}
+
+// viewer filter and print entries
+type viewer struct {
+	output     io.Writer
+	console    log.ConsoleLog
+	format     log.LineFormat
+	minLevel   log.Level
+	conditions conditions
+	columns    []string
+}
+
+func (v viewer) print(line string) {
+	line = strings.TrimRight(line, "\r\n")
+	if "" == strings.TrimSpace(line) {
+		return
+	}
+	entry, err := log.ParseLine(line, v.format)
+	if nil != err {
+		fmt.Fprintln(v.output, line)
+		return
+	}
+	if entry.Level < v.minLevel {
+		return
+	}
+	for _, condition := range v.conditions {
+		if !condition.match(entry) {
+			return
+		}
+	}
+	if nil != v.columns {
+		flat := entry.Structure.Flatten()
+		selected := log.Structure{}
+		for _, column := range v.columns {
+			if value, ok := flat[column]; ok {
+				selected[column] = value
+			}
+		}
+		entry.Structure = selected
+	}
+	io.WriteString(v.output, v.console.Format(entry))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("logview", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	minLevel := flags.String("min-level", "trace", "only show the entries at or above the level")
+	var where conditions
+	flags.Var(&where, "where", "only show the entries whose field has the value (key=value or key!=value), can be repeated")
+	columns := flags.String("columns", "", "comma-separated list of the fields to show (all by default)")
+	follow := flags.Bool("follow", false, "keep reading the files as they grow")
+	formatName := flags.String("format", "auto", "format of the lines: auto, json, logfmt, basic or logrus")
+	color := flags.String("color", "auto", "use colors: auto, always or never")
+	timeFormat := flags.String("time", "2006-01-02 15:04:05.000", "layout of the timestamps")
+	if err := flags.Parse(args); nil != err {
+		return 2
+	}
+
+	v := viewer{output: stdout, conditions: where, console: log.ConsoleLog{Writer: stdout, TimeFormat: *timeFormat}}
+	var err error
+	if v.minLevel, err = log.ParseLevel(*minLevel); nil != err {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	if v.format, err = log.ParseLineFormat(*formatName); nil != err {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	switch *color {
+	case "auto":
+		v.console.Colors = log.ColorAuto
+	case "always":
+		v.console.Colors = log.ColorAlways
+	case "never":
+		v.console.Colors = log.ColorNever
+	default:
+		fmt.Fprintf(stderr, "unknown color mode '%s'\n", *color)
+		return 2
+	}
+	if "" != *columns {
+		v.columns = strings.Split(*columns, ",")
+	}
+
+	if 0 == flags.NArg() {
+		if err := log.ReadLines(stdin, v.print); nil != err {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+		return 0
+	}
+	if *follow {
+		lines := make(chan string)
+		waiter := &sync.WaitGroup{}
+		for _, name := range flags.Args() {
+			waiter.Add(1)
+			go func(name string) {
+				defer waiter.Done()
+				if err := tail(name, lines, nil, followInterval); nil != err {
+					fmt.Fprintln(stderr, err)
+				}
+			}(name)
+		}
+		go func() {
+			waiter.Wait()
+			close(lines)
+		}()
+		for line := range lines {
+			v.print(line)
+		}
+		return 0
+	}
+
+	status := 0
+	for _, name := range flags.Args() {
+		file, err := os.Open(name)
+		if nil == err {
+			err = log.ReadLines(file, v.print)
+			file.Close()
+		}
+		if nil != err {
+			fmt.Fprintln(stderr, err)
+			status = 1
+		}
+	}
+	return status
+}
+
+// tail send the lines of the file, then the lines appended to it, until stop is closed. Files truncated or replaced (by a rotation for example) are read again from their beginning.
+func tail(name string, lines chan<- string, stop <-chan struct{}, interval time.Duration) error {
+	file, err := os.Open(name)
+	if nil != err {
+		return err
+	}
+	defer func() {
+		file.Close()
+	}()
+	reader := bufio.NewReader(file)
+	var partial string
+	for {
+		line, err := reader.ReadString('\n')
+		if nil == err {
+			lines <- partial + line
+			partial = ""
+			continue
+		}
+		if io.EOF != err {
+			return err
+		}
+		partial += line
+
+		select {
+		case <-stop:
+			return nil
+		case <-time.After(interval):
+		}
+		current, err := file.Stat()
+		if nil != err {
+			return err
+		}
+		position, err := file.Seek(0, io.SeekCurrent)
+		if nil != err {
+			return err
+		}
+		if replaced, err := os.Stat(name); nil == err && !os.SameFile(current, replaced) {
+			// Finish reading the previous file before following the new one
+			rest, _ := io.ReadAll(reader)
+			for _, line := range strings.SplitAfter(partial+string(rest), "\n") {
+				if "" != line {
+					lines <- line
+				}
+			}
+			partial = ""
+			if reopened, err := os.Open(name); nil == err {
+				file.Close()
+				file = reopened
+				reader.Reset(file)
+			}
+		} else if current.Size() < position {
+			if _, err := file.Seek(0, io.SeekStart); nil != err {
+				return err
+			}
+			reader.Reset(file)
+			partial = ""
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const input = `{"level":"info","message":"Started","time":"2016-08-13T15:40:05Z","user_id":42}
+level=warn msg="Slow request" user_id=42 duration=1.5s
+2016/08/13 15:40:06 [ERROR]Request failed [user_id:7;status:500]
+not a log line
+{"level":"debug","message":"Details","user_id":42}
+`
+
+func TestRun(t *testing.T) {
+	cases := []struct {
+		Args     []string
+		Expected []string
+		Excluded []string
+	}{
+		{
+			Args:     []string{"-color", "never"},
+			Expected: []string{"2016-08-13 15:40:05.000 INFO  Started user_id=42", "WARN  Slow request duration=1.5s user_id=42", "ERROR Request failed status=500 user_id=7", "not a log line", "DEBUG Details"},
+		},
+		{
+			Args:     []string{"-color", "never", "-min-level", "warn"},
+			Expected: []string{"Slow request", "Request failed", "not a log line"},
+			Excluded: []string{"Started", "Details"},
+		},
+		{
+			Args:     []string{"-color", "never", "-where", "user_id=42", "-where", "level!=debug"},
+			Expected: []string{"Started", "Slow request"},
+			Excluded: []string{"Request failed", "Details"},
+		},
+		{
+			Args:     []string{"-color", "never", "-columns", "status", "-format", "basic"},
+			Expected: []string{"ERROR Request failed status=500\n", `{"level":"info"`},
+			Excluded: []string{"user_id=7"},
+		},
+		{
+			Args:     []string{"-color", "always", "-min-level", "error"},
+			Expected: []string{"\x1b[1m\x1b[31mERROR\x1b[0m"},
+		},
+	}
+
+	for _, test := range cases {
+		stdout := &bytes.Buffer{}
+		stderr := &bytes.Buffer{}
+		if status := run(test.Args, strings.NewReader(input), stdout, stderr); 0 != status {
+			t.Fatalf("Error (Mismatched status for %v) [Expected: '%d'; Received: '%d'; Errors: '%s']", test.Args, 0, status, stderr)
+		}
+		for _, expected := range test.Expected {
+			if !strings.Contains(stdout.String(), expected) {
+				t.Errorf("Error (Missing output for %v) [Expected: '%q'; Received: '%q']", test.Args, expected, stdout)
+			}
+		}
+		for _, excluded := range test.Excluded {
+			if strings.Contains(stdout.String(), excluded) {
+				t.Errorf("Error (Unexpected output for %v) [Excluded: '%s'; Received: '%s']", test.Args, excluded, stdout)
+			}
+		}
+	}
+}
+
+func TestRun_InvalidFlags(t *testing.T) {
+	for _, args := range [][]string{{"-min-level", "verbose"}, {"-format", "xml"}, {"-color", "sometimes"}, {"-where", "missing"}} {
+		if status := run(args, strings.NewReader(""), &bytes.Buffer{}, &bytes.Buffer{}); 2 != status {
+			t.Errorf("Error (Mismatched status for %v) [Expected: '%d'; Received: '%d']", args, 2, status)
+		}
+	}
+}
+
+func TestRun_Files(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "app.log")
+	if err := os.WriteFile(name, []byte(input), 0644); nil != err {
+		t.Fatal(err)
+	}
+	stdout := &bytes.Buffer{}
+	if status := run([]string{"-color", "never", name, name + ".missing"}, strings.NewReader(""), stdout, &bytes.Buffer{}); 1 != status {
+		t.Errorf("Error (Missing file not reported) [Status: '%d']", status)
+	}
+	if 1 != strings.Count(stdout.String(), "Started") {
+		t.Errorf("Error (File not read) [Received: '%s']", stdout)
+	}
+}
+
+func receiveLine(t *testing.T, lines <-chan string) string {
+	select {
+	case line := <-lines:
+		return line
+	case <-time.After(5 * time.Second):
+		t.Fatal("Error (No line received)")
+	}
+	return ""
+}
+
+func TestTail(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "app.log")
+	if err := os.WriteFile(name, []byte("first\n"), 0644); nil != err {
+		t.Fatal(err)
+	}
+	lines := make(chan string)
+	stop := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- tail(name, lines, stop, 10*time.Millisecond)
+	}()
+
+	if line := receiveLine(t, lines); "first\n" != line {
+		t.Errorf("Error (Mismatched lines) [Expected: '%q'; Received: '%q']", "first\n", line)
+	}
+
+	file, err := os.OpenFile(name, os.O_APPEND|os.O_WRONLY, 0644)
+	if nil != err {
+		t.Fatal(err)
+	}
+	file.WriteString("sec")
+	time.Sleep(50 * time.Millisecond)
+	file.WriteString("ond\n")
+	file.Close()
+	if line := receiveLine(t, lines); "second\n" != line {
+		t.Errorf("Error (Partial line not completed) [Expected: '%q'; Received: '%q']", "second\n", line)
+	}
+
+	if err := os.WriteFile(name, []byte("truncated\n"), 0644); nil != err {
+		t.Fatal(err)
+	}
+	if line := receiveLine(t, lines); "truncated\n" != line {
+		t.Errorf("Error (Truncated file not read again) [Expected: '%q'; Received: '%q']", "truncated\n", line)
+	}
+
+	rotated := name + ".1"
+	if err := os.Rename(name, rotated); nil != err {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(name, []byte("replaced\n"), 0644); nil != err {
+		t.Fatal(err)
+	}
+	if line := receiveLine(t, lines); "replaced\n" != line {
+		t.Errorf("Error (Replaced file not followed) [Expected: '%q'; Received: '%q']", "replaced\n", line)
+	}
+
+	close(stop)
+	if err := <-done; nil != err {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,238 @@
+package log
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ColorMode tell when ConsoleLog use colors
+type ColorMode int
+
+// Supported color modes
+const (
+	// ColorAuto use colors when writing to a terminal, unless the NO_COLOR environment variable is set
+	ColorAuto ColorMode = iota
+	ColorAlways
+	ColorNever
+)
+
+// ANSI escape sequences used by ConsoleLog
+const (
+	ansiReset      = "\x1b[0m"
+	ansiBold       = "\x1b[1m"
+	ansiFaint      = "\x1b[2m"
+	ansiRed        = "\x1b[31m"
+	ansiGreen      = "\x1b[32m"
+	ansiYellow     = "\x1b[33m"
+	ansiBlue       = "\x1b[34m"
+	ansiMagenta    = "\x1b[35m"
+	ansiCyan       = "\x1b[36m"
+	ansiGray       = "\x1b[90m"
+	ansiWhiteOnRed = "\x1b[1;37;41m"
+)
+
+// levelColors are the colors of the level badges
+var levelColors = map[Level]string{
+	PANIC: ansiWhiteOnRed,
+	FATAL: ansiBold + ansiMagenta,
+	ERROR: ansiBold + ansiRed,
+	WARN:  ansiYellow,
+	INFO:  ansiGreen,
+	DEBUG: ansiBlue,
+	TRACE: ansiGray,
+}
+
+// processStart is the time relative timestamps are computed from, when no other is given
+var processStart = time.Now()
+
+// ConsoleLog is an AgnosticLogger writing human-friendly lines to Writer (the standard error by default), meant for development: a timestamp, an aligned level badge, the message and the fields of the Structure. Fields holding nested values (maps, slices, errors with causes or stack traces) are pretty-printed on the following lines.
+//
+// Timestamps are relative to Start (the start of the program by default), unless a TimeFormat is given. Levels are colored depending on Colors.
+type ConsoleLog struct {
+	Writer     io.Writer
+	Level      Level
+	Colors     ColorMode
+	Start      time.Time
+	TimeFormat string
+	structure  Structure
+}
+
+// Log write the entry to the console.
+func (l ConsoleLog) Log(lvl Level, str Structure, v ...interface{}) {
+	if l.Enabled(lvl) {
+		entry := NewEntry(lvl, Structure{}.With(l.structure).With(str), v...)
+		_, err := io.WriteString(l.writer(), l.Format(entry))
+		reportError("console", err)
+		terminate(lvl, entry.Message())
+	}
+}
+
+// Enabled send back true if entries of the level are written
+func (l ConsoleLog) Enabled(lvl Level) bool {
+	return lvl >= l.Level
+}
+
+// With add some fields to a new logger created from the source and return it
+func (l ConsoleLog) With(str Structure) AgnosticLogger {
+	l.structure = Structure{}.With(l.structure).With(str)
+	return l
+}
+
+func (l ConsoleLog) writer() io.Writer {
+	if nil == l.Writer {
+		return os.Stderr
+	}
+	return l.Writer
+}
+
+// colored tell if the output should be colored
+func (l ConsoleLog) colored() bool {
+	switch l.Colors {
+	case ColorAlways:
+		return true
+	case ColorNever:
+		return false
+	}
+	if "" != os.Getenv("NO_COLOR") {
+		return false
+	}
+	return isTerminal(l.writer())
+}
+
+// isTerminal tell if the writer is a terminal (a character device)
+func isTerminal(writer io.Writer) bool {
+	file, ok := writer.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := file.Stat()
+	return nil == err && 0 != info.Mode()&os.ModeCharDevice
+}
+
+// Format send back the lines written for the entry
+func (l ConsoleLog) Format(e Entry) string {
+	painter := ansiPainter(l.colored())
+	builder := &strings.Builder{}
+	timestamp := l.timestamp(e.Time)
+	builder.WriteString(painter.paint(ansiFaint, timestamp))
+	builder.WriteString(" ")
+	builder.WriteString(painter.paint(levelColors[e.Level], fmt.Sprintf("%-5s", strings.ToUpper(e.Level.String()))))
+	builder.WriteString(" ")
+
+	// Continuation lines are aligned on the message
+	indent := strings.Repeat(" ", len(timestamp)+7)
+	builder.WriteString(strings.ReplaceAll(e.Message(), "\n", "\n"+indent))
+
+	var nested []string
+	for _, key := range sortedKeys(e.Structure) {
+		value := consoleValue(e.Structure[key])
+		if isNested(value) {
+			nested = append(nested, key)
+			continue
+		}
+		builder.WriteString(" " + painter.paint(ansiCyan, logfmtKey(key)) + "=" + logfmtValue(value))
+	}
+	builder.WriteString("\n")
+	for _, key := range nested {
+		builder.WriteString(indent + painter.paint(ansiCyan, key) + ":\n")
+		writeNested(builder, painter, indent+"  ", consoleValue(e.Structure[key]))
+	}
+	return builder.String()
+}
+
+func (l ConsoleLog) timestamp(t time.Time) string {
+	if t.IsZero() {
+		// Entries parsed from lines without time keep the alignment
+		return strings.Repeat(" ", len(l.timestamp(time.Now())))
+	}
+	if "" != l.TimeFormat {
+		return t.Format(l.TimeFormat)
+	}
+	start := l.Start
+	if start.IsZero() {
+		start = processStart
+	}
+	return fmt.Sprintf("%+10.3fs", t.Sub(start).Seconds())
+}
+
+// ansiPainter color strings when set
+type ansiPainter bool
+
+func (p ansiPainter) paint(color, text string) string {
+	if !p || "" == color {
+		return text
+	}
+	return color + text + ansiReset
+}
+
+// consoleValue convert errors into maps, unless they're simple enough to fit on a line
+func consoleValue(value interface{}) interface{} {
+	var detail ErrorDetail
+	switch value := value.(type) {
+	case error:
+		detail = NewErrorDetail(value)
+	case ErrorDetail:
+		detail = value
+	case Structure:
+		return map[string]interface{}(value)
+	default:
+		return value
+	}
+	if 0 == len(detail.Causes) && 0 == len(detail.Stack) {
+		return detail.Message
+	}
+	return detail.Map()
+}
+
+// isNested tell if the value is a map or a slice, to be written on several lines
+func isNested(value interface{}) bool {
+	if nil == value {
+		return false
+	}
+	switch reflect.TypeOf(value).Kind() {
+	case reflect.Map:
+		return 0 != reflect.ValueOf(value).Len()
+	case reflect.Slice, reflect.Array:
+		if _, isBytes := value.([]byte); isBytes {
+			return false
+		}
+		return 0 != reflect.ValueOf(value).Len()
+	}
+	return false
+}
+
+// writeNested write maps as "key: value" lines and slices as "- value" lines, nested values being indented below their key
+func writeNested(builder *strings.Builder, painter ansiPainter, indent string, value interface{}) {
+	reflected := reflect.ValueOf(value)
+	if reflect.Map == reflected.Kind() {
+		keys := reflected.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+		})
+		for _, key := range keys {
+			item := consoleValue(reflected.MapIndex(key).Interface())
+			name := painter.paint(ansiCyan, fmt.Sprint(key.Interface())) + ":"
+			if isNested(item) {
+				builder.WriteString(indent + name + "\n")
+				writeNested(builder, painter, indent+"  ", item)
+				continue
+			}
+			builder.WriteString(indent + name + " " + fmt.Sprint(item) + "\n")
+		}
+		return
+	}
+	for i := 0; i < reflected.Len(); i++ {
+		item := consoleValue(reflected.Index(i).Interface())
+		if isNested(item) {
+			builder.WriteString(indent + "-\n")
+			writeNested(builder, painter, indent+"  ", item)
+			continue
+		}
+		builder.WriteString(indent + "- " + fmt.Sprint(item) + "\n")
+	}
+}
@@ -0,0 +1,88 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConsoleLog_Format(t *testing.T) {
+	entry := testEntry(WARN, Structure{
+		"user":  "john doe",
+		"count": 2,
+		"error": errors.New("failure"),
+		"tags":  []string{"a", "b"},
+		"request": Structure{
+			"method":  "GET",
+			"headers": map[string]interface{}{"accept": "*/*"},
+		},
+	}, "First line\nSecond line")
+	console := ConsoleLog{Colors: ColorNever, Start: entry.Time.Add(-1500 * time.Millisecond)}
+
+	expected := "    +1.500s WARN  First line\n" +
+		"                  Second line count=2 error=failure user=\"john doe\"\n" +
+		"                  request:\n" +
+		"                    headers:\n" +
+		"                      accept: */*\n" +
+		"                    method: GET\n" +
+		"                  tags:\n" +
+		"                    - a\n" +
+		"                    - b\n"
+	if received := console.Format(entry); expected != received {
+		t.Errorf("Error (Mismatched lines)\n\tExpected:\n%s\n\tReceived:\n%s", expected, received)
+	}
+}
+
+func TestConsoleLog_Badges(t *testing.T) {
+	console := ConsoleLog{Colors: ColorNever, TimeFormat: "15:04:05"}
+	for _, lvl := range []Level{PANIC, FATAL, ERROR, WARN, INFO, DEBUG, TRACE} {
+		line := console.Format(testEntry(lvl, Structure{}, "Message"))
+		expected := fmt.Sprintf("15:40:05 %-5s Message\n", strings.ToUpper(lvl.String()))
+		if expected != line {
+			t.Errorf("Error (Mismatched lines) [Expected: '%s'; Received: '%s']", expected, line)
+		}
+	}
+}
+
+func TestConsoleLog_Colors(t *testing.T) {
+	entry := testEntry(ERROR, Structure{"user": "john"}, "Message")
+	line := ConsoleLog{Colors: ColorAlways, TimeFormat: "15:04:05"}.Format(entry)
+	expected := ansiFaint + "15:40:05" + ansiReset + " " + ansiBold + ansiRed + "ERROR" + ansiReset + " Message " + ansiCyan + "user" + ansiReset + "=john\n"
+	if expected != line {
+		t.Errorf("Error (Mismatched lines) [Expected: '%q'; Received: '%q']", expected, line)
+	}
+
+	buffer := &bytes.Buffer{}
+	ConsoleLog{Writer: buffer}.Log(ERROR, Structure{}, "Message")
+	if strings.Contains(buffer.String(), "\x1b[") {
+		t.Errorf("Error (Colors used when not writing to a terminal) [Received: '%q']", buffer)
+	}
+
+	file, err := os.Create(filepath.Join(t.TempDir(), "console.log"))
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if (ConsoleLog{Writer: file}).colored() {
+		t.Error("Error (Colors used when writing to a file)")
+	}
+	t.Setenv("NO_COLOR", "1")
+	if (ConsoleLog{Writer: os.Stdout}).colored() {
+		t.Error("Error (Colors used despite NO_COLOR)")
+	}
+}
+
+func TestConsoleLog(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	console := ConsoleLog{Writer: buffer, Level: INFO}.With(Structure{"user": "john"})
+	console.Log(DEBUG, Structure{}, "Filtered")
+	console.Log(INFO, Structure{"count": 2}, "Message")
+	if !strings.HasSuffix(buffer.String(), " INFO  Message count=2 user=john\n") || 1 != strings.Count(buffer.String(), "\n") {
+		t.Errorf("Error (Mismatched output) [Received: '%s']", buffer)
+	}
+}
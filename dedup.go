@@ -0,0 +1,148 @@
+package log
+
+import (
+	"sync"
+	"time"
+)
+
+// RepeatedKey is the key used by DedupLogger to report how many times an entry has been repeated
+const RepeatedKey = "repeated"
+
+// DedupLogger decorate an AgnosticLogger to collapse repeated identical entries (same level, message and Structure), like syslog's "last message repeated N times". The first entry is logged immediately. Its repetitions are counted and reported by logging the entry again with the count under RepeatedKey, when a different entry is logged or after Timeout.
+//
+// The state is shared by all the loggers created with With and is safe for concurrent use: entries and the reports of their repetitions are logged one at a time, in order. Use NewDedupLogger to create one.
+type DedupLogger struct {
+	Logger    AgnosticLogger
+	Timeout   time.Duration
+	structure Structure
+	state     *dedupState
+}
+
+// NewDedupLogger create a DedupLogger reporting repetitions at the latest after the given timeout (never if 0).
+func NewDedupLogger(logger AgnosticLogger, timeout time.Duration) DedupLogger {
+	return DedupLogger{
+		Logger:  logger,
+		Timeout: timeout,
+		state:   &dedupState{},
+	}
+}
+
+// Log log the message through the decorated logger, unless it's a repetition of the previous entry. Entries of levels the decorated logger doesn't write are ignored.
+func (l DedupLogger) Log(lvl Level, str Structure, v ...interface{}) {
+	if !l.Enabled(lvl) {
+		return
+	}
+	if nil == l.state {
+		l.Logger.Log(lvl, str, v...)
+		return
+	}
+	str, v = resolveStructure(str), resolveValues(v)
+
+	// The keys of the Structure are sorted by Logfmt, so identical entries get identical keys whatever the order of the map
+	key := Entry{Level: lvl, Structure: Structure{}.With(l.structure).With(str), Values: v}.Logfmt()
+
+	l.state.mutex.Lock()
+	if pending := l.state.pending; nil != pending && key == pending.key {
+		pending.count++
+		if nil == l.state.timer && 0 != l.Timeout {
+			generation := l.state.generation
+			l.state.timer = time.AfterFunc(l.Timeout, func() { l.state.timeout(generation) })
+		}
+		l.state.mutex.Unlock()
+		return
+	}
+
+	repeated := l.state.flush()
+	l.state.pending = &dedupEntry{
+		key:       key,
+		level:     lvl,
+		structure: Structure{}.With(str),
+		values:    v,
+		logger:    l.Logger,
+	}
+	l.state.output.Lock()
+	defer l.state.output.Unlock()
+	l.state.mutex.Unlock()
+
+	repeated.report()
+	l.Logger.Log(lvl, str, v...)
+}
+
+// Enabled send back true if the decorated logger is enabled for the level
+func (l DedupLogger) Enabled(lvl Level) bool {
+	return Enabled(l.Logger, lvl)
+}
+
+// With send back a DedupLogger decorating a logger containing the given fields. The new logger shares its state with the current one.
+func (l DedupLogger) With(str Structure) AgnosticLogger {
+	if nil != l.Logger {
+		l.Logger = l.Logger.With(str)
+	}
+	l.structure = Structure{}.With(l.structure).With(str)
+	return l
+}
+
+// Flush report the repetitions of the last entry, if any. Call it before exiting to avoid losing them.
+func (l DedupLogger) Flush() {
+	if nil == l.state {
+		return
+	}
+	l.state.mutex.Lock()
+	repeated := l.state.flush()
+	l.state.output.Lock()
+	defer l.state.output.Unlock()
+	l.state.mutex.Unlock()
+	repeated.report()
+}
+
+type dedupEntry struct {
+	key       string
+	level     Level
+	structure Structure
+	values    []interface{}
+	logger    AgnosticLogger
+	count     uint64
+}
+
+// dedupState is the state shared by a DedupLogger and the loggers created from it. mutex protect the pending entry, and output serialize the entries logged: it's locked before mutex is released, so entries are logged in the order they were handled, while repetitions can still be counted during slow writes.
+type dedupState struct {
+	mutex      sync.Mutex
+	output     sync.Mutex
+	pending    *dedupEntry
+	timer      *time.Timer
+	generation uint64
+}
+
+// report log the repetitions of the entry, if it has been repeated. It's called holding the output mutex only.
+func (e *dedupEntry) report() {
+	if nil == e || 0 == e.count {
+		return
+	}
+	str := Structure{}.With(e.structure).With(Structure{RepeatedKey: e.count})
+	e.logger.Log(e.level, str, e.values...)
+}
+
+// flush forget the pending entry and send it back, for its repetitions to be reported holding the output mutex. The mutex must be held.
+func (s *dedupState) flush() *dedupEntry {
+	if nil != s.timer {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	s.generation++
+
+	pending := s.pending
+	s.pending = nil
+	return pending
+}
+
+func (s *dedupState) timeout(generation uint64) {
+	s.mutex.Lock()
+	var repeated *dedupEntry
+	if generation == s.generation {
+		repeated = s.flush()
+	}
+	s.output.Lock()
+	defer s.output.Unlock()
+	s.mutex.Unlock()
+	repeated.report()
+}
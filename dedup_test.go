@@ -0,0 +1,212 @@
+package log
+
+import (
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDedupLogger(t *testing.T) {
+	recorder := newRecordingLogger()
+	dedup := NewDedupLogger(recorder, 0)
+
+	for i := 0; i < 4; i++ {
+		dedup.Log(INFO, Structure{"Test": "test"}, "Message")
+	}
+	dedup.Log(INFO, Structure{"Test": "test"}, "Other message")
+
+	entries := recorder.Entries()
+	if 3 != len(entries) {
+		t.Fatalf("Error (Mismatched number of entries) [Expected: '%d'; Received: '%d']", 3, len(entries))
+	}
+	if _, ok := entries[0].Structure[RepeatedKey]; ok {
+		t.Errorf("Error (Unexpected repeat count) [Received: '%+v']", entries[0].Structure)
+	}
+	if uint64(3) != entries[1].Structure[RepeatedKey] {
+		t.Errorf("Error (Mismatched repeat count) [Expected: '%d'; Received: '%v']", 3, entries[1].Structure[RepeatedKey])
+	}
+	if "Message" != entries[1].Message || "test" != entries[1].Structure["Test"] {
+		t.Errorf("Error (Mismatched repeated entry) [Received: '%+v']", entries[1])
+	}
+	if "Other message" != entries[2].Message {
+		t.Errorf("Error (Mismatched strings) [Expected: '%s'; Received: '%s']", "Other message", entries[2].Message)
+	}
+}
+
+func TestDedupLogger_SeveralFields(t *testing.T) {
+	recorder := newRecordingLogger()
+	dedup := NewDedupLogger(recorder, 0)
+
+	for i := 0; i < 20; i++ {
+		dedup.Log(INFO, Structure{"First": 1, "Second": "two", "Third": 3.5, "Fourth": true}, "Message")
+	}
+	dedup.Flush()
+
+	entries := recorder.Entries()
+	if 2 != len(entries) {
+		t.Fatalf("Error (Mismatched number of entries) [Expected: '%d'; Received: '%d']", 2, len(entries))
+	}
+	if uint64(19) != entries[1].Structure[RepeatedKey] {
+		t.Errorf("Error (Mismatched repeat count) [Expected: '%d'; Received: '%v']", 19, entries[1].Structure[RepeatedKey])
+	}
+}
+
+func TestDedupLogger_DifferentEntries(t *testing.T) {
+	cases := []struct {
+		Level     Level
+		Structure Structure
+		Message   string
+	}{
+		{Level: INFO, Structure: Structure{}, Message: "Message"},
+		{Level: WARN, Structure: Structure{}, Message: "Message"},
+		{Level: WARN, Structure: Structure{"Test": "test"}, Message: "Message"},
+		{Level: WARN, Structure: Structure{"Test": "other"}, Message: "Message"},
+	}
+
+	recorder := newRecordingLogger()
+	dedup := NewDedupLogger(recorder, 0)
+	for _, test := range cases {
+		dedup.Log(test.Level, test.Structure, test.Message)
+	}
+
+	entries := recorder.Entries()
+	if len(cases) != len(entries) {
+		t.Errorf("Error (Mismatched number of entries) [Expected: '%d'; Received: '%d']", len(cases), len(entries))
+	}
+}
+
+func TestDedupLogger_With(t *testing.T) {
+	recorder := newRecordingLogger()
+	dedup := NewDedupLogger(recorder, 0)
+
+	dedup.Log(INFO, Structure{}, "Message")
+	dedup.With(Structure{"Test": "test"}).Log(INFO, Structure{}, "Message")
+
+	entries := recorder.Entries()
+	if 2 != len(entries) {
+		t.Fatalf("Error (Mismatched number of entries) [Expected: '%d'; Received: '%d']", 2, len(entries))
+	}
+	if "test" != entries[1].Structure["Test"] {
+		t.Errorf("Error (Mismatched strings) [Expected: '%s'; Received: '%v']", "test", entries[1].Structure["Test"])
+	}
+}
+
+func TestDedupLogger_Timeout(t *testing.T) {
+	recorder := newRecordingLogger()
+	dedup := NewDedupLogger(recorder, 10*time.Millisecond)
+
+	dedup.Log(INFO, Structure{}, "Message")
+	dedup.Log(INFO, Structure{}, "Message")
+
+	deadline := time.Now().Add(time.Second)
+	for 2 != len(recorder.Entries()) && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	entries := recorder.Entries()
+	if 2 != len(entries) {
+		t.Fatalf("Error (Mismatched number of entries) [Expected: '%d'; Received: '%d']", 2, len(entries))
+	}
+	if uint64(1) != entries[1].Structure[RepeatedKey] {
+		t.Errorf("Error (Mismatched repeat count) [Expected: '%d'; Received: '%v']", 1, entries[1].Structure[RepeatedKey])
+	}
+
+	dedup.Log(INFO, Structure{}, "Message")
+	if 3 != len(recorder.Entries()) {
+		t.Errorf("Error (Entry not logged after timeout) [Expected: '%d'; Received: '%d']", 3, len(recorder.Entries()))
+	}
+}
+
+func TestDedupLogger_Flush(t *testing.T) {
+	recorder := newRecordingLogger()
+	dedup := NewDedupLogger(recorder, time.Hour)
+
+	dedup.Log(INFO, Structure{}, "Message")
+	dedup.Log(INFO, Structure{}, "Message")
+	dedup.Flush()
+	dedup.Flush()
+
+	entries := recorder.Entries()
+	if 2 != len(entries) {
+		t.Errorf("Error (Mismatched number of entries) [Expected: '%d'; Received: '%d']", 2, len(entries))
+	}
+}
+
+func TestDedupLogger_Concurrent(t *testing.T) {
+	recorder := newRecordingLogger()
+	dedup := NewDedupLogger(recorder, time.Millisecond)
+
+	group := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		group.Add(1)
+		go func() {
+			defer group.Done()
+			for j := 0; j < 100; j++ {
+				dedup.Log(INFO, Structure{}, "Message")
+			}
+		}()
+	}
+	group.Wait()
+	dedup.Flush()
+
+	var total uint64
+	for _, entry := range recorder.Entries() {
+		if count, ok := entry.Structure[RepeatedKey].(uint64); ok {
+			total += count
+		} else {
+			total++
+		}
+	}
+	if 1000 != total {
+		t.Errorf("Error (Mismatched number of entries) [Expected: '%d'; Received: '%d']", 1000, total)
+	}
+}
+
+// yieldingLogger yield the processor before logging, to widen the windows in which goroutines can race
+type yieldingLogger struct {
+	AgnosticLogger
+}
+
+func (l yieldingLogger) Log(lvl Level, str Structure, v ...interface{}) {
+	runtime.Gosched()
+	l.AgnosticLogger.Log(lvl, str, v...)
+}
+
+func TestDedupLogger_ConcurrentOrder(t *testing.T) {
+	recorder := newRecordingLogger()
+	dedup := NewDedupLogger(yieldingLogger{recorder}, 0)
+
+	group := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		group.Add(1)
+		go func(i int) {
+			defer group.Done()
+			for j := 0; j < 200; j++ {
+				dedup.Log(INFO, Structure{}, "Message ", (i+j)%2)
+				runtime.Gosched()
+			}
+		}(i)
+	}
+	group.Wait()
+	dedup.Flush()
+
+	// Repetitions are reported right after the entry they repeat
+	entries := recorder.Entries()
+	for i, entry := range entries {
+		if _, ok := entry.Structure[RepeatedKey]; !ok {
+			continue
+		}
+		if 0 == i || entries[i-1].Message != entry.Message {
+			t.Fatalf("Error (Repetitions reported out of order) [Index: '%d'; Entries: '%+v']", i, entries[max(0, i-2):i+1])
+		}
+		if _, ok := entries[i-1].Structure[RepeatedKey]; ok {
+			t.Fatalf("Error (Repetitions reported twice) [Index: '%d'; Entries: '%+v']", i, entries[i-1:i+1])
+		}
+	}
+}
+
+func TestDedupLogger_NoLogger(t *testing.T) {
+	dedup := DedupLogger{}
+	dedup.Log(INFO, Structure{}, "Message")
+	dedup.Flush()
+}
@@ -0,0 +1,336 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ECSVersion is the version of the Elastic Common Schema followed by ECSFormatter
+const ECSVersion = "8.11.0"
+
+// DefaultECSFields map common Structure keys to their ECS field
+var DefaultECSFields = map[string]string{
+	"trace_id":       "trace.id",
+	"traceID":        "trace.id",
+	"span_id":        "span.id",
+	"spanID":         "span.id",
+	"transaction_id": "transaction.id",
+	"service":        "service.name",
+	"user":           "user.name",
+	"user_id":        "user.id",
+	"userID":         "user.id",
+	"host":           "host.name",
+	"hostname":       "host.hostname",
+	"method":         "http.request.method",
+	"status_code":    "http.response.status_code",
+	"url":            "url.full",
+	"client_ip":      "client.ip",
+	"user_agent":     "user_agent.original",
+	"logger":         "log.logger",
+	"duration":       "event.duration",
+	StackKey:         "error.stack_trace",
+}
+
+// ecsType is the type of an ECS field, as far as JSON values are concerned
+type ecsType int
+
+const (
+	ecsKeyword ecsType = iota
+	ecsLong
+	ecsDate
+	ecsIP
+)
+
+// ecsFields are the types of the ECS fields set by ECSFormatter or DefaultECSFields
+var ecsFields = map[string]ecsType{
+	"@timestamp":                ecsDate,
+	"message":                   ecsKeyword,
+	"ecs.version":               ecsKeyword,
+	"log.level":                 ecsKeyword,
+	"log.logger":                ecsKeyword,
+	"error.message":             ecsKeyword,
+	"error.type":                ecsKeyword,
+	"error.stack_trace":         ecsKeyword,
+	"service.name":              ecsKeyword,
+	"service.version":           ecsKeyword,
+	"service.environment":       ecsKeyword,
+	"trace.id":                  ecsKeyword,
+	"span.id":                   ecsKeyword,
+	"transaction.id":            ecsKeyword,
+	"user.id":                   ecsKeyword,
+	"user.name":                 ecsKeyword,
+	"host.name":                 ecsKeyword,
+	"host.hostname":             ecsKeyword,
+	"http.request.method":       ecsKeyword,
+	"http.response.status_code": ecsLong,
+	"url.full":                  ecsKeyword,
+	"client.ip":                 ecsIP,
+	"user_agent.original":       ecsKeyword,
+	"event.duration":            ecsLong,
+}
+
+// ecsFieldSets are the ECS field sets, which must be objects
+var ecsFieldSets = map[string]bool{
+	"agent": true, "client": true, "cloud": true, "container": true, "destination": true, "dns": true, "ecs": true,
+	"error": true, "event": true, "file": true, "host": true, "http": true, "labels": true, "log": true, "network": true,
+	"observer": true, "organization": true, "process": true, "server": true, "service": true, "source": true, "span": true,
+	"trace": true, "transaction": true, "url": true, "user": true, "user_agent": true,
+}
+
+// ECSFormatter format entries following the Elastic Common Schema (version ECSVersion): the time goes under @timestamp, the level under log.level and errors stored under ErrorKey under error.message, error.type and error.stack_trace. Keys of the Structure holding single values are renamed using Fields (DefaultECSFields when nil) and nested into objects on their dots, so "http.request.id" becomes {"http": {"request": {"id": ...}}}.
+//
+// Keys that can't be stored without breaking the schema (clashing with other fields, or holding values of the wrong type) are stored as strings under labels. Documents are checked with ValidateECS before being sent back.
+type ECSFormatter struct {
+	ServiceName        string
+	ServiceVersion     string
+	ServiceEnvironment string
+	Fields             map[string]string
+}
+
+// Format build the ECS document of the entry
+func (f ECSFormatter) Format(e Entry) (map[string]interface{}, error) {
+	document := map[string]interface{}{
+		"@timestamp": e.Time.UTC().Format(time.RFC3339Nano),
+		"message":    e.Message(),
+		"ecs":        map[string]interface{}{"version": ECSVersion},
+		"log":        map[string]interface{}{"level": strings.ToLower(e.Level.String())},
+	}
+	for field, value := range map[string]string{"service.name": f.ServiceName, "service.version": f.ServiceVersion, "service.environment": f.ServiceEnvironment} {
+		if "" != value {
+			nestField(document, field, value)
+		}
+	}
+
+	fields := f.Fields
+	if nil == fields {
+		fields = DefaultECSFields
+	}
+	keys := make([]string, 0, len(e.Structure))
+	for key := range e.Structure {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value := e.Structure[key]
+		if ErrorKey == key {
+			if err := setECSError(document, value); nil == err {
+				continue
+			}
+		}
+		field, converted := key, ecsValue(value)
+		if _, isObject := converted.(map[string]interface{}); !isObject && "" != fields[key] {
+			field = fields[key]
+		}
+		if err := setECSField(document, field, converted); nil != err {
+			labels, _ := document["labels"].(map[string]interface{})
+			if nil == labels {
+				labels = map[string]interface{}{}
+				document["labels"] = labels
+			}
+			labels[strings.ReplaceAll(key, ".", "_")] = fmt.Sprint(value)
+		}
+	}
+	return document, ValidateECS(document)
+}
+
+// setECSError store the error under the error field set
+func setECSError(document map[string]interface{}, value interface{}) error {
+	var detail ErrorDetail
+	switch value := value.(type) {
+	case error:
+		detail = NewErrorDetail(value)
+	case ErrorDetail:
+		detail = value
+	case string:
+		detail = ErrorDetail{Message: value}
+	default:
+		return errors.New("not an error")
+	}
+	fields := map[string]interface{}{"message": detail.Message}
+	if "" != detail.Type {
+		fields["type"] = detail.Type
+	}
+	if 0 != len(detail.Stack) {
+		fields["stack_trace"] = strings.Join(detail.Stack, "\n")
+	}
+	return setECSField(document, "error", fields)
+}
+
+// ecsValue convert values without a JSON representation matching their ECS type
+func ecsValue(value interface{}) interface{} {
+	switch value := value.(type) {
+	case error:
+		return NewErrorDetail(value).Map()
+	case ErrorDetail:
+		return value.Map()
+	case StackTrace:
+		return strings.Join(value, "\n")
+	case time.Duration:
+		return int64(value)
+	case Structure:
+		return map[string]interface{}(value)
+	}
+	return value
+}
+
+// setECSField store the value under the field, if it doesn't break the schema. Maps are merged into the existing objects.
+func setECSField(document map[string]interface{}, field string, value interface{}) error {
+	if object, ok := value.(map[string]interface{}); ok {
+		// Check every field before setting any of them, so invalid maps are stored as labels as a whole
+		candidate := map[string]interface{}{}
+		for _, key := range sortedKeys(object) {
+			if err := nestField(candidate, field+"."+key, ecsValue(object[key])); nil != err {
+				return err
+			}
+		}
+		if err := ValidateECS(mergeDocuments(copyDocument(document), candidate)); nil != err {
+			return err
+		}
+		mergeDocuments(document, candidate)
+		return nil
+	}
+	if ecsFieldSets[field] {
+		return fmt.Errorf("ECS field set %s must be an object", field)
+	}
+	if expected, ok := ecsFields[field]; ok {
+		if err := checkECSType(field, expected, value); nil != err {
+			return err
+		}
+	}
+	return nestField(document, field, value)
+}
+
+// nestField store the value under the dotted path, creating the intermediate objects
+func nestField(document map[string]interface{}, path string, value interface{}) error {
+	parts := strings.Split(path, ".")
+	current := document
+	for i, part := range parts[:len(parts)-1] {
+		next, exists := current[part]
+		if !exists {
+			object := map[string]interface{}{}
+			current[part] = object
+			current = object
+			continue
+		}
+		object, ok := next.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("field %s conflicts with %s", path, strings.Join(parts[:i+1], "."))
+		}
+		current = object
+	}
+	last := parts[len(parts)-1]
+	if _, exists := current[last]; exists {
+		return fmt.Errorf("field %s already set", path)
+	}
+	current[last] = value
+	return nil
+}
+
+// mergeDocuments merge the source into the destination, which is sent back. Both must not share leaves.
+func mergeDocuments(destination, source map[string]interface{}) map[string]interface{} {
+	for key, value := range source {
+		existing, ok := destination[key].(map[string]interface{})
+		if object, isObject := value.(map[string]interface{}); ok && isObject {
+			mergeDocuments(existing, object)
+			continue
+		}
+		destination[key] = value
+	}
+	return destination
+}
+
+func copyDocument(document map[string]interface{}) map[string]interface{} {
+	copied := make(map[string]interface{}, len(document))
+	for key, value := range document {
+		if object, ok := value.(map[string]interface{}); ok {
+			value = copyDocument(object)
+		}
+		copied[key] = value
+	}
+	return copied
+}
+
+func sortedKeys(object map[string]interface{}) []string {
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ValidateECS check that the document follows the Elastic Common Schema (version ECSVersion): @timestamp, message, ecs.version and log.level are set, field sets are objects and the fields known by ECSFormatter have the right types.
+func ValidateECS(document map[string]interface{}) error {
+	var errs []error
+	for _, field := range []string{"@timestamp", "message", "ecs.version", "log.level"} {
+		if _, ok := lookupField(document, field); !ok {
+			errs = append(errs, fmt.Errorf("missing ECS field %s", field))
+		}
+	}
+	if version, ok := lookupField(document, "ecs.version"); ok && ECSVersion != version {
+		errs = append(errs, fmt.Errorf("unexpected ECS version %v (expected %s)", version, ECSVersion))
+	}
+	for _, key := range sortedKeys(document) {
+		if _, ok := document[key].(map[string]interface{}); !ok && ecsFieldSets[key] {
+			errs = append(errs, fmt.Errorf("ECS field set %s must be an object", key))
+		}
+	}
+	for field, expected := range ecsFields {
+		if value, ok := lookupField(document, field); ok {
+			if err := checkECSType(field, expected, value); nil != err {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// lookupField send back the value stored under the dotted path
+func lookupField(document map[string]interface{}, path string) (interface{}, bool) {
+	var current interface{} = document
+	for _, part := range strings.Split(path, ".") {
+		object, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if current, ok = object[part]; !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+func checkECSType(field string, expected ecsType, value interface{}) error {
+	valid := false
+	switch expected {
+	case ecsKeyword:
+		_, valid = value.(string)
+	case ecsLong:
+		switch value := value.(type) {
+		case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+			valid = true
+		case float64:
+			valid = value == math.Trunc(value)
+		}
+	case ecsDate:
+		switch value := value.(type) {
+		case time.Time:
+			valid = true
+		case string:
+			_, err := time.Parse(time.RFC3339Nano, value)
+			valid = nil == err
+		}
+	case ecsIP:
+		text, ok := value.(string)
+		valid = ok && nil != net.ParseIP(text)
+	}
+	if !valid {
+		return fmt.Errorf("invalid value %v (%T) for ECS field %s", value, value, field)
+	}
+	return nil
+}
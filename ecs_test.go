@@ -0,0 +1,135 @@
+package log
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestECSFormatter(t *testing.T) {
+	formatter := ECSFormatter{ServiceName: "api", ServiceVersion: "1.2.0"}
+	entry := testEntry(WARN, Structure{
+		"trace_id":          "4bf92f3577b34da6",
+		"status_code":       404,
+		"duration":          1500 * time.Millisecond,
+		"http.request.id":   "42",
+		"http.request.body": Structure{"bytes": 12},
+		"custom":            "value",
+		"message":           "clash",
+		"host":              Structure{"name": "web-1"},
+		"client_ip":         "not an ip",
+	}, "Message")
+	document, err := formatter.Format(entry)
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	expected := map[string]interface{}{
+		"@timestamp": "2016-08-13T15:40:05.123456Z",
+		"message":    "Message",
+		"ecs":        map[string]interface{}{"version": ECSVersion},
+		"log":        map[string]interface{}{"level": "warn"},
+		"service":    map[string]interface{}{"name": "api", "version": "1.2.0"},
+		"trace":      map[string]interface{}{"id": "4bf92f3577b34da6"},
+		"http": map[string]interface{}{
+			"request":  map[string]interface{}{"id": "42", "body": map[string]interface{}{"bytes": 12}},
+			"response": map[string]interface{}{"status_code": 404},
+		},
+		"event":  map[string]interface{}{"duration": int64(1500 * time.Millisecond)},
+		"host":   map[string]interface{}{"name": "web-1"},
+		"custom": "value",
+		"labels": map[string]interface{}{"message": "clash", "client_ip": "not an ip"},
+	}
+	if !reflect.DeepEqual(expected, document) {
+		t.Errorf("Error (Mismatched documents)\n\tExpected: %v\n\tReceived: %v", expected, document)
+	}
+}
+
+func TestECSFormatter_Error(t *testing.T) {
+	cause := errors.New("connection refused")
+	entry := testEntry(ERROR, Structure{}.WithError(fmt.Errorf("query failed: %w", cause)), "Message")
+	document, err := ECSFormatter{}.Format(entry)
+	if nil != err {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{"message": "query failed: connection refused", "type": "*fmt.wrapError"}
+	if !reflect.DeepEqual(expected, document["error"]) {
+		t.Errorf("Error (Mismatched errors) [Expected: '%v'; Received: '%v']", expected, document["error"])
+	}
+
+	document, err = ECSFormatter{}.Format(testEntry(ERROR, Structure{ErrorKey: "failure", StackKey: StackTrace{"main.main", "runtime.main"}}, "Message"))
+	if nil != err {
+		t.Fatal(err)
+	}
+	expected = map[string]interface{}{"message": "failure", "stack_trace": "main.main\nruntime.main"}
+	if !reflect.DeepEqual(expected, document["error"]) {
+		t.Errorf("Error (Mismatched errors) [Expected: '%v'; Received: '%v']", expected, document["error"])
+	}
+}
+
+func TestValidateECS(t *testing.T) {
+	valid := func() map[string]interface{} {
+		return map[string]interface{}{
+			"@timestamp": "2016-08-13T15:40:05.123456Z",
+			"message":    "Message",
+			"ecs":        map[string]interface{}{"version": ECSVersion},
+			"log":        map[string]interface{}{"level": "info"},
+		}
+	}
+	if err := ValidateECS(valid()); nil != err {
+		t.Errorf("Error (Valid document rejected) [Error: '%s']", err)
+	}
+
+	cases := []struct {
+		Name   string
+		Change func(map[string]interface{})
+		Error  string
+	}{
+		{Name: "missing level", Change: func(d map[string]interface{}) { delete(d, "log") }, Error: "missing ECS field log.level"},
+		{Name: "version", Change: func(d map[string]interface{}) { d["ecs"] = map[string]interface{}{"version": "1.0.0"} }, Error: "unexpected ECS version"},
+		{Name: "timestamp", Change: func(d map[string]interface{}) { d["@timestamp"] = "yesterday" }, Error: "ECS field @timestamp"},
+		{Name: "field set", Change: func(d map[string]interface{}) { d["host"] = "web-1" }, Error: "field set host must be an object"},
+		{Name: "long", Change: func(d map[string]interface{}) {
+			d["http"] = map[string]interface{}{"response": map[string]interface{}{"status_code": "404"}}
+		}, Error: "ECS field http.response.status_code"},
+		{Name: "ip", Change: func(d map[string]interface{}) { d["client"] = map[string]interface{}{"ip": "localhost"} }, Error: "ECS field client.ip"},
+	}
+	for _, test := range cases {
+		document := valid()
+		test.Change(document)
+		if err := ValidateECS(document); nil == err || !strings.Contains(err.Error(), test.Error) {
+			t.Errorf("Error (%s: mismatched errors) [Expected: '%s'; Received: '%v']", test.Name, test.Error, err)
+		}
+	}
+}
+
+func TestECSFormatter_Backends(t *testing.T) {
+	writer := &ElasticsearchWriter{Formatter: ECSFormatter{ServiceName: "api"}}
+	encoded, err := writer.Encode(testEntry(INFO, Structure{"trace_id": "abc"}, "Message"))
+	if nil != err {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(encoded)), "\n")
+	var document map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[1]), &document); nil != err {
+		t.Fatal(err)
+	}
+	if err := ValidateECS(document); nil != err {
+		t.Errorf("Error (Invalid document sent) [Error: '%s'; Document: '%s']", err, lines[1])
+	}
+	if "2016-08-13T15:40:05.123456Z" != document["@timestamp"] {
+		t.Errorf("Error (Mismatched timestamps) [Received: '%v']", document["@timestamp"])
+	}
+
+	payload, err := (&WebhookWriter{Formatter: ECSFormatter{}}).Encode([]Entry{testEntry(ERROR, Structure{}, "Message")})
+	if nil != err {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(payload), `"log":{"level":"error"}`) {
+		t.Errorf("Error (ECS not used by webhooks) [Received: '%s']", payload)
+	}
+}
@@ -0,0 +1,234 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Default index naming of the Elasticsearch backend
+const (
+	DefaultElasticsearchIndex = "logs"
+	DefaultIndexDateFormat    = "2006.01.02"
+)
+
+// ElasticsearchWriter index entries into Elasticsearch or OpenSearch using the _bulk API (URL being the address of the cluster, like http://localhost:9200). Entries are sent in the background, by batches of BatchSize entries or every BatchWait.
+//
+// Entries go to daily indices, named after Index and the UTC date of the entry formatted with IndexDateFormat (logs-2006.01.02 by default). Documents hold the Structure, the level, the message and the time under "@timestamp", unless another Formatter is given. Failed requests, and items rejected because the cluster is overloaded (429) or failing (5xx), are retried up to MaxRetries times, waiting from MinBackoff to MaxBackoff between attempts. Other rejected items are dropped and reported.
+type ElasticsearchWriter struct {
+	URL             string
+	Index           string
+	IndexDateFormat string
+	Username        string
+	Password        string
+	Formatter       Formatter
+	BatchSize       int
+	BatchWait       time.Duration
+	MaxRetries      int
+	MinBackoff      time.Duration
+	MaxBackoff      time.Duration
+	Client          *http.Client
+
+	once    sync.Once
+	batcher *batcher
+}
+
+func (w *ElasticsearchWriter) init() {
+	w.once.Do(func() {
+		if "" == w.Index {
+			w.Index = DefaultElasticsearchIndex
+		}
+		if "" == w.IndexDateFormat {
+			w.IndexDateFormat = DefaultIndexDateFormat
+		}
+		if 0 == w.MaxRetries {
+			w.MaxRetries = defaultMaxRetries
+		}
+		if 0 == w.MinBackoff {
+			w.MinBackoff = defaultMinBackoff
+		}
+		if 0 == w.MaxBackoff {
+			w.MaxBackoff = defaultMaxBackoff
+		}
+		if nil == w.Client {
+			w.Client = &http.Client{Timeout: defaultTimeout}
+		}
+		w.batcher = newBatcher("Elasticsearch", w.BatchSize, w.BatchWait, w.send)
+	})
+}
+
+// WriteEntry queue the entry, to be indexed with the next batch
+func (w *ElasticsearchWriter) WriteEntry(e Entry) error {
+	w.init()
+	w.batcher.add(e)
+	return nil
+}
+
+// Flush index the queued entries
+func (w *ElasticsearchWriter) Flush() error {
+	w.init()
+	return w.batcher.flush()
+}
+
+// Close stop indexing entries in the background and index the queued ones
+func (w *ElasticsearchWriter) Close() error {
+	w.init()
+	return w.batcher.close()
+}
+
+// IndexName send back the name of the index receiving the entry
+func (w *ElasticsearchWriter) IndexName(e Entry) string {
+	w.init()
+	return w.Index + "-" + e.Time.UTC().Format(w.IndexDateFormat)
+}
+
+// Encode serialize the entry as a bulk create action, followed by its document (each on a single line)
+func (w *ElasticsearchWriter) Encode(e Entry) ([]byte, error) {
+	action, err := json.Marshal(map[string]interface{}{
+		"create": map[string]string{"_index": w.IndexName(e)},
+	})
+	if nil != err {
+		return nil, err
+	}
+	document, err := format(w.Formatter, e)
+	if nil != err {
+		return nil, err
+	}
+	if _, ok := document["@timestamp"]; !ok {
+		document["@timestamp"] = e.Time.UTC().Format(time.RFC3339Nano)
+	}
+	source, err := json.Marshal(document)
+	if nil != err {
+		return nil, err
+	}
+	return append(append(append(action, '\n'), source...), '\n'), nil
+}
+
+// bulkResponse is the part of the _bulk API response needed to find the rejected items
+type bulkResponse struct {
+	Errors bool                        `json:"errors"`
+	Items  []map[string]bulkItemResult `json:"items"`
+}
+
+// bulkItemResult is the result of a single action, by action type
+type bulkItemResult struct {
+	Index  string     `json:"_index"`
+	Status int        `json:"status"`
+	Error  *bulkError `json:"error"`
+}
+
+type bulkError struct {
+	Type   string `json:"type"`
+	Reason string `json:"reason"`
+}
+
+func (w *ElasticsearchWriter) send(entries []Entry) error {
+	var errs []error
+	pending := make([][]byte, 0, len(entries))
+	for _, e := range entries {
+		item, err := w.Encode(e)
+		if nil != err {
+			errs = append(errs, fmt.Errorf("entry '%s' not indexed: %w", e.Message(), err))
+			continue
+		}
+		pending = append(pending, item)
+	}
+	if 0 == len(pending) {
+		return errors.Join(errs...)
+	}
+
+	err := retry(w.MaxRetries, w.MinBackoff, w.MaxBackoff, func() error {
+		response, err := w.bulk(pending)
+		if nil != err {
+			return err
+		}
+		if !response.Errors {
+			return nil
+		}
+		if len(response.Items) != len(pending) {
+			return permanentError{fmt.Errorf("unexpected bulk response: %d items for %d actions", len(response.Items), len(pending))}
+		}
+
+		var retried [][]byte
+		for i, item := range response.Items {
+			for _, result := range item {
+				if nil == result.Error {
+					continue
+				}
+				if http.StatusTooManyRequests == result.Status || result.Status >= 500 {
+					retried = append(retried, pending[i])
+					continue
+				}
+				errs = append(errs, fmt.Errorf("document rejected by index %s (status %d): %s: %s", result.Index, result.Status, result.Error.Type, result.Error.Reason))
+			}
+		}
+		pending = retried
+		if 0 != len(pending) {
+			return fmt.Errorf("%d documents rejected temporarily", len(pending))
+		}
+		return nil
+	})
+	if nil != err {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+func (w *ElasticsearchWriter) bulk(items [][]byte) (bulkResponse, error) {
+	req, err := http.NewRequest(http.MethodPost, strings.TrimSuffix(w.URL, "/")+"/_bulk", bytes.NewReader(bytes.Join(items, nil)))
+	if nil != err {
+		return bulkResponse{}, permanentError{err}
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	if "" != w.Username {
+		req.SetBasicAuth(w.Username, w.Password)
+	}
+	resp, err := w.Client.Do(req)
+	if nil != err {
+		return bulkResponse{}, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return bulkResponse{}, checkResponse(resp)
+	}
+	defer resp.Body.Close()
+	var response bulkResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); nil != err {
+		return bulkResponse{}, fmt.Errorf("invalid bulk response: %w", err)
+	}
+	return response, nil
+}
+
+// ElasticsearchLog is an AgnosticLogger indexing entries at or above Level into Elasticsearch or OpenSearch through Writer. Queued entries are indexed before PANIC and FATAL entries end the program.
+type ElasticsearchLog struct {
+	Writer    *ElasticsearchWriter
+	Level     Level
+	structure Structure
+}
+
+// Log queue the entry, to be indexed as a document.
+func (l ElasticsearchLog) Log(lvl Level, str Structure, v ...interface{}) {
+	if l.Enabled(lvl) {
+		entry := NewEntry(lvl, Structure{}.With(l.structure).With(str), v...)
+		reportError("Elasticsearch", l.Writer.WriteEntry(entry))
+		if lvl >= FATAL {
+			reportError("Elasticsearch", l.Writer.Flush())
+		}
+		terminate(lvl, entry.Message())
+	}
+}
+
+// Enabled send back true if entries of the level are sent
+func (l ElasticsearchLog) Enabled(lvl Level) bool {
+	return nil != l.Writer && lvl >= l.Level
+}
+
+// With add some fields to a new logger created from the source and return it
+func (l ElasticsearchLog) With(str Structure) AgnosticLogger {
+	l.structure = Structure{}.With(l.structure).With(str)
+	return l
+}
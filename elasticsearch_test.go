@@ -0,0 +1,184 @@
+package log
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeElasticsearch is a _bulk endpoint validating the requests. Documents holding a "reject" field are rejected with the status it contains, once for 429.
+type fakeElasticsearch struct {
+	*httptest.Server
+	t         *testing.T
+	mutex     sync.Mutex
+	requests  int
+	documents map[string][]map[string]interface{}
+	rejected  map[string]bool
+}
+
+func newFakeElasticsearch(t *testing.T) *fakeElasticsearch {
+	server := &fakeElasticsearch{t: t, documents: make(map[string][]map[string]interface{}), rejected: make(map[string]bool)}
+	server.Server = httptest.NewServer(http.HandlerFunc(server.bulk))
+	return server
+}
+
+func (s *fakeElasticsearch) bulk(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.requests++
+	if http.MethodPost != r.Method || "/_bulk" != r.URL.Path || "application/x-ndjson" != r.Header.Get("Content-Type") {
+		http.Error(w, fmt.Sprintf("unexpected request %s %s (%s)", r.Method, r.URL.Path, r.Header.Get("Content-Type")), http.StatusBadRequest)
+		return
+	}
+	items, err := parseBulk(r)
+	if nil != err {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response := bulkResponse{}
+	for _, item := range items {
+		result := bulkItemResult{Index: item.index, Status: http.StatusCreated}
+
+		message := fmt.Sprint(item.document["message"])
+		if reject, ok := item.document["reject"].(float64); ok && !(http.StatusTooManyRequests == reject && s.rejected[message]) {
+			s.rejected[message] = true
+			result.Status = int(reject)
+			result.Error = &bulkError{Type: "rejected", Reason: message}
+			response.Errors = true
+		} else {
+			s.documents[item.index] = append(s.documents[item.index], item.document)
+		}
+		response.Items = append(response.Items, map[string]bulkItemResult{"create": result})
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+type bulkItem struct {
+	index    string
+	document map[string]interface{}
+}
+
+// parseBulk validate the NDJSON body: pairs of lines holding a create action and a document, each line ending with a new line
+func parseBulk(r *http.Request) ([]bulkItem, error) {
+	body := &bytes.Buffer{}
+	body.ReadFrom(r.Body)
+	if !bytes.HasSuffix(body.Bytes(), []byte("\n")) {
+		return nil, errors.New("body not ending with a new line")
+	}
+	var items []bulkItem
+	scanner := bufio.NewScanner(body)
+	for scanner.Scan() {
+		var action map[string]map[string]string
+		if err := json.Unmarshal(scanner.Bytes(), &action); nil != err {
+			return nil, fmt.Errorf("invalid action: %w", err)
+		}
+		if 1 != len(action) || "" == action["create"]["_index"] {
+			return nil, fmt.Errorf("unexpected action: %v", action)
+		}
+		if !scanner.Scan() {
+			return nil, errors.New("missing document")
+		}
+		var document map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &document); nil != err {
+			return nil, fmt.Errorf("invalid document: %w", err)
+		}
+		items = append(items, bulkItem{index: action["create"]["_index"], document: document})
+	}
+	return items, scanner.Err()
+}
+
+func TestElasticsearchLog(t *testing.T) {
+	server := newFakeElasticsearch(t)
+	defer server.Close()
+
+	writer := &ElasticsearchWriter{URL: server.URL, Index: "app"}
+	elasticsearch := ElasticsearchLog{Writer: writer, Level: INFO}
+	elasticsearch.Log(DEBUG, Structure{}, "Filtered")
+	elasticsearch.With(Structure{"user": "john"}).Log(WARN, Structure{"count": 2}, "Message")
+	if err := writer.Close(); nil != err {
+		t.Fatal(err)
+	}
+
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	index := "app-" + time.Now().UTC().Format(DefaultIndexDateFormat)
+	if 1 != len(server.documents) || 1 != len(server.documents[index]) {
+		t.Fatalf("Error (Mismatched documents) [Expected index: '%s'; Received: '%v']", index, server.documents)
+	}
+	document := server.documents[index][0]
+	expected := map[string]interface{}{"user": "john", "count": 2.0, "level": "warn", "message": "Message"}
+	for key, value := range expected {
+		if value != document[key] {
+			t.Errorf("Error (Mismatched values for key '%s') [Expected: '%v'; Received: '%v']", key, value, document[key])
+		}
+	}
+	if timestamp, err := time.Parse(time.RFC3339Nano, fmt.Sprint(document["@timestamp"])); nil != err || time.Since(timestamp) > time.Minute {
+		t.Errorf("Error (Invalid timestamp) [Received: '%v']", document["@timestamp"])
+	}
+}
+
+func TestElasticsearchWriter_IndexName(t *testing.T) {
+	writer := &ElasticsearchWriter{IndexDateFormat: "2006.01"}
+	entry := testEntry(INFO, Structure{}, "Message")
+	entry.Time = entry.Time.In(time.FixedZone("UTC+10", 10*3600)).Add(10 * time.Hour)
+	if name := writer.IndexName(entry); "logs-2016.08" != name {
+		t.Errorf("Error (Mismatched index names) [Expected: '%s'; Received: '%s']", "logs-2016.08", name)
+	}
+}
+
+func TestElasticsearchWriter_PartialFailures(t *testing.T) {
+	server := newFakeElasticsearch(t)
+	defer server.Close()
+
+	writer := &ElasticsearchWriter{URL: server.URL, MinBackoff: time.Millisecond}
+	defer writer.Close()
+	writer.WriteEntry(testEntry(INFO, Structure{}, "Indexed"))
+	writer.WriteEntry(testEntry(INFO, Structure{"reject": 400}, "Invalid"))
+	writer.WriteEntry(testEntry(INFO, Structure{"reject": 429}, "Overloaded"))
+	err := writer.Flush()
+	if nil == err || !strings.Contains(err.Error(), "Invalid") || strings.Contains(err.Error(), "Overloaded") {
+		t.Errorf("Error (Mismatched errors) [Received: '%v']", err)
+	}
+
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	documents := server.documents["logs-2016.08.13"]
+	if 2 != len(documents) || "Indexed" != documents[0]["message"] || "Overloaded" != documents[1]["message"] {
+		t.Errorf("Error (Mismatched documents) [Received: '%v']", documents)
+	}
+	if 2 != server.requests {
+		t.Errorf("Error (Mismatched number of requests) [Expected: '%d'; Received: '%d']", 2, server.requests)
+	}
+}
+
+func TestElasticsearchWriter_BoundedRetries(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	writer := &ElasticsearchWriter{URL: server.URL, MaxRetries: 2, MinBackoff: time.Millisecond}
+	defer writer.Close()
+	writer.WriteEntry(testEntry(INFO, Structure{}, "Message"))
+	if err := writer.Flush(); nil == err {
+		t.Error("Error (Failure not reported)")
+	}
+	if 3 != requests {
+		t.Errorf("Error (Mismatched number of requests) [Expected: '%d'; Received: '%d']", 3, requests)
+	}
+}
+
+func TestElasticsearchLog_NoWriter(t *testing.T) {
+	ElasticsearchLog{}.With(Structure{}).Log(PANIC, Structure{}, "Message")
+}
@@ -0,0 +1,221 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// MetricsKey is the key used to store Metrics in a Structure
+const MetricsKey = "metrics"
+
+// Limits of the CloudWatch Embedded Metric Format
+const (
+	emfMaxDimensions      = 30
+	emfMaxMetrics         = 100
+	emfMaxValues          = 100
+	emfMaxNameLength      = 255
+	emfMaxNamespaceLength = 255
+	emfMaxDimensionValue  = 1024
+	emfHighResolution     = 1
+)
+
+// MetricUnit is the unit of a metric, as defined by CloudWatch
+type MetricUnit string
+
+// Units supported by CloudWatch
+const (
+	UnitSeconds            MetricUnit = "Seconds"
+	UnitMicroseconds       MetricUnit = "Microseconds"
+	UnitMilliseconds       MetricUnit = "Milliseconds"
+	UnitBytes              MetricUnit = "Bytes"
+	UnitKilobytes          MetricUnit = "Kilobytes"
+	UnitMegabytes          MetricUnit = "Megabytes"
+	UnitGigabytes          MetricUnit = "Gigabytes"
+	UnitTerabytes          MetricUnit = "Terabytes"
+	UnitBits               MetricUnit = "Bits"
+	UnitKilobits           MetricUnit = "Kilobits"
+	UnitMegabits           MetricUnit = "Megabits"
+	UnitGigabits           MetricUnit = "Gigabits"
+	UnitTerabits           MetricUnit = "Terabits"
+	UnitPercent            MetricUnit = "Percent"
+	UnitCount              MetricUnit = "Count"
+	UnitBytesPerSecond     MetricUnit = "Bytes/Second"
+	UnitKilobytesPerSecond MetricUnit = "Kilobytes/Second"
+	UnitMegabytesPerSecond MetricUnit = "Megabytes/Second"
+	UnitGigabytesPerSecond MetricUnit = "Gigabytes/Second"
+	UnitTerabytesPerSecond MetricUnit = "Terabytes/Second"
+	UnitBitsPerSecond      MetricUnit = "Bits/Second"
+	UnitKilobitsPerSecond  MetricUnit = "Kilobits/Second"
+	UnitMegabitsPerSecond  MetricUnit = "Megabits/Second"
+	UnitGigabitsPerSecond  MetricUnit = "Gigabits/Second"
+	UnitTerabitsPerSecond  MetricUnit = "Terabits/Second"
+	UnitCountPerSecond     MetricUnit = "Count/Second"
+	UnitNone               MetricUnit = "None"
+)
+
+var metricUnits = map[MetricUnit]bool{
+	UnitSeconds: true, UnitMicroseconds: true, UnitMilliseconds: true,
+	UnitBytes: true, UnitKilobytes: true, UnitMegabytes: true, UnitGigabytes: true, UnitTerabytes: true,
+	UnitBits: true, UnitKilobits: true, UnitMegabits: true, UnitGigabits: true, UnitTerabits: true,
+	UnitPercent: true, UnitCount: true,
+	UnitBytesPerSecond: true, UnitKilobytesPerSecond: true, UnitMegabytesPerSecond: true, UnitGigabytesPerSecond: true, UnitTerabytesPerSecond: true,
+	UnitBitsPerSecond: true, UnitKilobitsPerSecond: true, UnitMegabitsPerSecond: true, UnitGigabitsPerSecond: true, UnitTerabitsPerSecond: true,
+	UnitCountPerSecond: true, UnitNone: true,
+}
+
+// Metric is a named metric, with one or more values measured in Unit (None by default). High resolution metrics are stored with a 1 second resolution instead of 1 minute.
+type Metric struct {
+	Name           string
+	Unit           MetricUnit
+	Values         []float64
+	HighResolution bool
+}
+
+// NewMetric create a standard resolution metric
+func NewMetric(name string, unit MetricUnit, values ...float64) Metric {
+	return Metric{Name: name, Unit: unit, Values: values}
+}
+
+// Metrics are metrics sent to CloudWatch through the logs, using the Embedded Metric Format. Metrics are recorded in Namespace, once for every set of DimensionSets (a single set holding every dimension by default), using the values given in Dimensions.
+type Metrics struct {
+	Namespace     string
+	Dimensions    map[string]string
+	DimensionSets [][]string
+	Metrics       []Metric
+}
+
+// dimensionSets send back the sets of dimensions used to record the metrics
+func (m Metrics) dimensionSets() [][]string {
+	if nil != m.DimensionSets {
+		return m.DimensionSets
+	}
+	names := make([]string, 0, len(m.Dimensions))
+	for name := range m.Dimensions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return [][]string{names}
+}
+
+// Validate check the metrics against the limits of the Embedded Metric Format and CloudWatch: at most 30 dimensions per set and 100 metrics holding at most 100 finite values each, names and values of valid lengths, known units, and no name shared by a dimension and a metric.
+func (m Metrics) Validate() error {
+	var errs []error
+	if "" == m.Namespace || len(m.Namespace) > emfMaxNamespaceLength {
+		errs = append(errs, fmt.Errorf("namespace must contain 1 to %d characters", emfMaxNamespaceLength))
+	}
+	for name, value := range m.Dimensions {
+		if "" == name || len(name) > emfMaxNameLength {
+			errs = append(errs, fmt.Errorf("dimension name '%s' must contain 1 to %d characters", name, emfMaxNameLength))
+		}
+		if "" == value || len(value) > emfMaxDimensionValue {
+			errs = append(errs, fmt.Errorf("value of dimension '%s' must contain 1 to %d characters", name, emfMaxDimensionValue))
+		}
+	}
+	for _, set := range m.dimensionSets() {
+		if len(set) > emfMaxDimensions {
+			errs = append(errs, fmt.Errorf("dimension set %v has more than %d dimensions", set, emfMaxDimensions))
+		}
+		for _, name := range set {
+			if _, ok := m.Dimensions[name]; !ok {
+				errs = append(errs, fmt.Errorf("dimension '%s' has no value", name))
+			}
+		}
+	}
+
+	if 0 == len(m.Metrics) || len(m.Metrics) > emfMaxMetrics {
+		errs = append(errs, fmt.Errorf("1 to %d metrics must be given", emfMaxMetrics))
+	}
+	names := make(map[string]bool, len(m.Metrics))
+	for _, metric := range m.Metrics {
+		if "" == metric.Name || len(metric.Name) > emfMaxNameLength {
+			errs = append(errs, fmt.Errorf("metric name '%s' must contain 1 to %d characters", metric.Name, emfMaxNameLength))
+		}
+		if _, ok := m.Dimensions[metric.Name]; ok || names[metric.Name] {
+			errs = append(errs, fmt.Errorf("metric name '%s' already used", metric.Name))
+		}
+		names[metric.Name] = true
+		if "" != metric.Unit && !metricUnits[metric.Unit] {
+			errs = append(errs, fmt.Errorf("unknown unit '%s' for metric '%s'", metric.Unit, metric.Name))
+		}
+		if 0 == len(metric.Values) || len(metric.Values) > emfMaxValues {
+			errs = append(errs, fmt.Errorf("metric '%s' must have 1 to %d values", metric.Name, emfMaxValues))
+		}
+		for _, value := range metric.Values {
+			if math.IsNaN(value) || math.IsInf(value, 0) {
+				errs = append(errs, fmt.Errorf("metric '%s' has an invalid value %v", metric.Name, value))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// EMFFormatter format entries holding Metrics (under MetricsKey) using the CloudWatch Embedded Metric Format: the metric definitions are rendered under "_aws", and the values of the dimensions and metrics as members of the document. Documents are built by Formatter (Entry.Document, with the time under TimeKey, by default), and entries without metrics are sent as they are.
+//
+// Use it with a JSONLog writing on the standard output of Lambda functions, or on a file read by the CloudWatch agent.
+type EMFFormatter struct {
+	Formatter Formatter
+}
+
+// Format build the document of the entry, with its metrics if any
+func (f EMFFormatter) Format(e Entry) (map[string]interface{}, error) {
+	var metrics *Metrics
+	switch value := e.Structure[MetricsKey].(type) {
+	case Metrics:
+		metrics = &value
+	case *Metrics:
+		metrics = value
+	}
+	if nil != metrics {
+		if err := metrics.Validate(); nil != err {
+			return nil, fmt.Errorf("invalid metrics: %w", err)
+		}
+		structure := Structure{}.With(e.Structure)
+		delete(structure, MetricsKey)
+		e.Structure = structure
+	}
+
+	document, err := format(f.Formatter, e)
+	if nil != err {
+		return nil, err
+	}
+	if nil == f.Formatter {
+		if _, ok := document[TimeKey]; !ok {
+			document[TimeKey] = e.Time.Format(time.RFC3339Nano)
+		}
+	}
+	if nil == metrics {
+		return document, nil
+	}
+
+	definitions := make([]map[string]interface{}, 0, len(metrics.Metrics))
+	for _, metric := range metrics.Metrics {
+		definition := map[string]interface{}{"Name": metric.Name}
+		if "" != metric.Unit {
+			definition["Unit"] = metric.Unit
+		}
+		if metric.HighResolution {
+			definition["StorageResolution"] = emfHighResolution
+		}
+		definitions = append(definitions, definition)
+		if 1 == len(metric.Values) {
+			document[metric.Name] = metric.Values[0]
+		} else {
+			document[metric.Name] = metric.Values
+		}
+	}
+	for name, value := range metrics.Dimensions {
+		document[name] = value
+	}
+	document["_aws"] = map[string]interface{}{
+		"Timestamp": e.Time.UnixMilli(),
+		"CloudWatchMetrics": []map[string]interface{}{{
+			"Namespace":  metrics.Namespace,
+			"Dimensions": metrics.dimensionSets(),
+			"Metrics":    definitions,
+		}},
+	}
+	return document, nil
+}
@@ -0,0 +1,211 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// checkEMFSpecification check a decoded document against the rules of the Embedded Metric Format specification
+func checkEMFSpecification(document map[string]interface{}) error {
+	metadata, ok := document["_aws"].(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("_aws must be an object")
+	}
+	if timestamp, ok := metadata["Timestamp"].(float64); !ok || timestamp != math.Trunc(timestamp) || timestamp < 0 {
+		return fmt.Errorf("Timestamp must be a number of milliseconds since epoch")
+	}
+	directives, ok := metadata["CloudWatchMetrics"].([]interface{})
+	if !ok {
+		return fmt.Errorf("CloudWatchMetrics must be an array")
+	}
+	for _, directive := range directives {
+		directive, ok := directive.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("MetricDirective must be an object")
+		}
+		if namespace, ok := directive["Namespace"].(string); !ok || "" == namespace {
+			return fmt.Errorf("Namespace must be a non-empty string")
+		}
+		dimensionSets, ok := directive["Dimensions"].([]interface{})
+		if !ok {
+			return fmt.Errorf("Dimensions must be an array")
+		}
+		for _, set := range dimensionSets {
+			set, ok := set.([]interface{})
+			if !ok || len(set) > 30 {
+				return fmt.Errorf("DimensionSet must be an array of at most 30 dimensions")
+			}
+			for _, name := range set {
+				name, ok := name.(string)
+				if !ok {
+					return fmt.Errorf("dimension names must be strings")
+				}
+				if _, ok := document[name].(string); !ok {
+					return fmt.Errorf("dimension %s must be a string member of the root node", name)
+				}
+			}
+		}
+		metrics, ok := directive["Metrics"].([]interface{})
+		if !ok || len(metrics) > 100 {
+			return fmt.Errorf("Metrics must be an array of at most 100 MetricDefinitions")
+		}
+		for _, metric := range metrics {
+			metric, ok := metric.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("MetricDefinition must be an object")
+			}
+			name, ok := metric["Name"].(string)
+			if !ok {
+				return fmt.Errorf("metric names must be strings")
+			}
+			if unit, ok := metric["Unit"]; ok && !metricUnits[MetricUnit(fmt.Sprint(unit))] {
+				return fmt.Errorf("unknown unit %v", unit)
+			}
+			if resolution, ok := metric["StorageResolution"]; ok && 1.0 != resolution && 60.0 != resolution {
+				return fmt.Errorf("StorageResolution must be 1 or 60")
+			}
+			switch value := document[name].(type) {
+			case float64:
+			case []interface{}:
+				if len(value) > 100 {
+					return fmt.Errorf("metric %s has more than 100 values", name)
+				}
+				for _, item := range value {
+					if _, ok := item.(float64); !ok {
+						return fmt.Errorf("values of metric %s must be numbers", name)
+					}
+				}
+			default:
+				return fmt.Errorf("metric %s must be a number member of the root node", name)
+			}
+		}
+	}
+	return nil
+}
+
+func decodeJSONDocument(t *testing.T, data []byte) map[string]interface{} {
+	var document map[string]interface{}
+	if err := json.Unmarshal(data, &document); nil != err {
+		t.Fatal(err)
+	}
+	return document
+}
+
+func TestEMFFormatter_Fixtures(t *testing.T) {
+	cases := map[string]Entry{
+		"single_metric.json": testEntry(INFO, Structure{"request_id": "42"}.WithMetrics(Metrics{
+			Namespace:  "app",
+			Dimensions: map[string]string{"Service": "api"},
+			Metrics:    []Metric{NewMetric("Latency", UnitMilliseconds, 12.5)},
+		}), "Request handled"),
+		"dimension_sets.json": testEntry(WARN, Structure{}.WithMetrics(Metrics{
+			Namespace:     "shop/orders",
+			Dimensions:    map[string]string{"Service": "api", "Region": "eu-west-1"},
+			DimensionSets: [][]string{{"Service", "Region"}, {"Service"}},
+			Metrics: []Metric{
+				{Name: "Orders", Unit: UnitCount, Values: []float64{3}, HighResolution: true},
+				NewMetric("Size", UnitBytes, 512, 1024, 2048),
+				NewMetric("Ratio", "", 0.5),
+			},
+		}), "Orders placed"),
+		"no_dimensions.json": testEntry(INFO, Structure{}.WithMetrics(Metrics{
+			Namespace: "app",
+			Metrics:   []Metric{NewMetric("ColdStart", UnitCount, 1)},
+		}), "Cold start"),
+	}
+
+	for fixture, entry := range cases {
+		expected, err := os.ReadFile(filepath.Join("testdata", "emf", fixture))
+		if nil != err {
+			t.Fatal(err)
+		}
+		if err := checkEMFSpecification(decodeJSONDocument(t, expected)); nil != err {
+			t.Fatalf("Error (Fixture %s not following the specification) [Error: '%s']", fixture, err)
+		}
+
+		buffer := &bytes.Buffer{}
+		JSONLog{Writer: buffer, Formatter: EMFFormatter{}}.Log(entry.Level, entry.Structure, entry.Values...)
+		document := decodeJSONDocument(t, buffer.Bytes())
+		// The time of the entry can't be set through a logger
+		document["time"] = "2016-08-13T15:40:05.123456Z"
+		document["_aws"].(map[string]interface{})["Timestamp"] = 1471102805123.0
+		if err := checkEMFSpecification(document); nil != err {
+			t.Errorf("Error (%s: output not following the specification) [Error: '%s']", fixture, err)
+		}
+		if !reflect.DeepEqual(decodeJSONDocument(t, expected), document) {
+			t.Errorf("Error (Mismatched documents for %s)\n\tExpected: %s\n\tReceived: %s", fixture, expected, buffer)
+		}
+
+		formatted, err := EMFFormatter{}.Format(entry)
+		if nil != err {
+			t.Fatal(err)
+		}
+		encoded, _ := json.Marshal(formatted)
+		if !reflect.DeepEqual(decodeJSONDocument(t, expected), decodeJSONDocument(t, encoded)) {
+			t.Errorf("Error (Mismatched documents for %s)\n\tExpected: %s\n\tReceived: %s", fixture, expected, encoded)
+		}
+	}
+}
+
+func TestEMFFormatter_NoMetrics(t *testing.T) {
+	document, err := EMFFormatter{}.Format(testEntry(INFO, Structure{"user": "john"}, "Message"))
+	if nil != err {
+		t.Fatal(err)
+	}
+	if _, ok := document["_aws"]; ok || "john" != document["user"] {
+		t.Errorf("Error (Entry without metrics changed) [Received: '%v']", document)
+	}
+}
+
+func TestMetrics_Validate(t *testing.T) {
+	valid := func() Metrics {
+		return Metrics{Namespace: "app", Dimensions: map[string]string{"Service": "api"}, Metrics: []Metric{NewMetric("Latency", UnitMilliseconds, 1)}}
+	}
+	if err := valid().Validate(); nil != err {
+		t.Fatalf("Error (Valid metrics rejected) [Error: '%s']", err)
+	}
+
+	tooManyDimensions := valid()
+	var set []string
+	for i := 0; i < 31; i++ {
+		name := fmt.Sprintf("Dimension%d", i)
+		tooManyDimensions.Dimensions[name] = "value"
+		set = append(set, name)
+	}
+	tooManyDimensions.DimensionSets = [][]string{set}
+
+	tooManyMetrics := valid()
+	for i := 0; i < 100; i++ {
+		tooManyMetrics.Metrics = append(tooManyMetrics.Metrics, NewMetric(fmt.Sprintf("Metric%d", i), UnitCount, 1))
+	}
+
+	cases := map[string]func(m *Metrics){
+		"namespace":          func(m *Metrics) { m.Namespace = "" },
+		"more than 30":       func(m *Metrics) { *m = tooManyDimensions },
+		"has no value":       func(m *Metrics) { m.DimensionSets = [][]string{{"Region"}} },
+		"value of dimension": func(m *Metrics) { m.Dimensions["Service"] = "" },
+		"1 to 100 metrics":   func(m *Metrics) { *m = tooManyMetrics },
+		"already used":       func(m *Metrics) { m.Metrics = append(m.Metrics, NewMetric("Service", UnitCount, 1)) },
+		"unknown unit":       func(m *Metrics) { m.Metrics[0].Unit = "Hours" },
+		"1 to 100 values":    func(m *Metrics) { m.Metrics[0].Values = make([]float64, 101) },
+		"invalid value":      func(m *Metrics) { m.Metrics[0].Values = []float64{math.NaN()} },
+		"metric name":        func(m *Metrics) { m.Metrics[0].Name = strings.Repeat("a", 256) },
+	}
+	for expected, change := range cases {
+		metrics := valid()
+		change(&metrics)
+		if err := metrics.Validate(); nil == err || !strings.Contains(err.Error(), expected) {
+			t.Errorf("Error (Mismatched errors) [Expected: '%s'; Received: '%v']", expected, err)
+		}
+		if _, err := (EMFFormatter{}).Format(testEntry(INFO, Structure{}.WithMetrics(metrics), "Message")); nil == err {
+			t.Errorf("Error (Invalid metrics formatted) [Expected: '%s']", expected)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package log
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Keys used to store the level, the message and the time of the entries in the documents sent by the backends
+const (
+	LevelKey   = "level"
+	MessageKey = "message"
+	TimeKey    = "time"
+)
+
+// Entry represent a single logged entry, as received by a logger
+type Entry struct {
+	Time      time.Time
+	Level     Level
+	Structure Structure
+	Values    []interface{}
+}
+
+// NewEntry create an Entry logged now. The Structure is copied so later changes to it doesn't affect the entry, and lazy values are evaluated.
+func NewEntry(lvl Level, str Structure, v ...interface{}) Entry {
+	return Entry{
+		Time:      time.Now(),
+		Level:     lvl,
+		Structure: resolveStructure(Structure{}.With(str)),
+		Values:    resolveValues(v),
+	}
+}
+
+// Message send back the message of the entry, built as the loggers would
+func (e Entry) Message() string {
+	return fmt.Sprint(e.Values...)
+}
+
+// Document send back the entry as a document, suitable for JSON-like encodings: the keys of the Structure (errors being nested objects), the level (under LevelKey) and the message (under MessageKey). Keys of the Structure clashing with them are prefixed by "fields.", as logrus does.
+func (e Entry) Document() map[string]interface{} {
+	document := make(map[string]interface{}, len(e.Structure)+2)
+	for key, value := range e.Structure {
+		if LevelKey == key || MessageKey == key {
+			key = "fields." + key
+		}
+		document[key] = documentValue(value)
+	}
+	document[LevelKey] = strings.ToLower(e.Level.String())
+	document[MessageKey] = e.Message()
+	return document
+}
+
+// documentValue send back the value as stored in documents: errors are nested objects
+func documentValue(value interface{}) interface{} {
+	switch value := value.(type) {
+	case error:
+		return NewErrorDetail(value).Map()
+	case ErrorDetail:
+		return value.Map()
+	}
+	return value
+}
+
+// LogTo log the entry through the given logger
+func (e Entry) LogTo(logger AgnosticLogger) {
+	logger.Log(e.Level, e.Structure, e.Values...)
+}
+
+// terminate end the program the way the standard loggers do after logging an entry: PANIC entries panic with the message, FATAL entries exit with status 1.
+func terminate(lvl Level, msg string) {
+	switch lvl {
+	case PANIC:
+		panic(msg)
+	case FATAL:
+		os.Exit(1)
+	}
+}
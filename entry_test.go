@@ -0,0 +1,53 @@
+package log
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNewEntry_StructureCopied(t *testing.T) {
+	structure := Structure{"Test": "test"}
+	entry := NewEntry(INFO, structure, "Message")
+	structure["Test"] = "other"
+
+	if "test" != entry.Structure["Test"] {
+		t.Errorf("Error (Mismatched strings) [Expected: '%s'; Received: '%v']", "test", entry.Structure["Test"])
+	}
+}
+
+func TestEntry_Message(t *testing.T) {
+	entry := NewEntry(INFO, Structure{}, "Message ", 2)
+	if "Message 2" != entry.Message() {
+		t.Errorf("Error (Mismatched strings) [Expected: '%s'; Received: '%s']", "Message 2", entry.Message())
+	}
+}
+
+func TestEntry_LogTo(t *testing.T) {
+	recorder := newRecordingLogger()
+	NewEntry(WARN, Structure{"Test": "test"}, "Message").LogTo(recorder)
+
+	entries := recorder.Entries()
+	if 1 != len(entries) || WARN != entries[0].Level || "Message" != entries[0].Message || "test" != entries[0].Structure["Test"] {
+		t.Errorf("Error (Mismatched entries) [Received: '%+v']", entries)
+	}
+}
+
+func TestEntry_Document(t *testing.T) {
+	entry := NewEntry(WARN, Structure{"user": "john", "message": "clash", "error": errors.New("test")}, "Message")
+
+	document := entry.Document()
+	expected := map[string]interface{}{
+		"user":           "john",
+		"fields.message": "clash",
+		"level":          "warn",
+		"message":        "Message",
+	}
+	for key, value := range expected {
+		if value != document[key] {
+			t.Errorf("Error (Mismatched values for key '%s') [Expected: '%v'; Received: '%v']", key, value, document[key])
+		}
+	}
+	if detail, ok := document["error"].(map[string]interface{}); !ok || "test" != detail["message"] {
+		t.Errorf("Error (Error not nested) [Received: '%+v']", document["error"])
+	}
+}
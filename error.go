@@ -0,0 +1,120 @@
+package log
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ErrorKey is the key used by Structure.WithError to store errors
+const ErrorKey = "error"
+
+// maxErrorDepth limits how deep the chain of wrapped errors is followed
+const maxErrorDepth = 32
+
+// ErrorDetail describe an error in a way that can be rendered by every logger: its message, its type, the errors it wraps and the stack trace it carries (if any).
+type ErrorDetail struct {
+	Message string
+	Type    string
+	Causes  []ErrorDetail
+	Stack   StackTrace
+}
+
+// NewErrorDetail extract the details of the given error, following the chain of wrapped errors (Unwrap() error and Unwrap() []error).
+func NewErrorDetail(err error) ErrorDetail {
+	return newErrorDetail(err, 0)
+}
+
+func newErrorDetail(err error, depth int) ErrorDetail {
+	if nil == err {
+		return ErrorDetail{}
+	}
+	detail := ErrorDetail{
+		Message: err.Error(),
+		Type:    fmt.Sprintf("%T", err),
+		Stack:   stackOf(err),
+	}
+	if depth >= maxErrorDepth {
+		return detail
+	}
+	for _, cause := range unwrap(err) {
+		if nil != cause {
+			detail.Causes = append(detail.Causes, newErrorDetail(cause, depth+1))
+		}
+	}
+	return detail
+}
+
+// Map send back the details as nested maps, suitable for JSON encoding.
+func (e ErrorDetail) Map() map[string]interface{} {
+	toReturn := map[string]interface{}{
+		"message": e.Message,
+		"type":    e.Type,
+	}
+	if 0 != len(e.Causes) {
+		causes := make([]interface{}, 0, len(e.Causes))
+		for _, cause := range e.Causes {
+			causes = append(causes, cause.Map())
+		}
+		toReturn["causes"] = causes
+	}
+	if 0 != len(e.Stack) {
+		toReturn["stack"] = e.Stack
+	}
+	return toReturn
+}
+
+// Flatten send back the details as a flat Structure, every key being prefixed by the given prefix (eg: "error.message", "error.causes.0.type").
+func (e ErrorDetail) Flatten(prefix string) Structure {
+	toReturn := Structure{}
+	e.flattenInto(prefix, toReturn)
+	return toReturn
+}
+
+func (e ErrorDetail) flattenInto(prefix string, str Structure) {
+	str[prefix+".message"] = e.Message
+	str[prefix+".type"] = e.Type
+	for i, cause := range e.Causes {
+		cause.flattenInto(prefix+".causes."+strconv.Itoa(i), str)
+	}
+	if 0 != len(e.Stack) {
+		str[prefix+".stack"] = e.Stack.String()
+	}
+}
+
+// String representation of the error, as returned by its Error() method
+func (e ErrorDetail) String() string {
+	return e.Message
+}
+
+func unwrap(err error) []error {
+	switch wrapper := err.(type) {
+	case interface{ Unwrap() []error }:
+		return wrapper.Unwrap()
+	case interface{ Unwrap() error }:
+		return []error{wrapper.Unwrap()}
+	}
+	return nil
+}
+
+// stackOf send back the stack trace carried by the error. Errors exposing a StackTrace() method returning either program counters ([]uintptr) or a list of formattable frames (like github.com/pkg/errors) are supported.
+func stackOf(err error) StackTrace {
+	method := reflect.ValueOf(err).MethodByName("StackTrace")
+	if !method.IsValid() || 0 != method.Type().NumIn() || 1 != method.Type().NumOut() {
+		return nil
+	}
+	trace := method.Call(nil)[0]
+	if pcs, ok := trace.Interface().([]uintptr); ok {
+		return framesOf(pcs)
+	}
+	if reflect.Slice != trace.Kind() {
+		return nil
+	}
+	var toReturn StackTrace
+	for i := 0; i < trace.Len(); i++ {
+		frame := fmt.Sprintf("%+v", trace.Index(i).Interface())
+		toReturn = append(toReturn, strings.Replace(frame, "\n\t", " ", -1))
+	}
+	return toReturn
+}
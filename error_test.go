@@ -0,0 +1,166 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/Sirupsen/logrus"
+)
+
+type stackError struct {
+	pcs []uintptr
+}
+
+func (e stackError) Error() string {
+	return "stack error"
+}
+
+func (e stackError) StackTrace() []uintptr {
+	return e.pcs
+}
+
+func newStackError() error {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(1, pcs)
+	return stackError{pcs: pcs[:n]}
+}
+
+func TestNewErrorDetail_Nil(t *testing.T) {
+	detail := NewErrorDetail(nil)
+	if "" != detail.Message || "" != detail.Type {
+		t.Errorf("Error (Detail not empty) [Received: '%+v']", detail)
+	}
+}
+
+func TestNewErrorDetail_Wrapped(t *testing.T) {
+	root := errors.New("root")
+	err := fmt.Errorf("wrapper: %w", root)
+
+	detail := NewErrorDetail(err)
+	if err.Error() != detail.Message {
+		t.Errorf("Error (Mismatched strings) [Expected: '%s'; Received: '%s']", err.Error(), detail.Message)
+	}
+	if "*fmt.wrapError" != detail.Type {
+		t.Errorf("Error (Mismatched strings) [Expected: '%s'; Received: '%s']", "*fmt.wrapError", detail.Type)
+	}
+	if 1 != len(detail.Causes) {
+		t.Fatalf("Error (Mismatched number of causes) [Expected: '%d'; Received: '%d']", 1, len(detail.Causes))
+	}
+	if "root" != detail.Causes[0].Message {
+		t.Errorf("Error (Mismatched strings) [Expected: '%s'; Received: '%s']", "root", detail.Causes[0].Message)
+	}
+}
+
+func TestNewErrorDetail_Joined(t *testing.T) {
+	err := errors.Join(errors.New("first"), errors.New("second"))
+
+	detail := NewErrorDetail(err)
+	if 2 != len(detail.Causes) {
+		t.Fatalf("Error (Mismatched number of causes) [Expected: '%d'; Received: '%d']", 2, len(detail.Causes))
+	}
+	if "second" != detail.Causes[1].Message {
+		t.Errorf("Error (Mismatched strings) [Expected: '%s'; Received: '%s']", "second", detail.Causes[1].Message)
+	}
+}
+
+func TestNewErrorDetail_Stack(t *testing.T) {
+	detail := NewErrorDetail(newStackError())
+	if 0 == len(detail.Stack) {
+		t.Fatal("Error (No stack trace found)")
+	}
+	expect := "newStackError"
+	if !strings.Contains(detail.Stack[0], expect) {
+		t.Errorf("Error (Doesn't contains substring) [Expected: '%s'; Received: '%s']", expect, detail.Stack[0])
+	}
+}
+
+func TestErrorDetail_Flatten(t *testing.T) {
+	err := fmt.Errorf("wrapper: %w", errors.New("root"))
+
+	flat := NewErrorDetail(err).Flatten("error")
+	expected := map[string]string{
+		"error.message":          "wrapper: root",
+		"error.type":             "*fmt.wrapError",
+		"error.causes.0.message": "root",
+		"error.causes.0.type":    "*errors.errorString",
+	}
+	for key, value := range expected {
+		if value != flat[key] {
+			t.Errorf("Error (Mismatched strings for key '%s') [Expected: '%s'; Received: '%v']", key, value, flat[key])
+		}
+	}
+}
+
+func TestStructure_WithError(t *testing.T) {
+	err := errors.New("test")
+	structure := Structure{}.WithError(err)
+	if err != structure[ErrorKey] {
+		t.Errorf("Error (Mismatched errors) [Expected: '%s'; Received: '%v']", err, structure[ErrorKey])
+	}
+}
+
+func TestBasicLog_Error(t *testing.T) {
+	logger := log.Logger{}
+	buffer := &bytes.Buffer{}
+	logger.SetOutput(buffer)
+
+	basic := BasicLog{Logger: &logger, Level: DEBUG}
+	basic.Log(ERROR, Structure{}.WithError(errors.New("test")), "Message")
+
+	logMsg := buffer.String()
+	for _, expect := range []string{"error.message:test", "error.type:*errors.errorString"} {
+		if !strings.Contains(logMsg, expect) {
+			t.Errorf("Error (Doesn't contains substring) [Expected: '%s'; Received: '%s']", expect, logMsg)
+		}
+	}
+}
+
+func TestStructuredLog_Error_Text(t *testing.T) {
+	logger := logrus.New()
+	buffer := &bytes.Buffer{}
+	logger.Out = buffer
+	logger.Formatter = &logrus.TextFormatter{DisableColors: true, DisableTimestamp: true}
+
+	structured := StructuredLog{Logger: logger}
+	structured.Log(ERROR, Structure{}.WithError(errors.New("test")), "Message")
+
+	logMsg := buffer.String()
+	expect := "error.message=test"
+	if !strings.Contains(logMsg, expect) {
+		t.Errorf("Error (Doesn't contains substring) [Expected: '%s'; Received: '%s']", expect, logMsg)
+	}
+}
+
+func TestStructuredLog_Error_JSON(t *testing.T) {
+	logger := logrus.New()
+	buffer := &bytes.Buffer{}
+	logger.Out = buffer
+	logger.Formatter = &logrus.JSONFormatter{}
+
+	structured := StructuredLog{Logger: logger}
+	structured.Log(ERROR, Structure{}.WithError(fmt.Errorf("wrapper: %w", errors.New("root"))), "Message")
+
+	var decoded struct {
+		Error struct {
+			Message string
+			Causes  []struct {
+				Message string
+			}
+		}
+	}
+	if err := json.Unmarshal(buffer.Bytes(), &decoded); nil != err {
+		t.Fatal(err)
+	}
+	if "wrapper: root" != decoded.Error.Message {
+		t.Errorf("Error (Mismatched strings) [Expected: '%s'; Received: '%s']", "wrapper: root", decoded.Error.Message)
+	}
+	if 1 != len(decoded.Error.Causes) || "root" != decoded.Error.Causes[0].Message {
+		t.Errorf("Error (Mismatched causes) [Received: '%+v']", decoded.Error.Causes)
+	}
+}
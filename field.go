@@ -0,0 +1,165 @@
+package log
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// FieldType is the type of the value held by a Field
+type FieldType uint8
+
+// Types of the values of fields
+const (
+	AnyType FieldType = iota
+	StringType
+	IntType
+	FloatType
+	BoolType
+	DurationType
+	ErrorType
+)
+
+// Field is a typed field, created by String, Int, Duration, Bool, Err or Any. Only the member matching its Type is set, so loggers implementing FieldLogger can encode it without boxing its value in an interface.
+type Field struct {
+	Key       string
+	Type      FieldType
+	Integer   int64
+	Text      string
+	Interface interface{}
+}
+
+// String create a field holding a string
+func String(key, value string) Field {
+	return Field{Key: key, Type: StringType, Text: value}
+}
+
+// Int create a field holding an integer
+func Int(key string, value int) Field {
+	return Field{Key: key, Type: IntType, Integer: int64(value)}
+}
+
+// Int64 create a field holding a 64 bits integer
+func Int64(key string, value int64) Field {
+	return Field{Key: key, Type: IntType, Integer: value}
+}
+
+// Float64 create a field holding a floating point number
+func Float64(key string, value float64) Field {
+	return Field{Key: key, Type: FloatType, Integer: int64(math.Float64bits(value))}
+}
+
+// Bool create a field holding a boolean
+func Bool(key string, value bool) Field {
+	field := Field{Key: key, Type: BoolType}
+	if value {
+		field.Integer = 1
+	}
+	return field
+}
+
+// Duration create a field holding a duration
+func Duration(key string, value time.Duration) Field {
+	return Field{Key: key, Type: DurationType, Integer: int64(value)}
+}
+
+// Err create a field holding an error, under ErrorKey (as Structure.WithError)
+func Err(err error) Field {
+	return Field{Key: ErrorKey, Type: ErrorType, Interface: err}
+}
+
+// Any create a field holding any value, typed if it's one of the supported types
+func Any(key string, value interface{}) Field {
+	switch value := value.(type) {
+	case string:
+		return String(key, value)
+	case int:
+		return Int(key, value)
+	case int64:
+		return Int64(key, value)
+	case float64:
+		return Float64(key, value)
+	case bool:
+		return Bool(key, value)
+	case time.Duration:
+		return Duration(key, value)
+	case error:
+		return Field{Key: key, Type: ErrorType, Interface: value}
+	}
+	return Field{Key: key, Type: AnyType, Interface: value}
+}
+
+// Value send back the value of the field, as it would be stored in a Structure
+func (f Field) Value() interface{} {
+	switch f.Type {
+	case StringType:
+		return f.Text
+	case IntType:
+		return f.Integer
+	case FloatType:
+		return f.float()
+	case BoolType:
+		return 1 == f.Integer
+	case DurationType:
+		return time.Duration(f.Integer)
+	}
+	return f.Interface
+}
+
+func (f Field) float() float64 {
+	return math.Float64frombits(uint64(f.Integer))
+}
+
+// WithFields add the values of the given fields to the Structure
+func (s Structure) WithFields(fields ...Field) Structure {
+	if nil == s {
+		s = make(Structure, len(fields))
+	}
+	for _, field := range fields {
+		s[field.Key] = field.Value()
+	}
+	return s
+}
+
+// LogFields log the message and the fields through the logger: with its LogFields method if it's a FieldLogger, as a Structure otherwise.
+func LogFields(logger AgnosticLogger, lvl Level, msg string, fields ...Field) {
+	switch logger := logger.(type) {
+	case callerFieldLogger:
+		logger.logFields(2, lvl, msg, fields...)
+	case FieldLogger:
+		logger.LogFields(lvl, msg, fields...)
+	default:
+		logger.Log(lvl, Structure{}.WithFields(fields...), msg)
+	}
+}
+
+// callerFieldLogger is implemented by the field loggers reporting the caller of the logging function, which need to know how many frames to skip
+type callerFieldLogger interface {
+	logFields(calldepth int, lvl Level, msg string, fields ...Field)
+}
+
+// redefinesKeys send back true if some fields share their key with the Structure or with a previous field
+func redefinesKeys(str Structure, fields []Field) bool {
+	for i, field := range fields {
+		if _, ok := str[field.Key]; ok {
+			return true
+		}
+		for _, previous := range fields[:i] {
+			if previous.Key == field.Key {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// maxPooledBuffer is the capacity above which line buffers aren't reused, so a few huge entries don't hold memory forever
+const maxPooledBuffer = 64 << 10
+
+// lineBuffers hold the buffers used by the loggers to encode the lines of LogFields
+var lineBuffers = sync.Pool{
+	New: func() interface{} {
+		buffer := make([]byte, 0, 512)
+		return &buffer
+	},
+}
@@ -0,0 +1,230 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestField_Value(t *testing.T) {
+	err := errors.New("failure")
+	cases := []struct {
+		Field    Field
+		Type     FieldType
+		Expected interface{}
+	}{
+		{String("user", "john"), StringType, "john"},
+		{Int("count", 2), IntType, int64(2)},
+		{Int64("id", -42), IntType, int64(-42)},
+		{Float64("ratio", 0.5), FloatType, 0.5},
+		{Bool("ok", true), BoolType, true},
+		{Bool("ok", false), BoolType, false},
+		{Duration("elapsed", time.Second), DurationType, time.Second},
+		{Err(err), ErrorType, err},
+		{Any("count", 2), IntType, int64(2)},
+		{Any("elapsed", time.Second), DurationType, time.Second},
+		{Any("cause", err), ErrorType, err},
+		{Any("ids", uint8(3)), AnyType, uint8(3)},
+	}
+	for _, test := range cases {
+		if test.Type != test.Field.Type || test.Expected != test.Field.Value() {
+			t.Errorf("Error (Mismatched values for '%s') [Expected: '%v' (%d); Received: '%v' (%d)]", test.Field.Key, test.Expected, test.Type, test.Field.Value(), test.Field.Type)
+		}
+	}
+	if ErrorKey != Err(err).Key {
+		t.Errorf("Error (Mismatched keys) [Expected: '%s'; Received: '%s']", ErrorKey, Err(err).Key)
+	}
+}
+
+func TestLogFields(t *testing.T) {
+	logger := newRecordingLogger()
+	LogFields(logger, WARN, "Message", String("user", "john"), Int("count", 2))
+	entries := logger.Entries()
+	if 1 != len(entries) {
+		t.Fatalf("Error (Mismatched number of entries) [Expected: '%d'; Received: '%d']", 1, len(entries))
+	}
+	if entry := entries[0]; WARN != entry.Level || "Message" != entry.Message || "john" != entry.Structure["user"] || int64(2) != entry.Structure["count"] {
+		t.Errorf("Error (Mismatched entries) [Received: '%+v']", entry)
+	}
+}
+
+func TestJSONLog_LogFields(t *testing.T) {
+	err := errors.New("failure")
+	fields := []Field{
+		String("user", "<john> \"doe\"\n \xff"),
+		Int("count", 2),
+		Float64("ratio", 1e-7),
+		Float64("big", 1e21),
+		Bool("ok", true),
+		Duration("elapsed", 1500*time.Millisecond),
+		Err(err),
+		Any("ids", []int{1, 2}),
+		String("message", "clash"),
+	}
+	for _, logger := range []AgnosticLogger{JSONLog{Level: INFO}, JSONLog{Level: INFO}.With(Structure{"service": "api", "level": "clash"})} {
+		expected := &bytes.Buffer{}
+		received := &bytes.Buffer{}
+		structured := logger.(JSONLog)
+		structured.Writer = expected
+		structured.Log(WARN, Structure{}.WithFields(fields...), "Message")
+		typed := logger.(JSONLog)
+		typed.Writer = received
+		typed.LogFields(DEBUG, "Filtered", fields...)
+		typed.LogFields(WARN, "Message", fields...)
+
+		var expectedDocument, receivedDocument map[string]interface{}
+		if err := json.Unmarshal(expected.Bytes(), &expectedDocument); nil != err {
+			t.Fatal(err)
+		}
+		if err := json.Unmarshal(received.Bytes(), &receivedDocument); nil != err {
+			t.Fatalf("Error (Invalid JSON) [Received: '%s'; Error: '%s']", received, err)
+		}
+		delete(expectedDocument, TimeKey)
+		if logged, err := time.Parse(time.RFC3339Nano, receivedDocument[TimeKey].(string)); nil != err || time.Since(logged) > time.Minute {
+			t.Errorf("Error (Invalid time) [Received: '%v']", receivedDocument[TimeKey])
+		}
+		delete(receivedDocument, TimeKey)
+		expectedJSON, _ := json.Marshal(expectedDocument)
+		receivedJSON, _ := json.Marshal(receivedDocument)
+		if string(expectedJSON) != string(receivedJSON) {
+			t.Errorf("Error (Mismatched documents) [Expected: '%s'; Received: '%s']", expectedJSON, receivedJSON)
+		}
+		if 1 != strings.Count(received.String(), "\n") {
+			t.Errorf("Error (Mismatched number of lines) [Received: '%s']", received)
+		}
+	}
+}
+
+func TestAppendJSON(t *testing.T) {
+	for _, value := range []string{"", "plain", "<a&b>", "quote\" backslash\\ slash/", "\x00\x1f\t\r\n", "  ", "invalid \xff\xfe utf-8", "héllo wörld 日本"} {
+		expected, _ := json.Marshal(value)
+		if received := appendJSONString(nil, value); string(expected) != string(received) {
+			t.Errorf("Error (Mismatched strings) [Expected: '%s'; Received: '%s']", expected, received)
+		}
+	}
+	for _, value := range []float64{0, -0.5, 1, 1e20, 1e21, 1e-6, 1e-7, 123456789.123, -1.5e-9, math.MaxFloat64} {
+		expected, _ := json.Marshal(value)
+		if received := appendJSONFloat(nil, value); string(expected) != string(received) {
+			t.Errorf("Error (Mismatched numbers) [Expected: '%s'; Received: '%s']", expected, received)
+		}
+	}
+	if received := appendJSONFloat(nil, math.Inf(-1)); `"-Inf"` != string(received) {
+		t.Errorf("Error (Mismatched numbers) [Expected: '%s'; Received: '%s']", `"-Inf"`, received)
+	}
+}
+
+func TestBasicLog_LogFields(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	basic := BasicLog{Logger: log.New(buffer, "", 0), Level: INFO}
+	basic.LogFields(DEBUG, "Filtered", String("user", "john"))
+	basic.LogFields(WARN, "Message")
+	basic.LogFields(ERROR, "Message", String("user", "john"), Int("count", 2), Float64("ratio", 0.5), Bool("ok", true), Duration("elapsed", time.Second))
+	basic.With(Structure{"service": "api"}).(BasicLog).LogFields(INFO, "Message", Err(errors.New("failure")))
+
+	expected := "[WARN]Message\n" +
+		"[ERROR]Message [user:john;count:2;ratio:0.5;ok:true;elapsed:1s]\n"
+	if !strings.HasPrefix(buffer.String(), expected) {
+		t.Errorf("Error (Mismatched strings) [Expected: '%s'; Received: '%s']", expected, buffer)
+	}
+	last := strings.TrimPrefix(buffer.String(), expected)
+	for _, part := range []string{"[INFO]Message [", "service:api", "error.message:failure", "error.type:*errors.errorString"} {
+		if !strings.Contains(last, part) {
+			t.Errorf("Error (Missing field) [Expected: '%s'; Received: '%s']", part, last)
+		}
+	}
+}
+
+func TestLogFields_RedefinedKeys(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	json := JSONLog{Writer: buffer, Level: INFO}.With(Structure{"user": "jane", "service": "api"})
+	LogFields(json, INFO, "Message", String("user", "john"), Int("count", 1), Int("count", 2))
+	line := buffer.String()
+	if 1 != strings.Count(line, `"user"`) || 1 != strings.Count(line, `"count"`) || !strings.Contains(line, `"user":"john"`) || !strings.Contains(line, `"count":2`) || !strings.Contains(line, `"service":"api"`) {
+		t.Errorf("Error (Mismatched JSON fields) [Received: '%s']", line)
+	}
+
+	buffer.Reset()
+	basic := BasicLog{Logger: log.New(buffer, "", 0), Level: INFO}.With(Structure{"user": "jane", "service": "api"})
+	LogFields(basic, INFO, "Message", String("user", "john"), Int("count", 1), Int("count", 2))
+	line = buffer.String()
+	if 1 != strings.Count(line, "user:") || 1 != strings.Count(line, "count:") || !strings.Contains(line, "user:john") || !strings.Contains(line, "count:2") || !strings.Contains(line, "service:api") {
+		t.Errorf("Error (Mismatched fields) [Received: '%s']", line)
+	}
+}
+
+func TestBasicLog_LogFieldsCaller(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	basic := BasicLog{Logger: log.New(buffer, "", log.Lshortfile), Level: INFO}
+	basic.LogFields(INFO, "Method", String("user", "john"))
+	LogFields(basic, INFO, "Function", String("user", "john"))
+
+	for _, line := range strings.Split(strings.TrimSpace(buffer.String()), "\n") {
+		if !strings.HasPrefix(line, "field_test.go:") {
+			t.Errorf("Error (Mismatched caller) [Expected: '%s'; Received: '%s']", "field_test.go", line)
+		}
+	}
+}
+
+func TestLogFields_DisabledAllocations(t *testing.T) {
+	err := errors.New("failure")
+	json := JSONLog{Writer: io.Discard, Level: INFO}
+	basic := BasicLog{Logger: log.New(io.Discard, "", 0), Level: INFO}
+	for name, function := range map[string]func(){
+		"JSONLog": func() {
+			json.LogFields(DEBUG, "Message", String("user", "john"), Int("count", 2), Duration("elapsed", time.Second), Err(err))
+		},
+		"BasicLog": func() {
+			basic.LogFields(DEBUG, "Message", String("user", "john"), Int("count", 2), Duration("elapsed", time.Second), Err(err))
+		},
+	} {
+		if allocations := testing.AllocsPerRun(100, function); 0 != allocations {
+			t.Errorf("Error (Allocations for disabled levels of %s) [Received: '%v']", name, allocations)
+		}
+	}
+}
+
+func BenchmarkJSONLog_Log(b *testing.B) {
+	logger := JSONLog{Writer: io.Discard, Level: INFO}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		logger.Log(INFO, Structure{"user": "john", "count": i, "elapsed": time.Second}, "Message")
+	}
+}
+
+func BenchmarkJSONLog_LogFields(b *testing.B) {
+	logger := JSONLog{Writer: io.Discard, Level: INFO}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		logger.LogFields(INFO, "Message", String("user", "john"), Int("count", i), Duration("elapsed", time.Second))
+	}
+}
+
+func BenchmarkJSONLog_LogFields_Disabled(b *testing.B) {
+	logger := JSONLog{Writer: io.Discard, Level: INFO}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		logger.LogFields(DEBUG, "Message", String("user", "john"), Int("count", i), Duration("elapsed", time.Second))
+	}
+}
+
+func BenchmarkBasicLog_LogFields(b *testing.B) {
+	logger := BasicLog{Logger: log.New(io.Discard, "", 0), Level: INFO}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		logger.LogFields(INFO, "Message", String("user", "john"), Int("count", i), Bool("ok", true))
+	}
+}
+
+func BenchmarkBasicLog_LogFields_Disabled(b *testing.B) {
+	logger := BasicLog{Logger: log.New(io.Discard, "", 0), Level: INFO}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		logger.LogFields(DEBUG, "Message", String("user", "john"), Int("count", i), Bool("ok", true))
+	}
+}
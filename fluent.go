@@ -0,0 +1,259 @@
+package log
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// FluentWriter send entries to Fluentd or Fluent Bit, using the forward protocol (MessagePack over TCP). Network can be "tcp" (the default), "tls" (using TLSConfig) or "unix". Records are built by Formatter (Entry.Document by default).
+//
+// FluentLog send entries from a background goroutine, through a queue of QueueSize entries (DefaultQueueSize by default): entries logged while it's full are dropped. Entries are sent one by one in Message mode. When the server can't be reached, up to BufferSize entries are kept and sent with the next one, as a single Forward mode message. When RequireAck is set, every message waits for the acknowledgement of the server, and is sent again on a new connection if it doesn't come before Timeout: entries are delivered at least once.
+type FluentWriter struct {
+	Network    string
+	Address    string
+	TLSConfig  *tls.Config
+	Tag        string
+	RequireAck bool
+	Formatter  Formatter
+	BufferSize int
+	QueueSize  int
+	Timeout    time.Duration
+
+	once   sync.Once
+	sender *sender
+	mutex  sync.Mutex
+	conn   net.Conn
+	reader *bufio.Reader
+	buffer [][]byte
+}
+
+func (w *FluentWriter) init() {
+	w.once.Do(func() {
+		w.sender = newSender("fluent", w.QueueSize, w.WriteEntry)
+	})
+}
+
+// WriteEntry send the entry to the server, with the buffered entries if any
+func (w *FluentWriter) WriteEntry(e Entry) error {
+	record, err := format(w.Formatter, e)
+	if nil != err {
+		return err
+	}
+	event := w.encodeEvent(e, record)
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if 0 == len(w.buffer) {
+		err := w.send(func(option map[string]interface{}) []byte {
+			return w.encodeMessage(e, record, option)
+		})
+		if nil != err {
+			w.enqueue(event)
+		}
+		return err
+	}
+
+	w.enqueue(event)
+	return w.flush()
+}
+
+// Flush send the queued entries, and try to send the entries kept while the server was unreachable
+func (w *FluentWriter) Flush() error {
+	w.init()
+	w.sender.drain()
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.flush()
+}
+
+// Close send the queued entries and close the connection to the server. Buffered entries are lost, and entries logged afterward are dropped.
+func (w *FluentWriter) Close() error {
+	w.init()
+	w.sender.close()
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.buffer = nil
+	return w.disconnect()
+}
+
+func (w *FluentWriter) flush() error {
+	if 0 == len(w.buffer) {
+		return nil
+	}
+	err := w.send(func(option map[string]interface{}) []byte {
+		return w.encodeForward(w.buffer, option)
+	})
+	if nil == err {
+		w.buffer = nil
+	}
+	return err
+}
+
+func (w *FluentWriter) enqueue(event []byte) {
+	if 0 >= w.BufferSize {
+		return
+	}
+	if len(w.buffer) >= w.BufferSize {
+		w.buffer = w.buffer[1:]
+	}
+	w.buffer = append(w.buffer, event)
+}
+
+// send encode and send a message, waiting for its acknowledgement if needed. It's tried twice, on a new connection the second time.
+func (w *FluentWriter) send(encode func(option map[string]interface{}) []byte) error {
+	var err error
+	for attempt := 0; attempt < 2; attempt++ {
+		if nil == w.conn {
+			if err = w.connect(); nil != err {
+				continue
+			}
+		}
+		option := map[string]interface{}{}
+		if w.RequireAck {
+			if option["chunk"], err = newChunkID(); nil != err {
+				return err
+			}
+		}
+		if err = w.write(encode(option), option["chunk"]); nil == err {
+			return nil
+		}
+		w.disconnect()
+	}
+	return err
+}
+
+func (w *FluentWriter) write(msg []byte, chunk interface{}) error {
+	w.conn.SetDeadline(time.Now().Add(w.timeout()))
+	if _, err := w.conn.Write(msg); nil != err {
+		return err
+	}
+	if nil == chunk {
+		return nil
+	}
+
+	response, err := decodeMsgpack(w.reader)
+	if nil != err {
+		return err
+	}
+	if ack, ok := response.(map[string]interface{}); !ok || chunk != ack["ack"] {
+		return fmt.Errorf("unexpected acknowledgement %v for chunk %v", response, chunk)
+	}
+	return nil
+}
+
+func (w *FluentWriter) timeout() time.Duration {
+	if 0 == w.Timeout {
+		return defaultTimeout
+	}
+	return w.Timeout
+}
+
+func (w *FluentWriter) connect() error {
+	network := w.Network
+	if "" == network {
+		network = "tcp"
+	}
+	dialer := &net.Dialer{Timeout: w.timeout()}
+	var conn net.Conn
+	var err error
+	if "tls" == network {
+		conn, err = tls.DialWithDialer(dialer, "tcp", w.Address, w.TLSConfig)
+	} else {
+		conn, err = dialer.Dial(network, w.Address)
+	}
+	if nil != err {
+		return err
+	}
+	w.conn = conn
+	w.reader = bufio.NewReader(conn)
+	return nil
+}
+
+func (w *FluentWriter) disconnect() error {
+	if nil == w.conn {
+		return nil
+	}
+	err := w.conn.Close()
+	w.conn = nil
+	w.reader = nil
+	return err
+}
+
+// encodeEvent encode the entry as a forward protocol event: [time, record]
+func (w *FluentWriter) encodeEvent(e Entry, record map[string]interface{}) []byte {
+	buffer := &bytes.Buffer{}
+	encoder := msgpackEncoder{buffer: buffer}
+	encoder.writeArrayHeader(2)
+	encoder.writeEventTime(e.Time)
+	encoder.writeMap(record)
+	return buffer.Bytes()
+}
+
+// encodeMessage encode the entry in Message mode: [tag, time, record, option]
+func (w *FluentWriter) encodeMessage(e Entry, record, option map[string]interface{}) []byte {
+	buffer := &bytes.Buffer{}
+	encoder := msgpackEncoder{buffer: buffer}
+	encoder.writeArrayHeader(4)
+	encoder.writeString(w.Tag)
+	encoder.writeEventTime(e.Time)
+	encoder.writeMap(record)
+	encoder.writeMap(option)
+	return buffer.Bytes()
+}
+
+// encodeForward encode the events in Forward mode: [tag, [event...], option]
+func (w *FluentWriter) encodeForward(events [][]byte, option map[string]interface{}) []byte {
+	buffer := &bytes.Buffer{}
+	encoder := msgpackEncoder{buffer: buffer}
+	encoder.writeArrayHeader(3)
+	encoder.writeString(w.Tag)
+	encoder.writeArrayHeader(len(events))
+	for _, event := range events {
+		buffer.Write(event)
+	}
+	encoder.writeMap(option)
+	return buffer.Bytes()
+}
+
+func newChunkID() (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); nil != err {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(id), nil
+}
+
+// FluentLog is an AgnosticLogger sending entries at or above Level to Fluentd or Fluent Bit through Writer.
+type FluentLog struct {
+	Writer    *FluentWriter
+	Level     Level
+	structure Structure
+}
+
+// Log send the entry in the background, as a record holding the Structure, the level and the message.
+func (l FluentLog) Log(lvl Level, str Structure, v ...interface{}) {
+	if l.Enabled(lvl) {
+		entry := NewEntry(lvl, Structure{}.With(l.structure).With(str), v...)
+		l.Writer.init()
+		l.Writer.sender.log(entry)
+		terminate(lvl, entry.Message())
+	}
+}
+
+// Enabled send back true if entries of the level are sent
+func (l FluentLog) Enabled(lvl Level) bool {
+	return nil != l.Writer && lvl >= l.Level
+}
+
+// With add some fields to a new logger created from the source and return it
+func (l FluentLog) With(str Structure) AgnosticLogger {
+	l.structure = Structure{}.With(l.structure).With(str)
+	return l
+}
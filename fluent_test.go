@@ -0,0 +1,183 @@
+package log
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fluentMessage is a message received by the fake forward server
+type fluentMessage struct {
+	Tag    string
+	Events [][]interface{}
+	Option map[string]interface{}
+}
+
+// fakeFluentServer is an in-process forward protocol server. It acknowledges the messages asking for it, except the first ignoredAcks ones.
+type fakeFluentServer struct {
+	listener    net.Listener
+	messages    chan fluentMessage
+	mutex       sync.Mutex
+	ignoredAcks int
+}
+
+func newFakeFluentServer(t *testing.T, address string, ignoredAcks int) *fakeFluentServer {
+	listener, err := net.Listen("tcp", address)
+	if nil != err {
+		t.Fatal(err)
+	}
+	server := &fakeFluentServer{listener: listener, messages: make(chan fluentMessage, 10), ignoredAcks: ignoredAcks}
+	go server.serve()
+	return server
+}
+
+func (s *fakeFluentServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if nil != err {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeFluentServer) handle(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	for {
+		decoded, err := decodeMsgpack(reader)
+		if nil != err {
+			return
+		}
+		array := decoded.([]interface{})
+		msg := fluentMessage{Tag: array[0].(string), Option: array[len(array)-1].(map[string]interface{})}
+		if events, ok := array[1].([]interface{}); ok {
+			for _, event := range events {
+				msg.Events = append(msg.Events, event.([]interface{}))
+			}
+		} else {
+			msg.Events = [][]interface{}{{array[1], array[2]}}
+		}
+		s.messages <- msg
+
+		if chunk, ok := msg.Option["chunk"]; ok {
+			if s.ignoreAck() {
+				continue
+			}
+			buffer := &bytes.Buffer{}
+			encoder := msgpackEncoder{buffer: buffer}
+			encoder.writeMap(map[string]interface{}{"ack": chunk})
+			conn.Write(buffer.Bytes())
+		}
+	}
+}
+
+func (s *fakeFluentServer) ignoreAck() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if 0 < s.ignoredAcks {
+		s.ignoredAcks--
+		return true
+	}
+	return false
+}
+
+func (s *fakeFluentServer) receive(t *testing.T) fluentMessage {
+	select {
+	case msg := <-s.messages:
+		return msg
+	case <-time.After(5 * time.Second):
+		t.Fatal("Error (No message received)")
+	}
+	return fluentMessage{}
+}
+
+func TestFluentLog(t *testing.T) {
+	server := newFakeFluentServer(t, "127.0.0.1:0", 0)
+	defer server.listener.Close()
+
+	writer := &FluentWriter{Address: server.listener.Addr().String(), Tag: "app.logs"}
+	defer writer.Close()
+	fluent := FluentLog{Writer: writer, Level: INFO}
+	fluent.Log(DEBUG, Structure{}, "Filtered")
+	fluent.With(Structure{"user": "john"}).Log(WARN, Structure{"count": 2}, "Message")
+
+	msg := server.receive(t)
+	if "app.logs" != msg.Tag || 1 != len(msg.Events) {
+		t.Fatalf("Error (Mismatched message) [Received: '%+v']", msg)
+	}
+	eventTime, ok := msg.Events[0][0].(msgpackExtension)
+	if !ok || 0 != eventTime.Type || 8 != len(eventTime.Data) {
+		t.Errorf("Error (Time not sent as EventTime) [Received: '%#v']", msg.Events[0][0])
+	} else if seconds := binary.BigEndian.Uint32(eventTime.Data); time.Since(time.Unix(int64(seconds), 0)) > time.Minute {
+		t.Errorf("Error (Mismatched time) [Received: '%d']", seconds)
+	}
+	record := msg.Events[0][1].(map[string]interface{})
+	expected := map[string]interface{}{"user": "john", "count": int64(2), "level": "warn", "message": "Message"}
+	for key, value := range expected {
+		if value != record[key] {
+			t.Errorf("Error (Mismatched values for key '%s') [Expected: '%v'; Received: '%v']", key, value, record[key])
+		}
+	}
+}
+
+func TestFluentLog_Ack(t *testing.T) {
+	server := newFakeFluentServer(t, "127.0.0.1:0", 1)
+	defer server.listener.Close()
+
+	writer := &FluentWriter{Address: server.listener.Addr().String(), Tag: "app", RequireAck: true, Timeout: 200 * time.Millisecond}
+	defer writer.Close()
+	if err := writer.WriteEntry(testEntry(INFO, Structure{}, "Message")); nil != err {
+		t.Fatal(err)
+	}
+
+	first := server.receive(t)
+	second := server.receive(t)
+	if nil == first.Option["chunk"] || first.Option["chunk"] == second.Option["chunk"] {
+		t.Errorf("Error (Mismatched chunks) [First: '%v'; Second: '%v']", first.Option["chunk"], second.Option["chunk"])
+	}
+	if "Message" != second.Events[0][1].(map[string]interface{})["message"] {
+		t.Errorf("Error (Entry not sent again) [Received: '%+v']", second)
+	}
+}
+
+func TestFluentLog_Buffering(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if nil != err {
+		t.Fatal(err)
+	}
+	address := listener.Addr().String()
+	listener.Close()
+
+	writer := &FluentWriter{Address: address, Tag: "app", BufferSize: 10, Timeout: time.Second}
+	defer writer.Close()
+	for _, msg := range []string{"First", "Second"} {
+		if err := writer.WriteEntry(testEntry(INFO, Structure{}, msg)); nil == err {
+			t.Fatal("Error (Entry sent without server)")
+		}
+	}
+
+	server := newFakeFluentServer(t, address, 0)
+	defer server.listener.Close()
+	if err := writer.WriteEntry(testEntry(INFO, Structure{}, "Third")); nil != err {
+		t.Fatal(err)
+	}
+
+	msg := server.receive(t)
+	if 3 != len(msg.Events) {
+		t.Fatalf("Error (Mismatched number of events) [Expected: '%d'; Received: '%d']", 3, len(msg.Events))
+	}
+	for i, expect := range []string{"First", "Second", "Third"} {
+		if received := msg.Events[i][1].(map[string]interface{})["message"]; expect != received {
+			t.Errorf("Error (Mismatched strings) [Expected: '%s'; Received: '%v']", expect, received)
+		}
+	}
+}
+
+func TestFluentLog_NoWriter(t *testing.T) {
+	FluentLog{}.With(Structure{}).Log(PANIC, Structure{}, "Message")
+}
@@ -0,0 +1,14 @@
+package log
+
+// Formatter build the documents sent by the JSON-producing backends (Elasticsearch, Loki, webhooks and Fluent) from the entries, to follow the schema expected by the receiving side. When no Formatter is given, these backends use Entry.Document.
+type Formatter interface {
+	Format(e Entry) (map[string]interface{}, error)
+}
+
+// format build the document of the entry using the formatter, if any
+func format(formatter Formatter, e Entry) (map[string]interface{}, error) {
+	if nil == formatter {
+		return e.Document(), nil
+	}
+	return formatter.Format(e)
+}
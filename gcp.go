@@ -0,0 +1,212 @@
+package log
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Special fields of the Google Cloud Logging structured logs
+const (
+	gcpTraceKey          = "logging.googleapis.com/trace"
+	gcpSpanKey           = "logging.googleapis.com/spanId"
+	gcpSourceLocationKey = "logging.googleapis.com/sourceLocation"
+	gcpHTTPRequestKey    = "httpRequest"
+)
+
+// Default keys of the Structure read by GCPFormatter
+const (
+	DefaultGCPTraceKey       = "trace_id"
+	DefaultGCPSpanKey        = "span_id"
+	DefaultGCPCallerKey      = "caller"
+	DefaultGCPHTTPRequestKey = "http_request"
+)
+
+// gcpSeverities are the Cloud Logging severities, indexed by syslog severity
+var gcpSeverities = []string{"EMERGENCY", "ALERT", "CRITICAL", "ERROR", "WARNING", "NOTICE", "INFO", "DEBUG"}
+
+// GCPSeverity send back the Cloud Logging severity matching the level: EMERGENCY for PANIC, CRITICAL for FATAL, ERROR, WARNING, INFO, and DEBUG for DEBUG and TRACE.
+func GCPSeverity(lvl Level) string {
+	return gcpSeverities[lvl.Severity()]
+}
+
+// GCPHTTPRequest describe an HTTP request, for the httpRequest field of Cloud Logging
+type GCPHTTPRequest struct {
+	Method       string
+	URL          string
+	Status       int
+	RequestSize  int64
+	ResponseSize int64
+	UserAgent    string
+	RemoteIP     string
+	ServerIP     string
+	Referer      string
+	Latency      time.Duration
+	Protocol     string
+}
+
+// NewGCPHTTPRequest describe the request, which got a response with the given status after latency
+func NewGCPHTTPRequest(req *http.Request, status int, latency time.Duration) GCPHTTPRequest {
+	return GCPHTTPRequest{
+		Method:      req.Method,
+		URL:         req.URL.String(),
+		Status:      status,
+		RequestSize: req.ContentLength,
+		UserAgent:   req.UserAgent(),
+		RemoteIP:    req.RemoteAddr,
+		Referer:     req.Referer(),
+		Latency:     latency,
+		Protocol:    req.Proto,
+	}
+}
+
+// document send back the request as a LogEntry HttpRequest object, omitting the unset fields
+func (r GCPHTTPRequest) document() map[string]interface{} {
+	document := make(map[string]interface{})
+	for key, value := range map[string]string{
+		"requestMethod": r.Method,
+		"requestUrl":    r.URL,
+		"userAgent":     r.UserAgent,
+		"remoteIp":      r.RemoteIP,
+		"serverIp":      r.ServerIP,
+		"referer":       r.Referer,
+		"protocol":      r.Protocol,
+	} {
+		if "" != value {
+			document[key] = value
+		}
+	}
+	if 0 != r.Status {
+		document["status"] = r.Status
+	}
+	// Sizes are int64, sent as strings in the JSON representation of LogEntry
+	if 0 < r.RequestSize {
+		document["requestSize"] = strconv.FormatInt(r.RequestSize, 10)
+	}
+	if 0 < r.ResponseSize {
+		document["responseSize"] = strconv.FormatInt(r.ResponseSize, 10)
+	}
+	if 0 != r.Latency {
+		document["latency"] = strconv.FormatFloat(r.Latency.Seconds(), 'f', -1, 64) + "s"
+	}
+	return document
+}
+
+// GCPFormatter format entries as the structured logs parsed by Google Cloud Logging (on GKE, Cloud Run or Cloud Functions): the level goes under severity, the message under message and the time under time.
+//
+// The values of the Structure stored under TraceKey, SpanKey, CallerKey and HTTPRequestKey (DefaultGCPTraceKey, DefaultGCPSpanKey, DefaultGCPCallerKey and DefaultGCPHTTPRequestKey by default) are moved to the matching special fields. Traces are prefixed by projects/ProjectID/traces/ when ProjectID is set. Callers can be formatted like the StackTrace frames ("function file:line") or be a StackTrace, the innermost frame of the stack under StackKey being used when there's no caller. HTTP requests can be GCPHTTPRequest, *http.Request or maps already following the LogEntry format. The rest of the Structure is kept, to end in jsonPayload.
+type GCPFormatter struct {
+	ProjectID      string
+	TraceKey       string
+	SpanKey        string
+	CallerKey      string
+	HTTPRequestKey string
+}
+
+// Format build the structured log of the entry
+func (f GCPFormatter) Format(e Entry) (map[string]interface{}, error) {
+	traceKey := defaultString(f.TraceKey, DefaultGCPTraceKey)
+	spanKey := defaultString(f.SpanKey, DefaultGCPSpanKey)
+	callerKey := defaultString(f.CallerKey, DefaultGCPCallerKey)
+	httpRequestKey := defaultString(f.HTTPRequestKey, DefaultGCPHTTPRequestKey)
+
+	fields := Structure{}.With(e.Structure)
+	document := Entry{Level: e.Level, Structure: fields, Values: e.Values}.Document()
+	delete(document, LevelKey)
+	document["severity"] = GCPSeverity(e.Level)
+	document["time"] = e.Time.UTC().Format(time.RFC3339Nano)
+
+	if trace, ok := fields[traceKey]; ok {
+		delete(document, traceKey)
+		document[gcpTraceKey] = f.trace(fmt.Sprint(trace))
+	}
+	if span, ok := fields[spanKey]; ok {
+		delete(document, spanKey)
+		document[gcpSpanKey] = fmt.Sprint(span)
+	}
+	if caller, ok := fields[callerKey]; ok {
+		if location, valid := gcpSourceLocation(caller); valid {
+			delete(document, callerKey)
+			document[gcpSourceLocationKey] = location
+		}
+	} else if stack, ok := fields[StackKey].(StackTrace); ok {
+		if location, valid := gcpSourceLocation(stack); valid {
+			document[gcpSourceLocationKey] = location
+		}
+	}
+	if request, ok := fields[httpRequestKey]; ok {
+		if converted, valid := gcpHTTPRequest(request); valid {
+			delete(document, httpRequestKey)
+			document[gcpHTTPRequestKey] = converted
+		}
+	}
+	return document, nil
+}
+
+func (f GCPFormatter) trace(trace string) string {
+	if "" == f.ProjectID || strings.HasPrefix(trace, "projects/") {
+		return trace
+	}
+	return "projects/" + f.ProjectID + "/traces/" + trace
+}
+
+// gcpSourceLocation parse a caller, formatted as "function file:line" or "file:line"
+func gcpSourceLocation(caller interface{}) (map[string]interface{}, bool) {
+	var frame string
+	switch caller := caller.(type) {
+	case StackTrace:
+		if 0 == len(caller) {
+			return nil, false
+		}
+		frame = caller[0]
+	case string:
+		frame = caller
+	default:
+		return nil, false
+	}
+
+	location := make(map[string]interface{})
+	if index := strings.LastIndexByte(frame, ' '); -1 != index {
+		location["function"] = frame[:index]
+		frame = frame[index+1:]
+	}
+	index := strings.LastIndexByte(frame, ':')
+	if -1 == index {
+		return nil, false
+	}
+	if _, err := strconv.Atoi(frame[index+1:]); nil != err {
+		return nil, false
+	}
+	location["file"] = frame[:index]
+	// line is an int64, sent as a string in the JSON representation of LogEntry
+	location["line"] = frame[index+1:]
+	return location, true
+}
+
+func gcpHTTPRequest(request interface{}) (interface{}, bool) {
+	switch request := request.(type) {
+	case GCPHTTPRequest:
+		return request.document(), true
+	case *GCPHTTPRequest:
+		return request.document(), nil != request
+	case *http.Request:
+		if nil == request {
+			return nil, false
+		}
+		return NewGCPHTTPRequest(request, 0, 0).document(), true
+	case map[string]interface{}:
+		return request, true
+	case Structure:
+		return map[string]interface{}(request), true
+	}
+	return nil, false
+}
+
+func defaultString(value, defaultValue string) string {
+	if "" == value {
+		return defaultValue
+	}
+	return value
+}
@@ -0,0 +1,109 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestGCPSeverity(t *testing.T) {
+	cases := map[Level]string{
+		PANIC: "EMERGENCY",
+		FATAL: "CRITICAL",
+		ERROR: "ERROR",
+		WARN:  "WARNING",
+		INFO:  "INFO",
+		DEBUG: "DEBUG",
+		TRACE: "DEBUG",
+	}
+	for lvl, expected := range cases {
+		if received := GCPSeverity(lvl); expected != received {
+			t.Errorf("Error (Mismatched severities for %s) [Expected: '%s'; Received: '%s']", lvl, expected, received)
+		}
+	}
+}
+
+func TestGCPFormatter(t *testing.T) {
+	formatter := GCPFormatter{ProjectID: "my-project"}
+	entry := testEntry(WARN, Structure{
+		"trace_id":     "4bf92f3577b34da6a3ce929d0e0e4736",
+		"span_id":      "00f067aa0ba902b7",
+		"caller":       "main.handle /app/main.go:42",
+		"http_request": GCPHTTPRequest{Method: "GET", URL: "/users", Status: 404, ResponseSize: 12, Latency: 1500 * time.Millisecond},
+		"user":         "john",
+	}, "Message")
+	document, err := formatter.Format(entry)
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	expected := map[string]interface{}{
+		"severity":                              "WARNING",
+		"message":                               "Message",
+		"time":                                  "2016-08-13T15:40:05.123456Z",
+		"logging.googleapis.com/trace":          "projects/my-project/traces/4bf92f3577b34da6a3ce929d0e0e4736",
+		"logging.googleapis.com/spanId":         "00f067aa0ba902b7",
+		"logging.googleapis.com/sourceLocation": map[string]interface{}{"function": "main.handle", "file": "/app/main.go", "line": "42"},
+		"httpRequest":                           map[string]interface{}{"requestMethod": "GET", "requestUrl": "/users", "status": 404, "responseSize": "12", "latency": "1.5s"},
+		"user":                                  "john",
+	}
+	if !reflect.DeepEqual(expected, document) {
+		t.Errorf("Error (Mismatched documents)\n\tExpected: %v\n\tReceived: %v", expected, document)
+	}
+}
+
+func TestGCPFormatter_Stack(t *testing.T) {
+	request := httptest.NewRequest("POST", "http://example.com/login", nil)
+	request.Header.Set("User-Agent", "test")
+	entry := testEntry(ERROR, Structure{
+		StackKey:       StackTrace{"main.main /app/main.go:10", "runtime.main /go/proc.go:250"},
+		"caller":       42,
+		"http_request": request,
+		"trace_id":     "projects/other/traces/abc",
+	}, "Message")
+	document, err := GCPFormatter{ProjectID: "my-project"}.Format(entry)
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	if 42 != document["caller"] {
+		t.Errorf("Error (Invalid caller not kept) [Received: '%v']", document["caller"])
+	}
+	if _, ok := document["logging.googleapis.com/sourceLocation"]; ok {
+		t.Errorf("Error (Stack used despite caller) [Received: '%v']", document)
+	}
+	if "projects/other/traces/abc" != document["logging.googleapis.com/trace"] {
+		t.Errorf("Error (Mismatched traces) [Received: '%v']", document["logging.googleapis.com/trace"])
+	}
+	expected := map[string]interface{}{"requestMethod": "POST", "requestUrl": "http://example.com/login", "userAgent": "test", "remoteIp": "192.0.2.1:1234", "protocol": "HTTP/1.1"}
+	if !reflect.DeepEqual(expected, document["httpRequest"]) {
+		t.Errorf("Error (Mismatched HTTP requests) [Expected: '%v'; Received: '%v']", expected, document["httpRequest"])
+	}
+
+	delete(entry.Structure, "caller")
+	document, _ = GCPFormatter{}.Format(entry)
+	location := map[string]interface{}{"function": "main.main", "file": "/app/main.go", "line": "10"}
+	if !reflect.DeepEqual(location, document["logging.googleapis.com/sourceLocation"]) {
+		t.Errorf("Error (Mismatched source locations) [Expected: '%v'; Received: '%v']", location, document["logging.googleapis.com/sourceLocation"])
+	}
+}
+
+func TestJSONLog_GCP(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	logger := JSONLog{Writer: buffer, Formatter: GCPFormatter{}, Level: DEBUG}.With(Structure{"user": "john"})
+	logger.Log(TRACE, Structure{}, "Filtered")
+	logger.Log(DEBUG, Structure{"trace_id": "abc"}, "Message")
+
+	var document map[string]interface{}
+	if err := json.Unmarshal(buffer.Bytes(), &document); nil != err {
+		t.Fatalf("Error (Invalid JSON line) [Error: '%s'; Received: '%s']", err, buffer)
+	}
+	for key, value := range map[string]interface{}{"severity": "DEBUG", "message": "Message", "user": "john", "logging.googleapis.com/trace": "abc"} {
+		if value != document[key] {
+			t.Errorf("Error (Mismatched values for key '%s') [Expected: '%v'; Received: '%v']", key, value, document[key])
+		}
+	}
+}
@@ -0,0 +1,245 @@
+package log
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// GELFCompression is the compression applied to GELF messages sent over UDP
+type GELFCompression int
+
+// Supported compressions
+const (
+	GELFGzip GELFCompression = iota
+	GELFZlib
+	GELFNoCompression
+)
+
+// GELF chunking limits
+const (
+	// DefaultGELFChunkSize is the default maximum size of the UDP datagrams, suitable for WAN
+	DefaultGELFChunkSize = 1420
+	gelfChunkHeaderSize  = 12
+	gelfMaxChunks        = 128
+)
+
+// gelfFieldName match the names allowed for additional fields
+var gelfFieldName = regexp.MustCompile(`[^\w\.\-]`)
+
+// GELFWriter send entries to Graylog, as GELF 1.1 messages. Network can be "udp", "tcp" or "tls" (using TLSConfig). Over UDP, messages are compressed (gzip by default) and split into chunks of at most ChunkSize bytes. Over TCP, they are sent uncompressed and delimited by a null byte.
+//
+// Host default to the hostname of the current machine and ChunkSize to DefaultGELFChunkSize. When the server can't be reached, up to BufferSize messages are kept and sent again with the next one. GELFLog send entries from a background goroutine, through a queue of QueueSize entries (DefaultQueueSize by default): entries logged while it's full are dropped.
+type GELFWriter struct {
+	Network     string
+	Address     string
+	TLSConfig   *tls.Config
+	Host        string
+	Compression GELFCompression
+	ChunkSize   int
+	BufferSize  int
+	QueueSize   int
+	Timeout     time.Duration
+
+	once      sync.Once
+	transport *transport
+	sender    *sender
+}
+
+func (w *GELFWriter) init() {
+	w.once.Do(func() {
+		if "" == w.Host {
+			w.Host, _ = os.Hostname()
+		}
+		if w.ChunkSize <= gelfChunkHeaderSize {
+			w.ChunkSize = DefaultGELFChunkSize
+		}
+		w.transport = &transport{
+			network:    w.Network,
+			address:    w.Address,
+			tlsConfig:  w.TLSConfig,
+			timeout:    w.Timeout,
+			bufferSize: w.BufferSize,
+		}
+		w.sender = newSender("GELF", w.QueueSize, w.WriteEntry)
+	})
+}
+
+// WriteEntry send the entry to Graylog
+func (w *GELFWriter) WriteEntry(e Entry) error {
+	msg, err := w.Encode(e)
+	if nil != err {
+		return err
+	}
+	if !w.udp() {
+		return w.transport.write(append(msg, 0))
+	}
+
+	if msg, err = w.compress(msg); nil != err {
+		return err
+	}
+	chunks, err := w.chunk(msg)
+	if nil != err {
+		return err
+	}
+	for _, chunk := range chunks {
+		if err := w.transport.write(chunk); nil != err {
+			return err
+		}
+	}
+	return nil
+}
+
+// Flush send the queued entries, and try to send the messages kept while the server was unreachable
+func (w *GELFWriter) Flush() error {
+	w.init()
+	w.sender.drain()
+	return w.transport.retry()
+}
+
+// Close send the queued entries and close the connection to the server. Entries logged afterward are dropped.
+func (w *GELFWriter) Close() error {
+	w.init()
+	w.sender.close()
+	return w.transport.close()
+}
+
+// Encode serialize the entry as a GELF 1.1 JSON message. The first line of the message is sent as short_message, and the whole message as full_message when it spans multiple lines. Keys of the Structure are sent as additional fields, prefixed by '_'. The timestamp is omitted when the time of the entry is zero.
+func (w *GELFWriter) Encode(e Entry) ([]byte, error) {
+	w.init()
+	msg := e.Message()
+	short := msg
+	if index := strings.IndexByte(msg, '\n'); -1 != index {
+		short = msg[:index]
+	}
+	if "" == short {
+		short = "-"
+	}
+
+	document := map[string]interface{}{
+		"version":       "1.1",
+		"host":          w.Host,
+		"short_message": short,
+		"level":         e.Level.Severity(),
+	}
+	// Without timestamp, the server use the time the message is received
+	if !e.Time.IsZero() {
+		document["timestamp"] = json.Number(fmt.Sprintf("%d.%06d", e.Time.Unix(), e.Time.Nanosecond()/1000))
+	}
+	if short != msg {
+		document["full_message"] = msg
+	}
+	for key, value := range e.Structure.Flatten() {
+		name := "_" + gelfFieldName.ReplaceAllString(key, "_")
+		if "_id" == name {
+			name = "__id"
+		}
+		document[name] = gelfValue(value)
+	}
+	return json.Marshal(document)
+}
+
+// gelfValue convert the value into one of the types allowed for additional fields: strings and numbers
+func gelfValue(value interface{}) interface{} {
+	switch value.(type) {
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return value
+	}
+	return fmt.Sprint(value)
+}
+
+func (w *GELFWriter) udp() bool {
+	switch w.Network {
+	case "udp", "udp4", "udp6":
+		return true
+	}
+	return false
+}
+
+func (w *GELFWriter) compress(msg []byte) ([]byte, error) {
+	buffer := &bytes.Buffer{}
+	var writer io.WriteCloser
+	switch w.Compression {
+	case GELFGzip:
+		writer = gzip.NewWriter(buffer)
+	case GELFZlib:
+		writer = zlib.NewWriter(buffer)
+	default:
+		return msg, nil
+	}
+	if _, err := writer.Write(msg); nil != err {
+		return nil, err
+	}
+	if err := writer.Close(); nil != err {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// chunk split the message into GELF chunks if it doesn't fit in a single datagram
+func (w *GELFWriter) chunk(msg []byte) ([][]byte, error) {
+	if len(msg) <= w.ChunkSize {
+		return [][]byte{msg}, nil
+	}
+	size := w.ChunkSize - gelfChunkHeaderSize
+	count := (len(msg) + size - 1) / size
+	if count > gelfMaxChunks {
+		return nil, fmt.Errorf("GELF message too large: %d chunks needed", count)
+	}
+
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); nil != err {
+		return nil, err
+	}
+	chunks := make([][]byte, 0, count)
+	for i := 0; i < count; i++ {
+		end := (i + 1) * size
+		if end > len(msg) {
+			end = len(msg)
+		}
+		chunk := make([]byte, 0, gelfChunkHeaderSize+end-i*size)
+		chunk = append(chunk, 0x1e, 0x0f)
+		chunk = append(chunk, id...)
+		chunk = append(chunk, byte(i), byte(count))
+		chunks = append(chunks, append(chunk, msg[i*size:end]...))
+	}
+	return chunks, nil
+}
+
+// GELFLog is an AgnosticLogger sending entries at or above Level to Graylog through Writer.
+type GELFLog struct {
+	Writer    *GELFWriter
+	Level     Level
+	structure Structure
+}
+
+// Log send the message to Graylog in the background, with the Structure as additional fields.
+func (l GELFLog) Log(lvl Level, str Structure, v ...interface{}) {
+	if l.Enabled(lvl) {
+		entry := NewEntry(lvl, Structure{}.With(l.structure).With(str), v...)
+		l.Writer.init()
+		l.Writer.sender.log(entry)
+		terminate(lvl, entry.Message())
+	}
+}
+
+// Enabled send back true if entries of the level are sent
+func (l GELFLog) Enabled(lvl Level) bool {
+	return nil != l.Writer && lvl >= l.Level
+}
+
+// With add some fields to a new logger created from the source and return it
+func (l GELFLog) With(str Structure) AgnosticLogger {
+	l.structure = Structure{}.With(l.structure).With(str)
+	return l
+}
@@ -0,0 +1,163 @@
+package log
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/json"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// receiveGELF read datagrams until a whole GELF message has been received, reassembling chunks and decompressing it
+func receiveGELF(t *testing.T, conn net.PacketConn) map[string]interface{} {
+	chunks := map[byte][]byte{}
+	buffer := make([]byte, 65536)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		n, _, err := conn.ReadFrom(buffer)
+		if nil != err {
+			t.Fatal(err)
+		}
+		datagram := append([]byte{}, buffer[:n]...)
+		if !bytes.HasPrefix(datagram, []byte{0x1e, 0x0f}) {
+			return decodeGELF(t, datagram)
+		}
+		chunks[datagram[10]] = datagram[12:]
+		if int(datagram[11]) == len(chunks) {
+			msg := []byte{}
+			for i := 0; i < len(chunks); i++ {
+				msg = append(msg, chunks[byte(i)]...)
+			}
+			return decodeGELF(t, msg)
+		}
+	}
+}
+
+func decodeGELF(t *testing.T, msg []byte) map[string]interface{} {
+	var reader io.Reader = bytes.NewReader(msg)
+	var err error
+	switch {
+	case bytes.HasPrefix(msg, []byte{0x1f, 0x8b}):
+		reader, err = gzip.NewReader(reader)
+	case 0x78 == msg[0]:
+		reader, err = zlib.NewReader(reader)
+	}
+	if nil != err {
+		t.Fatal(err)
+	}
+	document := map[string]interface{}{}
+	if err := json.NewDecoder(reader).Decode(&document); nil != err {
+		t.Fatal(err)
+	}
+	return document
+}
+
+func listenGELFUDP(t *testing.T) net.PacketConn {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if nil != err {
+		t.Fatal(err)
+	}
+	return conn
+}
+
+func TestGELFWriter_Encode(t *testing.T) {
+	writer := &GELFWriter{Host: "host"}
+	msg, err := writer.Encode(testEntry(ERROR, Structure{"user id": "john", "count": 2, "id": 3}, "Short\nFull"))
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	expect := `{"__id":3,"_count":2,"_user_id":"john","full_message":"Short\nFull","host":"host","level":3,"short_message":"Short","timestamp":1471102805.123456,"version":"1.1"}`
+	if expect != string(msg) {
+		t.Errorf("Error (Mismatched strings) [Expected: '%s'; Received: '%s']", expect, msg)
+	}
+}
+
+func TestGELFLog_UDP(t *testing.T) {
+	for _, compression := range []GELFCompression{GELFGzip, GELFZlib, GELFNoCompression} {
+		conn := listenGELFUDP(t)
+		writer := &GELFWriter{Network: "udp", Address: conn.LocalAddr().String(), Host: "host", Compression: compression}
+		GELFLog{Writer: writer, Level: INFO}.With(Structure{"user": "john"}).Log(WARN, Structure{}, "Message")
+
+		document := receiveGELF(t, conn)
+		if "Message" != document["short_message"] || "john" != document["_user"] || float64(4) != document["level"] {
+			t.Errorf("Error (Mismatched message for compression '%d') [Received: '%+v']", compression, document)
+		}
+		writer.Close()
+		conn.Close()
+	}
+}
+
+func TestGELFLog_UDPChunked(t *testing.T) {
+	conn := listenGELFUDP(t)
+	defer conn.Close()
+	writer := &GELFWriter{Network: "udp", Address: conn.LocalAddr().String(), Compression: GELFNoCompression, ChunkSize: 100}
+	defer writer.Close()
+
+	large := strings.Repeat("a", 1000)
+	GELFLog{Writer: writer, Level: INFO}.Log(INFO, Structure{"large": large}, "Message")
+
+	document := receiveGELF(t, conn)
+	if large != document["_large"] {
+		t.Errorf("Error (Mismatched strings) [Expected: '%d' bytes; Received: '%v']", len(large), document["_large"])
+	}
+}
+
+func TestGELFWriter_TooManyChunks(t *testing.T) {
+	writer := &GELFWriter{Network: "udp", Address: "127.0.0.1:1", Compression: GELFNoCompression, ChunkSize: 20}
+	defer writer.Close()
+
+	if err := writer.WriteEntry(testEntry(INFO, Structure{}, strings.Repeat("a", 2000))); nil == err {
+		t.Error("Error (Message sent in more than 128 chunks)")
+	}
+}
+
+func TestGELFLog_TCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	messages := make(chan []byte, 10)
+	go func() {
+		conn, err := listener.Accept()
+		if nil != err {
+			return
+		}
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		for {
+			msg, err := reader.ReadBytes(0)
+			if nil != err {
+				return
+			}
+			messages <- msg[:len(msg)-1]
+		}
+	}()
+
+	writer := &GELFWriter{Network: "tcp", Address: listener.Addr().String()}
+	defer writer.Close()
+	gelf := GELFLog{Writer: writer, Level: INFO}
+	gelf.Log(INFO, Structure{}, "First")
+	gelf.Log(INFO, Structure{}, "Second")
+
+	for _, expect := range []string{"First", "Second"} {
+		select {
+		case msg := <-messages:
+			if document := decodeGELF(t, msg); expect != document["short_message"] {
+				t.Errorf("Error (Mismatched strings) [Expected: '%s'; Received: '%v']", expect, document["short_message"])
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("Error (No message received)")
+		}
+	}
+}
+
+func TestGELFLog_NoWriter(t *testing.T) {
+	GELFLog{}.With(Structure{}).Log(PANIC, Structure{}, "Message")
+}
@@ -5,3 +5,14 @@ type AgnosticLogger interface {
 	Log(lvl Level, str Structure, v ...interface{})
 	With(Structure) AgnosticLogger
 }
+
+// FieldLogger is implemented by the loggers able to log typed fields (see Field) without building a Structure. Use LogFields to log fields through any AgnosticLogger. Called on the loggers themselves (not through an interface), the fields don't escape: entries filtered by their level cost no allocation.
+type FieldLogger interface {
+	AgnosticLogger
+	LogFields(lvl Level, msg string, fields ...Field)
+}
+
+// LevelEnabler is implemented by the loggers able to tell if they would write entries of a level, so callers can skip building entries that would be filtered out. Use Enabled to query any AgnosticLogger.
+type LevelEnabler interface {
+	Enabled(lvl Level) bool
+}
@@ -1,6 +1,15 @@
 package log
 
-import "testing"
+import (
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+)
 
 // Test if loggers implements the AgnosticLogger interface
 
@@ -15,3 +24,143 @@ func TestStructuredLog_AgnosticInterface(t *testing.T) {
 	log = StructuredLog{}
 	log.Log(DEBUG, Structure{}, "Test")
 }
+
+func TestSyslogLog_AgnosticInterface(t *testing.T) {
+	var log AgnosticLogger
+	log = SyslogLog{}
+	log.Log(DEBUG, Structure{}, "Test")
+}
+
+func TestJournalLog_AgnosticInterface(t *testing.T) {
+	var log AgnosticLogger
+	log = JournalLog{}
+	log.Log(DEBUG, Structure{}, "Test")
+}
+
+func TestGELFLog_AgnosticInterface(t *testing.T) {
+	var log AgnosticLogger
+	log = GELFLog{}
+	log.Log(DEBUG, Structure{}, "Test")
+}
+
+func TestFluentLog_AgnosticInterface(t *testing.T) {
+	var log AgnosticLogger
+	log = FluentLog{}
+	log.Log(DEBUG, Structure{}, "Test")
+}
+
+func TestLokiLog_AgnosticInterface(t *testing.T) {
+	var log AgnosticLogger
+	log = LokiLog{}
+	log.Log(DEBUG, Structure{}, "Test")
+}
+
+func TestElasticsearchLog_AgnosticInterface(t *testing.T) {
+	var log AgnosticLogger
+	log = ElasticsearchLog{}
+	log.Log(DEBUG, Structure{}, "Test")
+}
+
+func TestWebhookLog_AgnosticInterface(t *testing.T) {
+	var log AgnosticLogger
+	log = WebhookLog{}
+	log.Log(DEBUG, Structure{}, "Test")
+}
+
+func TestJSONLog_AgnosticInterface(t *testing.T) {
+	var log AgnosticLogger
+	log = JSONLog{}
+	log.Log(DEBUG, Structure{}, "Test")
+}
+
+func TestConsoleLog_AgnosticInterface(t *testing.T) {
+	var log AgnosticLogger
+	log = ConsoleLog{Writer: io.Discard}
+	log.Log(DEBUG, Structure{}, "Test")
+}
+
+func TestEnabled(t *testing.T) {
+	discard := log.New(io.Discard, "", 0)
+	logrusLogger := logrus.New()
+	logrusLogger.Level = logrus.WarnLevel
+	basic := BasicLog{Logger: discard, Level: WARN}
+	cases := []struct {
+		Name     string
+		Logger   AgnosticLogger
+		Disabled Level
+		Enabled  Level
+	}{
+		{"BasicLog", basic, INFO, WARN},
+		{"BasicLog.With", basic.With(Structure{"user": "john"}), INFO, WARN},
+		{"ConsoleLog", ConsoleLog{Level: WARN}, INFO, WARN},
+		{"JSONLog", JSONLog{Writer: io.Discard, Level: WARN}, INFO, WARN},
+		{"SyslogLog", SyslogLog{Writer: &SyslogWriter{}, Level: WARN}, INFO, WARN},
+		{"JournalLog", JournalLog{Writer: &JournalWriter{}, Level: WARN}, INFO, WARN},
+		{"GELFLog", GELFLog{Writer: &GELFWriter{}, Level: WARN}, INFO, WARN},
+		{"FluentLog", FluentLog{Writer: &FluentWriter{}, Level: WARN}, INFO, WARN},
+		{"LokiLog", LokiLog{Writer: &LokiWriter{}, Level: WARN}, INFO, WARN},
+		{"ElasticsearchLog", ElasticsearchLog{Writer: &ElasticsearchWriter{}, Level: WARN}, INFO, WARN},
+		{"WebhookLog", WebhookLog{Writer: &WebhookWriter{}, Level: WARN}, INFO, WARN},
+		{"StructuredLog", StructuredLog{Logger: logrusLogger}, INFO, WARN},
+		{"StructuredLog.With", StructuredLog{Logger: logrusLogger}.With(Structure{"user": "john"}), TRACE, ERROR},
+		{"DedupLogger", NewDedupLogger(basic, 0), DEBUG, ERROR},
+		{"SamplingLogger", NewSamplingLogger(basic, 1, 10, time.Second), INFO, WARN},
+		{"RedactingLogger", NewRedactingLogger(basic), INFO, WARN},
+		{"PIILogger", NewPIILogger(basic, []byte("key")), INFO, WARN},
+		{"StackTraceLogger", StackTraceLogger{Logger: basic, Level: ERROR}, INFO, ERROR},
+		{"FlightRecorder", NewFlightRecorder(BasicLog{Logger: discard, Level: DEBUG}, WARN, 10), TRACE, DEBUG},
+		{"FlightRecorder.Logger", NewFlightRecorder(basic, ERROR, 10), INFO, WARN},
+		{"FlightRecorder.NoBuffer", FlightRecorder{Logger: BasicLog{Logger: discard, Level: DEBUG}, Level: WARN}, DEBUG, WARN},
+	}
+	for _, test := range cases {
+		if Enabled(test.Logger, test.Disabled) {
+			t.Errorf("Error (%s enabled for %s)", test.Name, test.Disabled)
+		}
+		if !Enabled(test.Logger, test.Enabled) {
+			t.Errorf("Error (%s disabled for %s)", test.Name, test.Enabled)
+		}
+	}
+
+	if Enabled(BasicLog{}, PANIC) || Enabled(JSONLog{}, PANIC) || Enabled(StructuredLog{}, PANIC) || Enabled(nil, PANIC) {
+		t.Error("Error (Logger without output enabled)")
+	}
+	if !Enabled(newRecordingLogger(), TRACE) {
+		t.Error("Error (Logger without Enabled method disabled)")
+	}
+}
+
+// recordedEntry is an entry received by a recordingLogger
+type recordedEntry struct {
+	Level     Level
+	Structure Structure
+	Message   string
+}
+
+// recordingLogger is an AgnosticLogger keeping every entry it receives, used to test decorators
+type recordingLogger struct {
+	entries   *[]recordedEntry
+	mutex     *sync.Mutex
+	structure Structure
+}
+
+func newRecordingLogger() recordingLogger {
+	return recordingLogger{entries: &[]recordedEntry{}, mutex: &sync.Mutex{}}
+}
+
+func (l recordingLogger) Log(lvl Level, str Structure, v ...interface{}) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	structure := Structure{}.With(l.structure).With(str)
+	*l.entries = append(*l.entries, recordedEntry{Level: lvl, Structure: structure, Message: fmt.Sprint(v...)})
+}
+
+func (l recordingLogger) With(str Structure) AgnosticLogger {
+	l.structure = Structure{}.With(l.structure).With(str)
+	return l
+}
+
+func (l recordingLogger) Entries() []recordedEntry {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return append([]recordedEntry{}, *l.entries...)
+}
@@ -0,0 +1,161 @@
+package log
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultJournalSocket is the socket on which systemd-journald receive entries using its native protocol
+const DefaultJournalSocket = "/run/systemd/journal/socket"
+
+// JournalWriter send entries to systemd-journald using its native protocol. Entries too large to fit in a datagram are written into a sealed memfd (or an unlinked file in /dev/shm), whose descriptor is sent instead.
+//
+// Socket default to DefaultJournalSocket and Identifier (the SYSLOG_IDENTIFIER field) to the name of the current program.
+type JournalWriter struct {
+	Socket     string
+	Identifier string
+
+	once  sync.Once
+	mutex sync.Mutex
+	conn  *net.UnixConn
+}
+
+func (w *JournalWriter) init() {
+	w.once.Do(func() {
+		if "" == w.Socket {
+			w.Socket = DefaultJournalSocket
+		}
+		if "" == w.Identifier {
+			w.Identifier = filepath.Base(os.Args[0])
+		}
+	})
+}
+
+// WriteEntry send the entry to journald
+func (w *JournalWriter) WriteEntry(e Entry) error {
+	msg := w.Encode(e)
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if nil == w.conn {
+		// The socket isn't connected, so file descriptors can be sent along with the datagrams
+		conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Net: "unixgram"})
+		if nil != err {
+			return err
+		}
+		w.conn = conn
+	}
+	address := &net.UnixAddr{Name: w.Socket, Net: "unixgram"}
+	_, _, err := w.conn.WriteMsgUnix(msg, nil, address)
+	if nil != err && largeEntryError(err) {
+		return sendJournalFile(w.conn, address, msg)
+	}
+	return err
+}
+
+// Close close the socket used to send entries to journald
+func (w *JournalWriter) Close() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if nil == w.conn {
+		return nil
+	}
+	err := w.conn.Close()
+	w.conn = nil
+	return err
+}
+
+// journalWriterFields are the fields written by JournalWriter itself, which keys of the Structure can't override
+var journalWriterFields = map[string]bool{"MESSAGE": true, "PRIORITY": true, "SYSLOG_IDENTIFIER": true}
+
+// Encode serialize the entry using journald native protocol. The message is sent as MESSAGE, the level as PRIORITY and every key of the Structure as an uppercase field. Keys clashing with MESSAGE, PRIORITY or SYSLOG_IDENTIFIER are prefixed by FIELD_, as Entry.Document prefix the keys clashing with its own.
+func (w *JournalWriter) Encode(e Entry) []byte {
+	w.init()
+	buffer := &bytes.Buffer{}
+	writeJournalField(buffer, "MESSAGE", e.Message())
+	writeJournalField(buffer, "PRIORITY", strconv.Itoa(e.Level.Severity()))
+	writeJournalField(buffer, "SYSLOG_IDENTIFIER", w.Identifier)
+
+	flat := e.Structure.Flatten()
+	keys := make([]string, 0, len(flat))
+	for key := range flat {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		name := JournalFieldName(key)
+		if journalWriterFields[name] {
+			name = "FIELD_" + name
+		}
+		writeJournalField(buffer, name, fmt.Sprint(flat[key]))
+	}
+	return buffer.Bytes()
+}
+
+// writeJournalField write a field. Values containing a new line are written as binary data, prefixed by their length.
+func writeJournalField(buffer *bytes.Buffer, name, value string) {
+	buffer.WriteString(name)
+	if !strings.Contains(value, "\n") {
+		buffer.WriteString("=" + value + "\n")
+		return
+	}
+	buffer.WriteString("\n")
+	binary.Write(buffer, binary.LittleEndian, uint64(len(value)))
+	buffer.WriteString(value + "\n")
+}
+
+// JournalFieldName convert a Structure key into a valid journal field name: uppercase letters, digits and underscores, not starting with an underscore (reserved for trusted fields) or a digit, and at most 64 characters long.
+func JournalFieldName(key string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9', '_' == r:
+			return r
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		}
+		return '_'
+	}, key)
+	name = strings.TrimLeft(name, "_")
+	if "" == name || (name[0] >= '0' && name[0] <= '9') {
+		name = "FIELD_" + name
+	}
+	if len(name) > 64 {
+		name = name[:64]
+	}
+	return name
+}
+
+// JournalLog is an AgnosticLogger sending entries at or above Level to systemd-journald through Writer.
+type JournalLog struct {
+	Writer    *JournalWriter
+	Level     Level
+	structure Structure
+}
+
+// Log send the message to journald, with every key of the Structure as a separate field.
+func (l JournalLog) Log(lvl Level, str Structure, v ...interface{}) {
+	if l.Enabled(lvl) {
+		entry := NewEntry(lvl, Structure{}.With(l.structure).With(str), v...)
+		reportError("journald", l.Writer.WriteEntry(entry))
+		terminate(lvl, entry.Message())
+	}
+}
+
+// Enabled send back true if entries of the level are sent
+func (l JournalLog) Enabled(lvl Level) bool {
+	return nil != l.Writer && lvl >= l.Level
+}
+
+// With add some fields to a new logger created from the source and return it
+func (l JournalLog) With(str Structure) AgnosticLogger {
+	l.structure = Structure{}.With(l.structure).With(str)
+	return l
+}
@@ -0,0 +1,83 @@
+//go:build linux
+// +build linux
+
+package log
+
+import (
+	"errors"
+	"net"
+	"os"
+	"runtime"
+	"syscall"
+	"unsafe"
+)
+
+// memfd_create system call numbers, not exposed by the syscall package on every architecture
+var memfdCreateSyscalls = map[string]uintptr{
+	"386":      356,
+	"amd64":    319,
+	"arm":      385,
+	"arm64":    279,
+	"loong64":  279,
+	"mips64":   5314,
+	"mips64le": 5314,
+	"ppc64":    360,
+	"ppc64le":  360,
+	"riscv64":  279,
+	"s390x":    350,
+}
+
+const (
+	mfdCloexec       = 0x1
+	mfdAllowSealing  = 0x2
+	fcntlAddSeals    = 1033
+	sealAll          = 0x1 | 0x2 | 0x4 | 0x8 // F_SEAL_SEAL | F_SEAL_SHRINK | F_SEAL_GROW | F_SEAL_WRITE
+	journalMemfdName = "journal-entry"
+)
+
+// largeEntryError check if the error mean the entry doesn't fit in a datagram
+func largeEntryError(err error) bool {
+	return errors.Is(err, syscall.EMSGSIZE) || errors.Is(err, syscall.ENOBUFS)
+}
+
+// sendJournalFile write the entry into a file and send its descriptor to journald, as sd_journal_send does for large entries.
+func sendJournalFile(conn *net.UnixConn, address *net.UnixAddr, msg []byte) error {
+	file, err := journalFile()
+	if nil != err {
+		return err
+	}
+	defer file.Close()
+
+	if _, err := file.Write(msg); nil != err {
+		return err
+	}
+	// Sealing fails on regular files, which are accepted by journald as long as they are in /dev/shm
+	syscall.Syscall(syscall.SYS_FCNTL, file.Fd(), fcntlAddSeals, sealAll)
+
+	_, _, err = conn.WriteMsgUnix(nil, syscall.UnixRights(int(file.Fd())), address)
+	return err
+}
+
+// journalFile create a sealable memfd, falling back to an unlinked file in /dev/shm when memfd aren't supported
+func journalFile() (*os.File, error) {
+	if number, ok := memfdCreateSyscalls[runtime.GOARCH]; ok {
+		name, err := syscall.BytePtrFromString(journalMemfdName)
+		if nil != err {
+			return nil, err
+		}
+		fd, _, errno := syscall.Syscall(number, uintptr(unsafe.Pointer(name)), mfdCloexec|mfdAllowSealing, 0)
+		if 0 == errno {
+			return os.NewFile(fd, journalMemfdName), nil
+		}
+	}
+
+	file, err := os.CreateTemp("/dev/shm", "journal-")
+	if nil != err {
+		return nil, err
+	}
+	if err := os.Remove(file.Name()); nil != err {
+		file.Close()
+		return nil, err
+	}
+	return file, nil
+}
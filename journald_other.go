@@ -0,0 +1,18 @@
+//go:build !linux
+// +build !linux
+
+package log
+
+import (
+	"errors"
+	"net"
+)
+
+// largeEntryError always send back false, large entries being only supported on Linux
+func largeEntryError(err error) bool {
+	return false
+}
+
+func sendJournalFile(conn *net.UnixConn, address *net.UnixAddr, msg []byte) error {
+	return errors.New("large journal entries are only supported on Linux")
+}
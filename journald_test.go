@@ -0,0 +1,151 @@
+package log
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// parseJournalFields decode an entry serialized using journald native protocol
+func parseJournalFields(t *testing.T, data []byte) map[string]string {
+	fields := map[string]string{}
+	for 0 != len(data) {
+		end := bytes.IndexAny(data, "=\n")
+		if -1 == end {
+			t.Fatalf("Error (Malformed entry) [Received: '%s']", data)
+		}
+		name := string(data[:end])
+		if '=' == data[end] {
+			line := bytes.IndexByte(data, '\n')
+			fields[name] = string(data[end+1 : line])
+			data = data[line+1:]
+			continue
+		}
+		size := binary.LittleEndian.Uint64(data[end+1 : end+9])
+		fields[name] = string(data[end+9 : end+9+int(size)])
+		data = data[end+9+int(size)+1:]
+	}
+	return fields
+}
+
+// listenJournal start a fake journald socket
+func listenJournal(t *testing.T) (*net.UnixConn, string) {
+	path := filepath.Join(t.TempDir(), "journal.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if nil != err {
+		t.Fatal(err)
+	}
+	return conn, path
+}
+
+// readJournalEntry read an entry from the fake journald socket, following the descriptor sent for large entries
+func readJournalEntry(t *testing.T, conn *net.UnixConn) map[string]string {
+	buffer := make([]byte, 65536)
+	oob := make([]byte, 1024)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, oobn, _, _, err := conn.ReadMsgUnix(buffer, oob)
+	if nil != err {
+		t.Fatal(err)
+	}
+	if 0 == oobn {
+		return parseJournalFields(t, buffer[:n])
+	}
+
+	messages, err := syscall.ParseSocketControlMessage(oob[:oobn])
+	if nil != err {
+		t.Fatal(err)
+	}
+	fds, err := syscall.ParseUnixRights(&messages[0])
+	if nil != err {
+		t.Fatal(err)
+	}
+	file := os.NewFile(uintptr(fds[0]), "entry")
+	defer file.Close()
+	content, err := io.ReadAll(io.NewSectionReader(file, 0, 1<<30))
+	if nil != err {
+		t.Fatal(err)
+	}
+	return parseJournalFields(t, content)
+}
+
+func TestJournalFieldName(t *testing.T) {
+	cases := map[string]string{
+		"user_id":               "USER_ID",
+		"http.status":           "HTTP_STATUS",
+		"_private":              "PRIVATE",
+		"2fa":                   "FIELD_2FA",
+		"":                      "FIELD_",
+		strings.Repeat("a", 70): strings.Repeat("A", 64),
+	}
+	for key, expect := range cases {
+		if name := JournalFieldName(key); expect != name {
+			t.Errorf("Error (Mismatched strings) [Expected: '%s'; Received: '%s']", expect, name)
+		}
+	}
+}
+
+func TestJournalLog(t *testing.T) {
+	conn, path := listenJournal(t)
+	defer conn.Close()
+
+	writer := &JournalWriter{Socket: path, Identifier: "app"}
+	defer writer.Close()
+	journal := JournalLog{Writer: writer, Level: INFO}
+	journal.Log(DEBUG, Structure{}, "Filtered")
+	journal.With(Structure{"user": "john"}).Log(WARN, Structure{"stack": "line 1\nline 2"}, "Message")
+
+	fields := readJournalEntry(t, conn)
+	expected := map[string]string{
+		"MESSAGE":           "Message",
+		"PRIORITY":          "4",
+		"SYSLOG_IDENTIFIER": "app",
+		"USER":              "john",
+		"STACK":             "line 1\nline 2",
+	}
+	for name, value := range expected {
+		if value != fields[name] {
+			t.Errorf("Error (Mismatched strings for field '%s') [Expected: '%s'; Received: '%s']", name, value, fields[name])
+		}
+	}
+}
+
+func TestJournalWriter_EncodeReservedKeys(t *testing.T) {
+	writer := &JournalWriter{Identifier: "app"}
+	msg := string(writer.Encode(testEntry(INFO, Structure{"message": "forged", "priority": 0, "syslog_identifier": "other"}, "Message")))
+
+	lines := strings.Split(strings.TrimSuffix(msg, "\n"), "\n")
+	expected := []string{"MESSAGE=Message", "PRIORITY=6", "SYSLOG_IDENTIFIER=app", "FIELD_MESSAGE=forged", "FIELD_PRIORITY=0", "FIELD_SYSLOG_IDENTIFIER=other"}
+	if strings.Join(expected, "\n") != strings.Join(lines, "\n") {
+		t.Errorf("Error (Mismatched fields) [Expected: '%q'; Received: '%q']", expected, lines)
+	}
+}
+
+func TestJournalLog_LargeEntry(t *testing.T) {
+	if "linux" != runtime.GOOS {
+		t.Skip("Large entries are only supported on Linux")
+	}
+	conn, path := listenJournal(t)
+	defer conn.Close()
+
+	writer := &JournalWriter{Socket: path, Identifier: "app"}
+	defer writer.Close()
+	large := strings.Repeat("a", 1<<20)
+	JournalLog{Writer: writer, Level: INFO}.Log(INFO, Structure{"large": large}, "Message")
+
+	fields := readJournalEntry(t, conn)
+	if large != fields["LARGE"] || "Message" != fields["MESSAGE"] {
+		t.Errorf("Error (Mismatched large entry) [Received: '%d' bytes]", len(fields["LARGE"]))
+	}
+}
+
+func TestJournalLog_NoWriter(t *testing.T) {
+	JournalLog{}.With(Structure{}).Log(PANIC, Structure{}, "Message")
+}
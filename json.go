@@ -0,0 +1,248 @@
+package log
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+// JSONLog is an AgnosticLogger writing entries at or above Level to Writer, one JSON document per line. Documents are built by Formatter (Entry.Document, with the time under TimeKey, by default). It's meant to log on the standard output of containers and functions, for their logging agent to parse.
+//
+// Each line is written with a single call to Writer, which must support concurrent writes if the logger is used by several goroutines.
+type JSONLog struct {
+	Writer    io.Writer
+	Formatter Formatter
+	Level     Level
+	structure Structure
+	encoded   []byte
+}
+
+// Log write the document of the entry.
+func (l JSONLog) Log(lvl Level, str Structure, v ...interface{}) {
+	if l.Enabled(lvl) {
+		entry := NewEntry(lvl, Structure{}.With(l.structure).With(str), v...)
+		reportError("JSON", l.write(entry))
+		terminate(lvl, entry.Message())
+	}
+}
+
+func (l JSONLog) write(e Entry) error {
+	document, err := format(l.Formatter, e)
+	if nil != err {
+		return err
+	}
+	if nil == l.Formatter {
+		if _, ok := document[TimeKey]; !ok {
+			document[TimeKey] = e.Time.Format(time.RFC3339Nano)
+		}
+	}
+	line, err := json.Marshal(document)
+	if nil != err {
+		return err
+	}
+	_, err = l.Writer.Write(append(line, '\n'))
+	return err
+}
+
+// LogFields write the entry holding the fields, without building its document when there's no Formatter. As with Log, fields override the keys of the logger (and the previous fields) they redefine.
+func (l JSONLog) LogFields(lvl Level, msg string, fields ...Field) {
+	if !l.Enabled(lvl) {
+		return
+	}
+	if nil != l.Formatter || (0 != len(l.structure) && nil == l.encoded) || redefinesKeys(l.structure, fields) {
+		l.Log(lvl, Structure{}.WithFields(fields...), msg)
+		return
+	}
+
+	buffer := lineBuffers.Get().(*[]byte)
+	line := append((*buffer)[:0], `{"`+LevelKey+`":"`...)
+	line = appendLower(line, lvl.String())
+	line = append(line, `","`+MessageKey+`":`...)
+	line = appendJSONString(line, msg)
+	if !l.hasTime(fields) {
+		line = append(line, `,"`+TimeKey+`":"`...)
+		line = time.Now().AppendFormat(line, time.RFC3339Nano)
+		line = append(line, '"')
+	}
+	line = append(line, l.encoded...)
+	var err error
+	for _, field := range fields {
+		if line, err = appendJSONField(line, field); nil != err {
+			break
+		}
+	}
+	if nil == err {
+		_, err = l.Writer.Write(append(line, '}', '\n'))
+	}
+	if cap(line) <= maxPooledBuffer {
+		*buffer = line
+		lineBuffers.Put(buffer)
+	}
+	reportError("JSON", err)
+	terminate(lvl, msg)
+}
+
+// hasTime send back true if the structure or the fields hold the time, which must then not be added
+func (l JSONLog) hasTime(fields []Field) bool {
+	if _, ok := l.structure[TimeKey]; ok {
+		return true
+	}
+	for _, field := range fields {
+		if TimeKey == field.Key {
+			return true
+		}
+	}
+	return false
+}
+
+// Enabled send back true if entries of the level are written
+func (l JSONLog) Enabled(lvl Level) bool {
+	return nil != l.Writer && lvl >= l.Level
+}
+
+// With add some fields to a new logger created from the source and return it
+func (l JSONLog) With(str Structure) AgnosticLogger {
+	l.structure = Structure{}.With(l.structure).With(str)
+	l.encoded = nil
+	if !hasLazyValues(l.structure) {
+		l.encoded, _ = appendJSONStructure(nil, l.structure)
+	}
+	return l
+}
+
+// appendJSONStructure append the fields of the Structure (sorted by key), each one preceded by a comma
+func appendJSONStructure(line []byte, str Structure) ([]byte, error) {
+	keys := make([]string, 0, len(str))
+	for key := range str {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value, err := json.Marshal(documentValue(str[key]))
+		if nil != err {
+			return nil, err
+		}
+		line = append(appendJSONKey(line, key), value...)
+	}
+	return line, nil
+}
+
+// appendJSONField append the field, preceded by a comma. Only errors and fields of unknown types are boxed, to be encoded as Entry.Document would.
+func appendJSONField(line []byte, field Field) ([]byte, error) {
+	line = appendJSONKey(line, field.Key)
+	switch field.Type {
+	case StringType:
+		return appendJSONString(line, field.Text), nil
+	case IntType, DurationType:
+		return strconv.AppendInt(line, field.Integer, 10), nil
+	case FloatType:
+		return appendJSONFloat(line, field.float()), nil
+	case BoolType:
+		return strconv.AppendBool(line, 1 == field.Integer), nil
+	}
+	value, _ := resolveValue(field.Interface)
+	encoded, err := json.Marshal(documentValue(value))
+	if nil != err {
+		return line, err
+	}
+	return append(line, encoded...), nil
+}
+
+// appendJSONKey append a comma and the key, prefixed by "fields." if it clash with the level or the message (as in Entry.Document)
+func appendJSONKey(line []byte, key string) []byte {
+	line = append(line, ',', '"')
+	if LevelKey == key || MessageKey == key {
+		line = append(line, "fields."...)
+	}
+	line = appendJSONEscaped(line, key)
+	return append(line, '"', ':')
+}
+
+// appendJSONFloat append the number as encoding/json does. NaN and infinities, which JSON doesn't support, are written as strings.
+func appendJSONFloat(line []byte, value float64) []byte {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return appendJSONString(line, strconv.FormatFloat(value, 'g', -1, 64))
+	}
+	format := byte('f')
+	if abs := math.Abs(value); 0 != abs && (abs < 1e-6 || abs >= 1e21) {
+		format = 'e'
+	}
+	line = strconv.AppendFloat(line, value, format, -1, 64)
+	if 'e' == format {
+		// Clean up e-09 to e-9, as encoding/json does
+		if n := len(line); n >= 4 && 'e' == line[n-4] && '-' == line[n-3] && '0' == line[n-2] {
+			line[n-2] = line[n-1]
+			line = line[:n-1]
+		}
+	}
+	return line
+}
+
+func appendJSONString(line []byte, value string) []byte {
+	line = append(line, '"')
+	line = appendJSONEscaped(line, value)
+	return append(line, '"')
+}
+
+// appendJSONEscaped append the string escaped as encoding/json does, HTML characters included
+func appendJSONEscaped(line []byte, value string) []byte {
+	const hex = "0123456789abcdef"
+	start := 0
+	for i := 0; i < len(value); {
+		if b := value[i]; b < utf8.RuneSelf {
+			if b >= 0x20 && '"' != b && '\\' != b && '<' != b && '>' != b && '&' != b {
+				i++
+				continue
+			}
+			line = append(line, value[start:i]...)
+			switch b {
+			case '"', '\\':
+				line = append(line, '\\', b)
+			case '\n':
+				line = append(line, '\\', 'n')
+			case '\r':
+				line = append(line, '\\', 'r')
+			case '\t':
+				line = append(line, '\\', 't')
+			default:
+				line = append(line, '\\', 'u', '0', '0', hex[b>>4], hex[b&0xf])
+			}
+			i++
+			start = i
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(value[i:])
+		if utf8.RuneError == r && 1 == size {
+			line = append(line, value[start:i]...)
+			line = append(line, "\ufffd"...)
+			i += size
+			start = i
+			continue
+		}
+		if '\u2028' == r || '\u2029' == r {
+			line = append(line, value[start:i]...)
+			line = append(line, '\\', 'u', '2', '0', '2', hex[r&0xf])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	return append(line, value[start:]...)
+}
+
+// appendLower append the ASCII string in lower case
+func appendLower(line []byte, value string) []byte {
+	for i := 0; i < len(value); i++ {
+		b := value[i]
+		if 'A' <= b && b <= 'Z' {
+			b += 'a' - 'A'
+		}
+		line = append(line, b)
+	}
+	return line
+}
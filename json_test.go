@@ -0,0 +1,51 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJSONLog(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	logger := JSONLog{Writer: buffer, Level: INFO}.With(Structure{"user": "john"})
+	logger.Log(DEBUG, Structure{}, "Filtered")
+	logger.Log(WARN, Structure{"count": 2}, "First")
+	logger.Log(INFO, Structure{}, "Second")
+
+	lines := strings.Split(strings.TrimSuffix(buffer.String(), "\n"), "\n")
+	if 2 != len(lines) {
+		t.Fatalf("Error (Mismatched number of lines) [Expected: '%d'; Received: '%s']", 2, buffer)
+	}
+	var document map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &document); nil != err {
+		t.Fatal(err)
+	}
+	for key, value := range map[string]interface{}{"user": "john", "count": 2.0, "level": "warn", "message": "First"} {
+		if value != document[key] {
+			t.Errorf("Error (Mismatched values for key '%s') [Expected: '%v'; Received: '%v']", key, value, document[key])
+		}
+	}
+	if logged, err := time.Parse(time.RFC3339Nano, document[TimeKey].(string)); nil != err || time.Since(logged) > time.Minute {
+		t.Errorf("Error (Invalid time) [Received: '%v']", document[TimeKey])
+	}
+}
+
+func TestJSONLog_Panic(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	defer func() {
+		if err := recover(); "Message" != err {
+			t.Errorf("Error (Mismatched panic values) [Expected: '%s'; Received: '%v']", "Message", err)
+		}
+		if !strings.Contains(buffer.String(), `"message":"Message"`) {
+			t.Errorf("Error (Entry not written before panicking) [Received: '%s']", buffer)
+		}
+	}()
+	JSONLog{Writer: buffer}.Log(PANIC, Structure{}, "Message")
+}
+
+func TestJSONLog_NoWriter(t *testing.T) {
+	JSONLog{}.With(Structure{}).Log(PANIC, Structure{}, "Message")
+}
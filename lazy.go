@@ -0,0 +1,83 @@
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Lazy is a value computed only when the entry holding it is written, used as a Structure value or a message argument: entries filtered out by the level of the logger don't pay for it. Plain func() interface{} values are evaluated the same way. Entries recorded by a FlightRecorder are written later, but their lazy values are evaluated when they're recorded, so they hold the values of that time.
+type Lazy func() interface{}
+
+// String evaluate the value and format it, for the loggers formatting values themselves
+func (l Lazy) String() string {
+	value, _ := resolveValue(l)
+	return fmt.Sprint(value)
+}
+
+// MarshalJSON evaluate the value and encode it, for the loggers encoding values themselves
+func (l Lazy) MarshalJSON() ([]byte, error) {
+	value, _ := resolveValue(l)
+	return json.Marshal(value)
+}
+
+// resolveValue evaluate the value if it's lazy, and send back true in that case
+func resolveValue(value interface{}) (interface{}, bool) {
+	var function func() interface{}
+	switch value := value.(type) {
+	case Lazy:
+		function = value
+	case func() interface{}:
+		function = value
+	default:
+		return value, false
+	}
+	if nil == function {
+		return nil, true
+	}
+	return function(), true
+}
+
+// hasLazyValues send back true if some values of the Structure are lazy
+func hasLazyValues(str Structure) bool {
+	for _, value := range str {
+		switch value.(type) {
+		case Lazy, func() interface{}:
+			return true
+		}
+	}
+	return false
+}
+
+// resolveStructure send back the Structure with its lazy values evaluated. It's copied if it holds some, so the source isn't changed.
+func resolveStructure(str Structure) Structure {
+	var resolved Structure
+	for key, value := range str {
+		if value, ok := resolveValue(value); ok {
+			if nil == resolved {
+				resolved = Structure{}.With(str)
+			}
+			resolved[key] = value
+		}
+	}
+	if nil == resolved {
+		return str
+	}
+	return resolved
+}
+
+// resolveValues send back the message arguments with the lazy ones evaluated. They're copied if there's some, so the source isn't changed.
+func resolveValues(v []interface{}) []interface{} {
+	var resolved []interface{}
+	for i, value := range v {
+		if value, ok := resolveValue(value); ok {
+			if nil == resolved {
+				resolved = append([]interface{}{}, v...)
+			}
+			resolved[i] = value
+		}
+	}
+	if nil == resolved {
+		return v
+	}
+	return resolved
+}
@@ -0,0 +1,134 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"strings"
+	"testing"
+)
+
+// counter count the evaluations of its lazy values
+type counter struct {
+	calls int
+}
+
+func (c *counter) lazy(value interface{}) Lazy {
+	return func() interface{} {
+		c.calls++
+		return value
+	}
+}
+
+func TestLazy_NotEvaluatedWhenDisabled(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	evaluations := &counter{}
+	loggers := []AgnosticLogger{
+		BasicLog{Logger: log.New(buffer, "", 0), Level: INFO},
+		JSONLog{Writer: buffer, Level: INFO},
+		ConsoleLog{Writer: buffer, Level: INFO},
+		NewRedactingLogger(JSONLog{Writer: buffer, Level: INFO}),
+		NewPIILogger(JSONLog{Writer: buffer, Level: INFO}, []byte("key")),
+		NewDedupLogger(JSONLog{Writer: buffer, Level: INFO}, 0),
+		StackTraceLogger{Logger: JSONLog{Writer: buffer, Level: INFO}},
+	}
+	for _, logger := range loggers {
+		logger.Log(DEBUG, Structure{"user": evaluations.lazy("john")}, "Message ", evaluations.lazy(42))
+		LogFields(logger, DEBUG, "Message", Any("user", evaluations.lazy("john")))
+	}
+	if 0 != evaluations.calls || 0 != buffer.Len() {
+		t.Errorf("Error (Lazy values evaluated for disabled levels) [Evaluations: '%d'; Output: '%s']", evaluations.calls, buffer)
+	}
+}
+
+func TestLazy_BasicLog(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	evaluations := &counter{}
+	logger := BasicLog{Logger: log.New(buffer, "", 0), Level: INFO}.With(Structure{"request": evaluations.lazy(1)})
+	logger.Log(INFO, Structure{"user": func() interface{} { return "john" }}, "Message ", evaluations.lazy(42))
+	logger.Log(INFO, Structure{}, "Message")
+
+	lines := strings.Split(strings.TrimSuffix(buffer.String(), "\n"), "\n")
+	if 2 != len(lines) || !strings.HasPrefix(lines[0], "[INFO]Message 42 [") || !strings.Contains(lines[0], "user:john") || !strings.Contains(lines[0], "request:1") {
+		t.Errorf("Error (Lazy values not evaluated) [Received: '%s']", buffer)
+	}
+	if expected := "[INFO]Message [request:1]"; expected != lines[len(lines)-1] {
+		t.Errorf("Error (Mismatched strings) [Expected: '%s'; Received: '%s']", expected, lines[len(lines)-1])
+	}
+	if 3 != evaluations.calls {
+		t.Errorf("Error (Mismatched number of evaluations) [Expected: '%d'; Received: '%d']", 3, evaluations.calls)
+	}
+}
+
+func TestLazy_JSONLog(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	evaluations := &counter{}
+	logger := JSONLog{Writer: buffer, Level: INFO}.With(Structure{"request": evaluations.lazy(1)}).(JSONLog)
+	logger.Log(INFO, Structure{"user": evaluations.lazy("john")}, "Message ", evaluations.lazy(42))
+	logger.LogFields(INFO, "Message", Any("user", evaluations.lazy("john")), Any("id", func() interface{} { return 7 }))
+
+	lines := strings.Split(strings.TrimSuffix(buffer.String(), "\n"), "\n")
+	if 2 != len(lines) {
+		t.Fatalf("Error (Mismatched number of lines) [Expected: '%d'; Received: '%s']", 2, buffer)
+	}
+	for i, expected := range []map[string]interface{}{
+		{"message": "Message 42", "request": 1.0, "user": "john"},
+		{"message": "Message", "request": 1.0, "user": "john", "id": 7.0},
+	} {
+		var document map[string]interface{}
+		if err := json.Unmarshal([]byte(lines[i]), &document); nil != err {
+			t.Fatal(err)
+		}
+		for key, value := range expected {
+			if value != document[key] {
+				t.Errorf("Error (Mismatched values for key '%s') [Expected: '%v'; Received: '%v']", key, value, document[key])
+			}
+		}
+	}
+	if 5 != evaluations.calls {
+		t.Errorf("Error (Mismatched number of evaluations) [Expected: '%d'; Received: '%d']", 5, evaluations.calls)
+	}
+}
+
+func TestLazy_FlightRecorder(t *testing.T) {
+	logger := newRecordingLogger()
+	evaluations := &counter{}
+	recorder := NewFlightRecorder(logger, INFO, 10)
+	state := "before"
+	recorder.Log(DEBUG, Structure{"user": evaluations.lazy("john"), "state": Lazy(func() interface{} { return state })}, "Context")
+	if 1 != evaluations.calls {
+		t.Errorf("Error (Lazy values of recorded entries not evaluated when recorded) [Evaluations: '%d']", evaluations.calls)
+	}
+	state = "after"
+	recorder.Log(ERROR, Structure{}, "Failure")
+	entries := logger.Entries()
+	if 2 != len(entries) {
+		t.Fatalf("Error (Mismatched number of entries) [Expected: '%d'; Received: '%d']", 2, len(entries))
+	}
+	if "john" != entries[0].Structure["user"] || "before" != entries[0].Structure["state"] || 1 != evaluations.calls {
+		t.Errorf("Error (Lazy values not evaluated when recorded) [Received: '%+v'; Evaluations: '%d']", entries[0].Structure, evaluations.calls)
+	}
+}
+
+func TestLazy_Redacted(t *testing.T) {
+	logger := newRecordingLogger()
+	NewRedactingLogger(logger).Log(INFO, Structure{"token": Lazy(func() interface{} { return NewSecret("secret") })}, "Key ", Lazy(func() interface{} { return NewSecret("secret") }))
+	entries := logger.Entries()
+	if 1 != len(entries) || RedactedValue != entries[0].Structure["token"] || "Key "+RedactedValue != entries[0].Message {
+		t.Errorf("Error (Lazy values not redacted) [Received: '%+v']", entries)
+	}
+}
+
+func TestLazy_Formatting(t *testing.T) {
+	var value Lazy = func() interface{} { return []int{1, 2} }
+	if "[1 2]" != value.String() {
+		t.Errorf("Error (Mismatched strings) [Expected: '%s'; Received: '%s']", "[1 2]", value.String())
+	}
+	encoded, err := json.Marshal(Structure{"ids": value})
+	if nil != err || `{"ids":[1,2]}` != string(encoded) {
+		t.Errorf("Error (Mismatched JSON) [Expected: '%s'; Received: '%s'; Error: '%v']", `{"ids":[1,2]}`, encoded, err)
+	}
+	if "<nil>" != Lazy(nil).String() {
+		t.Errorf("Error (Mismatched strings) [Expected: '%s'; Received: '%s']", "<nil>", Lazy(nil).String())
+	}
+}
@@ -1,5 +1,10 @@
 package log
 
+import (
+	"fmt"
+	"strings"
+)
+
 // Level represent the level of logging
 type Level int
 
@@ -34,3 +39,64 @@ func (l Level) String() string {
 	}
 	return ""
 }
+
+// Syslog severities (RFC 5424), used by the backends relying on them
+const (
+	severityEmergency = 0
+	severityAlert     = 1
+	severityCritical  = 2
+	severityError     = 3
+	severityWarning   = 4
+	severityNotice    = 5
+	severityInfo      = 6
+	severityDebug     = 7
+)
+
+// Severity sends the syslog severity (RFC 5424) matching the level.
+func (l Level) Severity() int {
+	switch {
+	case l >= PANIC:
+		return severityEmergency
+	case l >= FATAL:
+		return severityCritical
+	case l >= ERROR:
+		return severityError
+	case l >= WARN:
+		return severityWarning
+	case l >= INFO:
+		return severityInfo
+	}
+	return severityDebug
+}
+
+// ParseLevel send back the level matching the name, ignoring case. Besides the names of the levels, the aliases used by other loggers and formats are accepted: "warning", "err", "critical", "emergency"...
+func ParseLevel(name string) (Level, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "panic", "emergency", "emerg", "alert":
+		return PANIC, nil
+	case "fatal", "critical", "crit":
+		return FATAL, nil
+	case "error", "err":
+		return ERROR, nil
+	case "warn", "warning":
+		return WARN, nil
+	case "info", "notice", "default":
+		return INFO, nil
+	case "debug":
+		return DEBUG, nil
+	case "trace":
+		return TRACE, nil
+	}
+	return INFO, fmt.Errorf("unknown level '%s'", name)
+}
+
+// Enabled send back true if the logger would write entries of the level. Loggers that aren't LevelEnablers are assumed to write every entry.
+func Enabled(logger AgnosticLogger, lvl Level) bool {
+	if nil == logger {
+		return false
+	}
+	if enabler, ok := logger.(LevelEnabler); ok {
+		return enabler.Enabled(lvl)
+	}
+	return true
+}
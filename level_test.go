@@ -0,0 +1,57 @@
+package log
+
+import "testing"
+
+func TestLevel_Severity(t *testing.T) {
+	cases := []struct {
+		Level    Level
+		Severity int
+	}{
+		{Level: PANIC, Severity: 0},
+		{Level: FATAL, Severity: 2},
+		{Level: ERROR, Severity: 3},
+		{Level: WARN, Severity: 4},
+		{Level: INFO, Severity: 6},
+		{Level: DEBUG, Severity: 7},
+		{Level: TRACE, Severity: 7},
+	}
+
+	for _, test := range cases {
+		if test.Severity != test.Level.Severity() {
+			t.Errorf("Error (Mismatched severities for '%s') [Expected: '%d'; Received: '%d']", test.Level, test.Severity, test.Level.Severity())
+		}
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	cases := map[string]Level{
+		"PANIC":     PANIC,
+		"emergency": PANIC,
+		"Fatal":     FATAL,
+		"critical":  FATAL,
+		"error":     ERROR,
+		"ERR":       ERROR,
+		"Warn":      WARN,
+		"warning":   WARN,
+		" info ":    INFO,
+		"notice":    INFO,
+		"debug":     DEBUG,
+		"TRACE":     TRACE,
+	}
+	for name, expected := range cases {
+		received, err := ParseLevel(name)
+		if nil != err || expected != received {
+			t.Errorf("Error (Mismatched levels for '%s') [Expected: '%s'; Received: '%s'; Error: '%v']", name, expected, received, err)
+		}
+	}
+
+	for _, lvl := range []Level{PANIC, FATAL, ERROR, WARN, INFO, DEBUG, TRACE} {
+		if received, err := ParseLevel(lvl.String()); nil != err || lvl != received {
+			t.Errorf("Error (Level '%s' not parsed back) [Received: '%s'; Error: '%v']", lvl, received, err)
+		}
+	}
+
+	if _, err := ParseLevel("verbose"); nil == err {
+		t.Error("Error (Unknown level parsed)")
+	}
+}
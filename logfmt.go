@@ -0,0 +1,73 @@
+package log
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Logfmt send back the Structure formatted as logfmt (key=value pairs, sorted by key). Errors are flattened and values containing spaces, quotes or '=' are quoted.
+func (s Structure) Logfmt() string {
+	flat := s.Flatten()
+	keys := make([]string, 0, len(flat))
+	for key := range flat {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	builder := &strings.Builder{}
+	for i, key := range keys {
+		if 0 != i {
+			builder.WriteByte(' ')
+		}
+		builder.WriteString(logfmtKey(key))
+		builder.WriteByte('=')
+		builder.WriteString(logfmtValue(flat[key]))
+	}
+	return builder.String()
+}
+
+func logfmtKey(key string) string {
+	key = strings.Map(func(r rune) rune {
+		if r <= ' ' || '=' == r || '"' == r {
+			return '_'
+		}
+		return r
+	}, key)
+	if "" == key {
+		return "_"
+	}
+	return key
+}
+
+func logfmtValue(value interface{}) string {
+	var formatted string
+	if nil != value {
+		formatted = fmt.Sprint(value)
+	}
+	if "" == formatted {
+		return `""`
+	}
+	if strings.IndexFunc(formatted, func(r rune) bool {
+		return r <= ' ' || '=' == r || '"' == r || '\\' == r || !strconv.IsPrint(r)
+	}) != -1 {
+		return strconv.Quote(formatted)
+	}
+	return formatted
+}
+
+// Logfmt send back the entry formatted as logfmt: its time (if set), level and message, followed by the Structure.
+func (e Entry) Logfmt() string {
+	builder := &strings.Builder{}
+	if !e.Time.IsZero() {
+		builder.WriteString(TimeKey + "=" + e.Time.Format(time.RFC3339Nano) + " ")
+	}
+	builder.WriteString(LevelKey + "=" + strings.ToLower(e.Level.String()) + " ")
+	builder.WriteString(MessageKey + "=" + logfmtValue(e.Message()))
+	if 0 != len(e.Structure) {
+		builder.WriteString(" " + e.Structure.Logfmt())
+	}
+	return builder.String()
+}
@@ -0,0 +1,35 @@
+package log
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestStructure_Logfmt(t *testing.T) {
+	cases := []struct {
+		Structure Structure
+		Expected  string
+	}{
+		{Structure: Structure{}, Expected: ""},
+		{Structure: Structure{"b": 2, "a": "test"}, Expected: "a=test b=2"},
+		{Structure: Structure{"msg": "with space", "quote": `a"b`, "empty": "", "nil": nil}, Expected: `empty="" msg="with space" nil="" quote="a\"b"`},
+		{Structure: Structure{"bad key": "a=b"}, Expected: `bad_key="a=b"`},
+		{Structure: Structure{"error": errors.New("test")}, Expected: "error.message=test error.type=*errors.errorString"},
+	}
+
+	for _, test := range cases {
+		if received := test.Structure.Logfmt(); test.Expected != received {
+			t.Errorf("Error (Mismatched strings) [Expected: '%s'; Received: '%s']", test.Expected, received)
+		}
+	}
+}
+
+func TestEntry_Logfmt(t *testing.T) {
+	expected := `time=2016-08-13T15:40:05.123456Z level=warn message="Test message" user=john`
+	if received := testEntry(WARN, Structure{"user": "john"}, "Test message").Logfmt(); expected != received {
+		t.Errorf("Error (Mismatched strings) [Expected: '%s'; Received: '%s']", expected, received)
+	}
+	if received := (Entry{Level: INFO, Values: []interface{}{"Test"}}).Logfmt(); "level=info message=Test" != received {
+		t.Errorf("Error (Mismatched strings) [Expected: '%s'; Received: '%s']", "level=info message=Test", received)
+	}
+}
@@ -0,0 +1,312 @@
+package log
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LokiFormat is the format of the log lines sent to Loki
+type LokiFormat int
+
+// Supported line formats
+const (
+	LokiJSON LokiFormat = iota
+	LokiLogfmt
+)
+
+// LokiEncoding is the encoding of the push requests
+type LokiEncoding int
+
+// Supported encodings
+const (
+	LokiProtobuf LokiEncoding = iota
+	LokiJSONEncoding
+)
+
+// lokiLabelName match the characters not allowed in label names
+var lokiLabelName = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+// LokiWriter send entries to Grafana Loki, using its push API (URL is the full push endpoint, like http://localhost:3100/loki/api/v1/push). Entries are sent in the background, by batches of BatchSize entries or every BatchWait, as snappy compressed protobuf (the default) or JSON.
+//
+// Every entry is labelled with Labels, its level and the keys of its Structure listed in LabelKeys, which should only be used for low-cardinality values. The other keys are kept in the line, written as JSON (the default, using Formatter if any) or logfmt. Failed requests are retried up to MaxRetries times, waiting from MinBackoff to MaxBackoff between attempts; client errors other than 429 aren't retried.
+type LokiWriter struct {
+	URL        string
+	TenantID   string
+	Labels     map[string]string
+	LabelKeys  []string
+	Format     LokiFormat
+	Formatter  Formatter
+	Encoding   LokiEncoding
+	BatchSize  int
+	BatchWait  time.Duration
+	MaxRetries int
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	Client     *http.Client
+
+	once    sync.Once
+	batcher *batcher
+}
+
+func (w *LokiWriter) init() {
+	w.once.Do(func() {
+		if 0 == w.MaxRetries {
+			w.MaxRetries = defaultMaxRetries
+		}
+		if 0 == w.MinBackoff {
+			w.MinBackoff = defaultMinBackoff
+		}
+		if 0 == w.MaxBackoff {
+			w.MaxBackoff = defaultMaxBackoff
+		}
+		if nil == w.Client {
+			w.Client = &http.Client{Timeout: defaultTimeout}
+		}
+		w.batcher = newBatcher("Loki", w.BatchSize, w.BatchWait, w.send)
+	})
+}
+
+// WriteEntry queue the entry, to be sent with the next batch
+func (w *LokiWriter) WriteEntry(e Entry) error {
+	w.init()
+	w.batcher.add(e)
+	return nil
+}
+
+// Flush send the queued entries
+func (w *LokiWriter) Flush() error {
+	w.init()
+	return w.batcher.flush()
+}
+
+// Close stop sending entries in the background and send the queued ones
+func (w *LokiWriter) Close() error {
+	w.init()
+	return w.batcher.close()
+}
+
+func (w *LokiWriter) send(entries []Entry) error {
+	body, contentType, err := w.Encode(entries)
+	if nil != err {
+		return err
+	}
+	return retry(w.MaxRetries, w.MinBackoff, w.MaxBackoff, func() error {
+		req, err := http.NewRequest(http.MethodPost, w.URL, bytes.NewReader(body))
+		if nil != err {
+			return permanentError{err}
+		}
+		req.Header.Set("Content-Type", contentType)
+		if LokiProtobuf == w.Encoding {
+			req.Header.Set("Content-Encoding", "snappy")
+		}
+		if "" != w.TenantID {
+			req.Header.Set("X-Scope-OrgID", w.TenantID)
+		}
+		resp, err := w.Client.Do(req)
+		if nil != err {
+			return err
+		}
+		return checkResponse(resp)
+	})
+}
+
+// lokiStream is a set of entries sharing the same labels
+type lokiStream struct {
+	labels  map[string]string
+	entries []lokiEntry
+}
+
+type lokiEntry struct {
+	time time.Time
+	line string
+}
+
+// Encode serialize the entries as a push request, sending back its content type
+func (w *LokiWriter) Encode(entries []Entry) ([]byte, string, error) {
+	streams, err := w.streams(entries)
+	if nil != err {
+		return nil, "", err
+	}
+	if LokiJSONEncoding == w.Encoding {
+		body, err := encodeLokiJSON(streams)
+		return body, "application/json", err
+	}
+	return snappyEncode(encodeLokiProtobuf(streams)), "application/x-protobuf", nil
+}
+
+// streams group the entries by labels, in the order of their first entry, each stream being sorted by time
+func (w *LokiWriter) streams(entries []Entry) ([]*lokiStream, error) {
+	var streams []*lokiStream
+	byLabels := make(map[string]*lokiStream)
+	for _, e := range entries {
+		labels, fields := w.split(e)
+		line, err := w.line(Entry{Time: e.Time, Level: e.Level, Structure: fields, Values: e.Values})
+		if nil != err {
+			return nil, err
+		}
+		key := lokiLabels(labels)
+		stream, ok := byLabels[key]
+		if !ok {
+			stream = &lokiStream{labels: labels}
+			byLabels[key] = stream
+			streams = append(streams, stream)
+		}
+		stream.entries = append(stream.entries, lokiEntry{time: e.Time, line: line})
+	}
+	for _, stream := range streams {
+		sort.SliceStable(stream.entries, func(i, j int) bool {
+			return stream.entries[i].time.Before(stream.entries[j].time)
+		})
+	}
+	return streams, nil
+}
+
+// split separate the labels of the entry from the fields kept in the line
+func (w *LokiWriter) split(e Entry) (map[string]string, Structure) {
+	labels := make(map[string]string, len(w.Labels)+len(w.LabelKeys)+1)
+	for name, value := range w.Labels {
+		labels[lokiLabelName.ReplaceAllString(name, "_")] = value
+	}
+	labels[LevelKey] = strings.ToLower(e.Level.String())
+
+	fields := Structure{}.With(e.Structure)
+	for _, key := range w.LabelKeys {
+		if value, ok := fields[key]; ok {
+			labels[lokiLabelName.ReplaceAllString(key, "_")] = fmt.Sprint(value)
+			delete(fields, key)
+		}
+	}
+	return labels, fields
+}
+
+func (w *LokiWriter) line(e Entry) (string, error) {
+	if LokiLogfmt == w.Format {
+		line := logfmtKey(MessageKey) + "=" + logfmtValue(e.Message())
+		if 0 != len(e.Structure) {
+			line += " " + e.Structure.Logfmt()
+		}
+		return line, nil
+	}
+	var document map[string]interface{}
+	if nil == w.Formatter {
+		// The level is already a label
+		document = e.Document()
+		delete(document, LevelKey)
+	} else {
+		var err error
+		if document, err = w.Formatter.Format(e); nil != err {
+			return "", err
+		}
+	}
+	line, err := json.Marshal(document)
+	return string(line), err
+}
+
+// lokiLabels format the labels as a selector, like {app="api", level="info"}
+func lokiLabels(labels map[string]string) string {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	pairs := make([]string, 0, len(names))
+	for _, name := range names {
+		pairs = append(pairs, name+"="+strconv.Quote(labels[name]))
+	}
+	return "{" + strings.Join(pairs, ", ") + "}"
+}
+
+func encodeLokiJSON(streams []*lokiStream) ([]byte, error) {
+	type jsonStream struct {
+		Stream map[string]string `json:"stream"`
+		Values [][2]string       `json:"values"`
+	}
+	request := struct {
+		Streams []jsonStream `json:"streams"`
+	}{}
+	for _, stream := range streams {
+		values := make([][2]string, 0, len(stream.entries))
+		for _, e := range stream.entries {
+			values = append(values, [2]string{strconv.FormatInt(e.time.UnixNano(), 10), e.line})
+		}
+		request.Streams = append(request.Streams, jsonStream{Stream: stream.labels, Values: values})
+	}
+	return json.Marshal(request)
+}
+
+// encodeLokiProtobuf encode the streams as a logproto.PushRequest:
+//
+//	PushRequest   { repeated StreamAdapter streams = 1; }
+//	StreamAdapter { string labels = 1; repeated EntryAdapter entries = 2; }
+//	EntryAdapter  { google.protobuf.Timestamp timestamp = 1; string line = 2; }
+func encodeLokiProtobuf(streams []*lokiStream) []byte {
+	var request []byte
+	for _, stream := range streams {
+		message := appendProtobufBytes(nil, 1, []byte(lokiLabels(stream.labels)))
+		for _, e := range stream.entries {
+			var timestamp []byte
+			timestamp = appendProtobufVarint(timestamp, 1, uint64(e.time.Unix()))
+			timestamp = appendProtobufVarint(timestamp, 2, uint64(e.time.Nanosecond()))
+			entry := appendProtobufBytes(nil, 1, timestamp)
+			entry = appendProtobufBytes(entry, 2, []byte(e.line))
+			message = appendProtobufBytes(message, 2, entry)
+		}
+		request = appendProtobufBytes(request, 1, message)
+	}
+	return request
+}
+
+// appendProtobufVarint append a varint field. Zero values are omitted, as proto3 does.
+func appendProtobufVarint(buffer []byte, field int, value uint64) []byte {
+	if 0 == value {
+		return buffer
+	}
+	buffer = binary.AppendUvarint(buffer, uint64(field)<<3)
+	return binary.AppendUvarint(buffer, value)
+}
+
+// appendProtobufBytes append a length-delimited field (string, bytes or embedded message)
+func appendProtobufBytes(buffer []byte, field int, value []byte) []byte {
+	buffer = binary.AppendUvarint(buffer, uint64(field)<<3|2)
+	buffer = binary.AppendUvarint(buffer, uint64(len(value)))
+	return append(buffer, value...)
+}
+
+// LokiLog is an AgnosticLogger sending entries at or above Level to Loki through Writer. Queued entries are sent before PANIC and FATAL entries end the program.
+type LokiLog struct {
+	Writer    *LokiWriter
+	Level     Level
+	structure Structure
+}
+
+// Log queue the entry, to be sent with its labels and its line.
+func (l LokiLog) Log(lvl Level, str Structure, v ...interface{}) {
+	if l.Enabled(lvl) {
+		entry := NewEntry(lvl, Structure{}.With(l.structure).With(str), v...)
+		reportError("Loki", l.Writer.WriteEntry(entry))
+		if lvl >= FATAL {
+			reportError("Loki", l.Writer.Flush())
+		}
+		terminate(lvl, entry.Message())
+	}
+}
+
+// Enabled send back true if entries of the level are sent
+func (l LokiLog) Enabled(lvl Level) bool {
+	return nil != l.Writer && lvl >= l.Level
+}
+
+// With add some fields to a new logger created from the source and return it
+func (l LokiLog) With(str Structure) AgnosticLogger {
+	l.structure = Structure{}.With(l.structure).With(str)
+	return l
+}
@@ -0,0 +1,231 @@
+package log
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// lokiPush is a push request received by a fake Loki server
+type lokiPush struct {
+	Header  http.Header
+	Streams map[string][]string
+}
+
+func newFakeLoki(t *testing.T, failures int) (*httptest.Server, <-chan lokiPush) {
+	pushes := make(chan lokiPush, 10)
+	var mutex sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		fail := 0 < failures
+		failures--
+		mutex.Unlock()
+		if fail {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		if "/loki/api/v1/push" != r.URL.Path {
+			http.NotFound(w, r)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if nil != err {
+			t.Error(err)
+			return
+		}
+		var streams map[string][]string
+		switch r.Header.Get("Content-Type") {
+		case "application/json":
+			streams, err = decodeLokiJSON(body)
+		case "application/x-protobuf":
+			if body, err = snappyDecode(body); nil == err {
+				streams, err = decodeLokiProtobuf(body)
+			}
+		default:
+			err = errors.New("unexpected content type")
+		}
+		if nil != err {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		pushes <- lokiPush{Header: r.Header, Streams: streams}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	return server, pushes
+}
+
+func decodeLokiJSON(body []byte) (map[string][]string, error) {
+	var request struct {
+		Streams []struct {
+			Stream map[string]string `json:"stream"`
+			Values [][2]string       `json:"values"`
+		} `json:"streams"`
+	}
+	if err := json.Unmarshal(body, &request); nil != err {
+		return nil, err
+	}
+	streams := make(map[string][]string)
+	for _, stream := range request.Streams {
+		labels := lokiLabels(stream.Stream)
+		for _, value := range stream.Values {
+			streams[labels] = append(streams[labels], value[1])
+		}
+	}
+	return streams, nil
+}
+
+// decodeProtobuf split a message into its fields, sending back the varints as uint64 and the length-delimited fields as []byte
+func decodeProtobuf(message []byte) (map[int][]interface{}, error) {
+	fields := make(map[int][]interface{})
+	for 0 != len(message) {
+		key, n := binary.Uvarint(message)
+		if n <= 0 {
+			return nil, errors.New("invalid key")
+		}
+		message = message[n:]
+		value, n := binary.Uvarint(message)
+		if n <= 0 {
+			return nil, errors.New("invalid value")
+		}
+		message = message[n:]
+		switch key & 0x07 {
+		case 0:
+			fields[int(key>>3)] = append(fields[int(key>>3)], value)
+		case 2:
+			if uint64(len(message)) < value {
+				return nil, errors.New("truncated field")
+			}
+			fields[int(key>>3)] = append(fields[int(key>>3)], message[:value])
+			message = message[value:]
+		default:
+			return nil, errors.New("unexpected wire type")
+		}
+	}
+	return fields, nil
+}
+
+func decodeLokiProtobuf(body []byte) (map[string][]string, error) {
+	request, err := decodeProtobuf(body)
+	if nil != err {
+		return nil, err
+	}
+	streams := make(map[string][]string)
+	for _, data := range request[1] {
+		stream, err := decodeProtobuf(data.([]byte))
+		if nil != err {
+			return nil, err
+		}
+		labels := string(stream[1][0].([]byte))
+		for _, data := range stream[2] {
+			entry, err := decodeProtobuf(data.([]byte))
+			if nil != err {
+				return nil, err
+			}
+			timestamp, err := decodeProtobuf(entry[1][0].([]byte))
+			if nil != err {
+				return nil, err
+			}
+			if 0 == len(timestamp[1]) || time.Since(time.Unix(int64(timestamp[1][0].(uint64)), 0)) > time.Minute {
+				return nil, errors.New("invalid timestamp")
+			}
+			streams[labels] = append(streams[labels], string(entry[2][0].([]byte)))
+		}
+	}
+	return streams, nil
+}
+
+func receiveLokiPush(t *testing.T, pushes <-chan lokiPush) lokiPush {
+	select {
+	case push := <-pushes:
+		return push
+	case <-time.After(5 * time.Second):
+		t.Fatal("Error (No push request received)")
+	}
+	return lokiPush{}
+}
+
+func TestLokiLog_Protobuf(t *testing.T) {
+	server, pushes := newFakeLoki(t, 0)
+	defer server.Close()
+
+	writer := &LokiWriter{URL: server.URL + "/loki/api/v1/push", TenantID: "tenant", Labels: map[string]string{"app": "api"}, LabelKeys: []string{"region"}, BatchSize: 3}
+	defer writer.Close()
+	loki := LokiLog{Writer: writer, Level: INFO}.With(Structure{"region": "eu"})
+	loki.Log(DEBUG, Structure{}, "Filtered")
+	loki.Log(INFO, Structure{"user": "john"}, "First")
+	loki.Log(WARN, Structure{"count": 2}, "Second")
+	loki.Log(INFO, Structure{"region": "us"}, "Third")
+
+	push := receiveLokiPush(t, pushes)
+	if "tenant" != push.Header.Get("X-Scope-OrgID") || "snappy" != push.Header.Get("Content-Encoding") {
+		t.Errorf("Error (Mismatched headers) [Received: '%v']", push.Header)
+	}
+	expected := map[string][]string{
+		`{app="api", level="info", region="eu"}`: {`{"message":"First","user":"john"}`},
+		`{app="api", level="warn", region="eu"}`: {`{"count":2,"message":"Second"}`},
+		`{app="api", level="info", region="us"}`: {`{"message":"Third"}`},
+	}
+	if len(expected) != len(push.Streams) {
+		t.Errorf("Error (Mismatched streams) [Received: '%v']", push.Streams)
+	}
+	for labels, lines := range expected {
+		if received := push.Streams[labels]; 1 != len(received) || lines[0] != received[0] {
+			t.Errorf("Error (Mismatched lines of %s) [Expected: '%v'; Received: '%v']", labels, lines, received)
+		}
+	}
+}
+
+func TestLokiLog_JSONLogfmt(t *testing.T) {
+	server, pushes := newFakeLoki(t, 0)
+	defer server.Close()
+
+	writer := &LokiWriter{URL: server.URL + "/loki/api/v1/push", Format: LokiLogfmt, Encoding: LokiJSONEncoding}
+	defer writer.Close()
+	LokiLog{Writer: writer}.Log(ERROR, Structure{"user": "john doe"}, "Message")
+	if err := writer.Flush(); nil != err {
+		t.Fatal(err)
+	}
+
+	push := receiveLokiPush(t, pushes)
+	expected := `message=Message user="john doe"`
+	if lines := push.Streams[`{level="error"}`]; 1 != len(lines) || expected != lines[0] {
+		t.Errorf("Error (Mismatched lines) [Expected: '%s'; Received: '%v']", expected, push.Streams)
+	}
+}
+
+func TestLokiWriter_Retry(t *testing.T) {
+	server, pushes := newFakeLoki(t, 2)
+	defer server.Close()
+
+	writer := &LokiWriter{URL: server.URL + "/loki/api/v1/push", MinBackoff: time.Millisecond}
+	defer writer.Close()
+	writer.WriteEntry(NewEntry(INFO, Structure{}, "Message"))
+	if err := writer.Flush(); nil != err {
+		t.Fatal(err)
+	}
+	receiveLokiPush(t, pushes)
+}
+
+func TestLokiWriter_ClientError(t *testing.T) {
+	server, _ := newFakeLoki(t, 0)
+	defer server.Close()
+
+	writer := &LokiWriter{URL: server.URL + "/unknown", MinBackoff: time.Hour}
+	defer writer.Close()
+	writer.WriteEntry(NewEntry(INFO, Structure{}, "Message"))
+	if err := writer.Flush(); nil == err || !strings.Contains(err.Error(), "404") {
+		t.Errorf("Error (Client error not reported) [Received: '%v']", err)
+	}
+}
+
+func TestLokiLog_NoWriter(t *testing.T) {
+	LokiLog{}.With(Structure{}).Log(PANIC, Structure{}, "Message")
+}
@@ -0,0 +1,365 @@
+package log
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"time"
+)
+
+// msgpackEncoder write values using the MessagePack format. Only the types needed by the backends are supported natively, the others being written as strings.
+type msgpackEncoder struct {
+	buffer *bytes.Buffer
+}
+
+func (e msgpackEncoder) writeArrayHeader(size int) {
+	switch {
+	case size < 16:
+		e.buffer.WriteByte(0x90 | byte(size))
+	case size <= math.MaxUint16:
+		e.buffer.WriteByte(0xdc)
+		binary.Write(e.buffer, binary.BigEndian, uint16(size))
+	default:
+		e.buffer.WriteByte(0xdd)
+		binary.Write(e.buffer, binary.BigEndian, uint32(size))
+	}
+}
+
+func (e msgpackEncoder) writeMapHeader(size int) {
+	switch {
+	case size < 16:
+		e.buffer.WriteByte(0x80 | byte(size))
+	case size <= math.MaxUint16:
+		e.buffer.WriteByte(0xde)
+		binary.Write(e.buffer, binary.BigEndian, uint16(size))
+	default:
+		e.buffer.WriteByte(0xdf)
+		binary.Write(e.buffer, binary.BigEndian, uint32(size))
+	}
+}
+
+func (e msgpackEncoder) writeString(value string) {
+	size := len(value)
+	switch {
+	case size < 32:
+		e.buffer.WriteByte(0xa0 | byte(size))
+	case size <= math.MaxUint8:
+		e.buffer.WriteByte(0xd9)
+		e.buffer.WriteByte(byte(size))
+	case size <= math.MaxUint16:
+		e.buffer.WriteByte(0xda)
+		binary.Write(e.buffer, binary.BigEndian, uint16(size))
+	default:
+		e.buffer.WriteByte(0xdb)
+		binary.Write(e.buffer, binary.BigEndian, uint32(size))
+	}
+	e.buffer.WriteString(value)
+}
+
+func (e msgpackEncoder) writeBinary(value []byte) {
+	size := len(value)
+	switch {
+	case size <= math.MaxUint8:
+		e.buffer.WriteByte(0xc4)
+		e.buffer.WriteByte(byte(size))
+	case size <= math.MaxUint16:
+		e.buffer.WriteByte(0xc5)
+		binary.Write(e.buffer, binary.BigEndian, uint16(size))
+	default:
+		e.buffer.WriteByte(0xc6)
+		binary.Write(e.buffer, binary.BigEndian, uint32(size))
+	}
+	e.buffer.Write(value)
+}
+
+func (e msgpackEncoder) writeInt(value int64) {
+	switch {
+	case value >= 0:
+		e.writeUint(uint64(value))
+	case value >= -32:
+		e.buffer.WriteByte(byte(value))
+	case value >= math.MinInt8:
+		e.buffer.WriteByte(0xd0)
+		e.buffer.WriteByte(byte(value))
+	case value >= math.MinInt16:
+		e.buffer.WriteByte(0xd1)
+		binary.Write(e.buffer, binary.BigEndian, int16(value))
+	case value >= math.MinInt32:
+		e.buffer.WriteByte(0xd2)
+		binary.Write(e.buffer, binary.BigEndian, int32(value))
+	default:
+		e.buffer.WriteByte(0xd3)
+		binary.Write(e.buffer, binary.BigEndian, value)
+	}
+}
+
+func (e msgpackEncoder) writeUint(value uint64) {
+	switch {
+	case value < 128:
+		e.buffer.WriteByte(byte(value))
+	case value <= math.MaxUint8:
+		e.buffer.WriteByte(0xcc)
+		e.buffer.WriteByte(byte(value))
+	case value <= math.MaxUint16:
+		e.buffer.WriteByte(0xcd)
+		binary.Write(e.buffer, binary.BigEndian, uint16(value))
+	case value <= math.MaxUint32:
+		e.buffer.WriteByte(0xce)
+		binary.Write(e.buffer, binary.BigEndian, uint32(value))
+	default:
+		e.buffer.WriteByte(0xcf)
+		binary.Write(e.buffer, binary.BigEndian, value)
+	}
+}
+
+// writeEventTime write the time using the EventTime extension of the Fluent forward protocol (extension 0: seconds and nanoseconds)
+func (e msgpackEncoder) writeEventTime(t time.Time) {
+	e.buffer.Write([]byte{0xd7, 0x00})
+	binary.Write(e.buffer, binary.BigEndian, uint32(t.Unix()))
+	binary.Write(e.buffer, binary.BigEndian, uint32(t.Nanosecond()))
+}
+
+func (e msgpackEncoder) writeValue(value interface{}) {
+	switch value := value.(type) {
+	case nil:
+		e.buffer.WriteByte(0xc0)
+	case bool:
+		if value {
+			e.buffer.WriteByte(0xc3)
+		} else {
+			e.buffer.WriteByte(0xc2)
+		}
+	case int:
+		e.writeInt(int64(value))
+	case int8:
+		e.writeInt(int64(value))
+	case int16:
+		e.writeInt(int64(value))
+	case int32:
+		e.writeInt(int64(value))
+	case int64:
+		e.writeInt(value)
+	case uint:
+		e.writeUint(uint64(value))
+	case uint8:
+		e.writeUint(uint64(value))
+	case uint16:
+		e.writeUint(uint64(value))
+	case uint32:
+		e.writeUint(uint64(value))
+	case uint64:
+		e.writeUint(value)
+	case float32:
+		e.buffer.WriteByte(0xca)
+		binary.Write(e.buffer, binary.BigEndian, math.Float32bits(value))
+	case float64:
+		e.buffer.WriteByte(0xcb)
+		binary.Write(e.buffer, binary.BigEndian, math.Float64bits(value))
+	case string:
+		e.writeString(value)
+	case []byte:
+		e.writeBinary(value)
+	case time.Time:
+		e.writeString(value.Format(time.RFC3339Nano))
+	case []interface{}:
+		e.writeArrayHeader(len(value))
+		for _, item := range value {
+			e.writeValue(item)
+		}
+	case []string:
+		e.writeArrayHeader(len(value))
+		for _, item := range value {
+			e.writeString(item)
+		}
+	case StackTrace:
+		e.writeValue([]string(value))
+	case map[string]interface{}:
+		e.writeMap(value)
+	case Structure:
+		e.writeMap(value)
+	case error:
+		e.writeMap(NewErrorDetail(value).Map())
+	case ErrorDetail:
+		e.writeMap(value.Map())
+	default:
+		e.writeString(fmt.Sprint(value))
+	}
+}
+
+// writeMap write the map with its keys sorted, so the output is deterministic
+func (e msgpackEncoder) writeMap(value map[string]interface{}) {
+	keys := make([]string, 0, len(value))
+	for key := range value {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	e.writeMapHeader(len(keys))
+	for _, key := range keys {
+		e.writeString(key)
+		e.writeValue(value[key])
+	}
+}
+
+// msgpackExtension is a decoded MessagePack extension
+type msgpackExtension struct {
+	Type int8
+	Data []byte
+}
+
+// errMsgpackType is sent back when an unknown type is decoded
+var errMsgpackType = errors.New("unsupported MessagePack type")
+
+// decodeMsgpack read a single value. Maps are decoded as map[string]interface{} (non-string keys being formatted), arrays as []interface{}, integers as int64 or uint64 and extensions as msgpackExtension.
+func decodeMsgpack(reader *bufio.Reader) (interface{}, error) {
+	code, err := reader.ReadByte()
+	if nil != err {
+		return nil, err
+	}
+	switch {
+	case code <= 0x7f:
+		return int64(code), nil
+	case code >= 0xe0:
+		return int64(int8(code)), nil
+	case code&0xf0 == 0x80:
+		return decodeMsgpackMap(reader, int(code&0x0f))
+	case code&0xf0 == 0x90:
+		return decodeMsgpackArray(reader, int(code&0x0f))
+	case code&0xe0 == 0xa0:
+		return readMsgpackString(reader, int(code&0x1f))
+	}
+
+	switch code {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xc5, 0xc6:
+		size, err := readMsgpackSize(reader, 1<<(code-0xc4))
+		if nil != err {
+			return nil, err
+		}
+		return readMsgpackBytes(reader, size)
+	case 0xca:
+		var value uint32
+		err := binary.Read(reader, binary.BigEndian, &value)
+		return float64(math.Float32frombits(value)), err
+	case 0xcb:
+		var value uint64
+		err := binary.Read(reader, binary.BigEndian, &value)
+		return math.Float64frombits(value), err
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		size, err := readMsgpackSize(reader, 1<<(code-0xcc))
+		return uint64(size), err
+	case 0xd0:
+		var value int8
+		err := binary.Read(reader, binary.BigEndian, &value)
+		return int64(value), err
+	case 0xd1:
+		var value int16
+		err := binary.Read(reader, binary.BigEndian, &value)
+		return int64(value), err
+	case 0xd2:
+		var value int32
+		err := binary.Read(reader, binary.BigEndian, &value)
+		return int64(value), err
+	case 0xd3:
+		var value int64
+		err := binary.Read(reader, binary.BigEndian, &value)
+		return value, err
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8:
+		return readMsgpackExtension(reader, 1<<(code-0xd4))
+	case 0xc7, 0xc8, 0xc9:
+		size, err := readMsgpackSize(reader, 1<<(code-0xc7))
+		if nil != err {
+			return nil, err
+		}
+		return readMsgpackExtension(reader, size)
+	case 0xd9, 0xda, 0xdb:
+		size, err := readMsgpackSize(reader, 1<<(code-0xd9))
+		if nil != err {
+			return nil, err
+		}
+		return readMsgpackString(reader, size)
+	case 0xdc, 0xdd:
+		size, err := readMsgpackSize(reader, 2<<(code-0xdc))
+		if nil != err {
+			return nil, err
+		}
+		return decodeMsgpackArray(reader, size)
+	case 0xde, 0xdf:
+		size, err := readMsgpackSize(reader, 2<<(code-0xde))
+		if nil != err {
+			return nil, err
+		}
+		return decodeMsgpackMap(reader, size)
+	}
+	return nil, errMsgpackType
+}
+
+// readMsgpackSize read a big endian unsigned integer of the given number of bytes
+func readMsgpackSize(reader *bufio.Reader, length int) (int, error) {
+	data, err := readMsgpackBytes(reader, length)
+	if nil != err {
+		return 0, err
+	}
+	var size uint64
+	for _, b := range data {
+		size = size<<8 | uint64(b)
+	}
+	return int(size), nil
+}
+
+func readMsgpackBytes(reader *bufio.Reader, size int) ([]byte, error) {
+	data := make([]byte, size)
+	_, err := io.ReadFull(reader, data)
+	return data, err
+}
+
+func readMsgpackString(reader *bufio.Reader, size int) (string, error) {
+	data, err := readMsgpackBytes(reader, size)
+	return string(data), err
+}
+
+func readMsgpackExtension(reader *bufio.Reader, size int) (interface{}, error) {
+	extensionType, err := reader.ReadByte()
+	if nil != err {
+		return nil, err
+	}
+	data, err := readMsgpackBytes(reader, size)
+	return msgpackExtension{Type: int8(extensionType), Data: data}, err
+}
+
+func decodeMsgpackArray(reader *bufio.Reader, size int) ([]interface{}, error) {
+	array := make([]interface{}, 0, size)
+	for i := 0; i < size; i++ {
+		value, err := decodeMsgpack(reader)
+		if nil != err {
+			return nil, err
+		}
+		array = append(array, value)
+	}
+	return array, nil
+}
+
+func decodeMsgpackMap(reader *bufio.Reader, size int) (map[string]interface{}, error) {
+	decoded := make(map[string]interface{}, size)
+	for i := 0; i < size; i++ {
+		key, err := decodeMsgpack(reader)
+		if nil != err {
+			return nil, err
+		}
+		value, err := decodeMsgpack(reader)
+		if nil != err {
+			return nil, err
+		}
+		decoded[fmt.Sprint(key)] = value
+	}
+	return decoded, nil
+}
@@ -0,0 +1,89 @@
+package log
+
+import (
+	"bufio"
+	"bytes"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMsgpack_RoundTrip(t *testing.T) {
+	cases := []struct {
+		Value    interface{}
+		Expected interface{}
+	}{
+		{Value: nil, Expected: nil},
+		{Value: true, Expected: true},
+		{Value: false, Expected: false},
+		{Value: 5, Expected: int64(5)},
+		{Value: -5, Expected: int64(-5)},
+		{Value: -100, Expected: int64(-100)},
+		{Value: -1000, Expected: int64(-1000)},
+		{Value: -100000, Expected: int64(-100000)},
+		{Value: int64(math.MinInt64), Expected: int64(math.MinInt64)},
+		{Value: 200, Expected: uint64(200)},
+		{Value: 1000, Expected: uint64(1000)},
+		{Value: uint32(100000), Expected: uint64(100000)},
+		{Value: uint64(math.MaxUint64), Expected: uint64(math.MaxUint64)},
+		{Value: float32(1.5), Expected: float64(1.5)},
+		{Value: 2.5, Expected: 2.5},
+		{Value: "short", Expected: "short"},
+		{Value: strings.Repeat("a", 100), Expected: strings.Repeat("a", 100)},
+		{Value: strings.Repeat("a", 1000), Expected: strings.Repeat("a", 1000)},
+		{Value: strings.Repeat("a", 70000), Expected: strings.Repeat("a", 70000)},
+		{Value: []byte("binary"), Expected: []byte("binary")},
+		{Value: []string{"a", "b"}, Expected: []interface{}{"a", "b"}},
+		{Value: make([]interface{}, 20), Expected: make([]interface{}, 20)},
+		{Value: Structure{"key": "value", "int": 1}, Expected: map[string]interface{}{"key": "value", "int": int64(1)}},
+		{Value: time.Date(2016, 8, 13, 15, 40, 5, 0, time.UTC), Expected: "2016-08-13T15:40:05Z"},
+		{Value: struct{ A int }{A: 1}, Expected: "{1}"},
+	}
+
+	for _, test := range cases {
+		buffer := &bytes.Buffer{}
+		msgpackEncoder{buffer: buffer}.writeValue(test.Value)
+
+		decoded, err := decodeMsgpack(bufio.NewReader(buffer))
+		if nil != err {
+			t.Errorf("Error (Decoding failed for '%v') [Received: '%v']", test.Value, err)
+			continue
+		}
+		if !reflect.DeepEqual(test.Expected, decoded) {
+			t.Errorf("Error (Mismatched values) [Expected: '%#v'; Received: '%#v']", test.Expected, decoded)
+		}
+	}
+}
+
+func TestMsgpack_LargeMap(t *testing.T) {
+	value := map[string]interface{}{}
+	for i := 0; i < 20; i++ {
+		value[strings.Repeat("k", i+1)] = i
+	}
+	buffer := &bytes.Buffer{}
+	msgpackEncoder{buffer: buffer}.writeValue(value)
+
+	decoded, err := decodeMsgpack(bufio.NewReader(buffer))
+	if nil != err {
+		t.Fatal(err)
+	}
+	if 20 != len(decoded.(map[string]interface{})) {
+		t.Errorf("Error (Mismatched map size) [Expected: '%d'; Received: '%d']", 20, len(decoded.(map[string]interface{})))
+	}
+}
+
+func TestMsgpack_EventTime(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	msgpackEncoder{buffer: buffer}.writeEventTime(time.Unix(1471102805, 123))
+
+	decoded, err := decodeMsgpack(bufio.NewReader(buffer))
+	if nil != err {
+		t.Fatal(err)
+	}
+	expect := msgpackExtension{Type: 0, Data: []byte{0x57, 0xaf, 0x3f, 0x55, 0, 0, 0, 123}}
+	if !reflect.DeepEqual(expect, decoded) {
+		t.Errorf("Error (Mismatched values) [Expected: '%#v'; Received: '%#v']", expect, decoded)
+	}
+}
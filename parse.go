@@ -0,0 +1,388 @@
+package log
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LineFormat is a format of log lines that can be parsed back into entries
+type LineFormat int
+
+// Supported line formats
+const (
+	// FormatAuto detect the format of every line
+	FormatAuto LineFormat = iota
+	// FormatJSON is a JSON document per line, as written by JSONLog or logrus' JSONFormatter
+	FormatJSON
+	// FormatLogfmt is key=value pairs, as written by Structure.Logfmt
+	FormatLogfmt
+	// FormatBasic is the default layout of BasicLog: "[LEVEL]message [key:value;...]", after the prefix of the Go logger
+	FormatBasic
+	// FormatLogrus is the layout of logrus' TextFormatter on terminals: "INFO[0000] message key=value". Out of terminals, it writes logfmt.
+	FormatLogrus
+)
+
+var lineFormatNames = map[LineFormat]string{
+	FormatAuto:   "auto",
+	FormatJSON:   "json",
+	FormatLogfmt: "logfmt",
+	FormatBasic:  "basic",
+	FormatLogrus: "logrus",
+}
+
+// String send back the name of the format
+func (f LineFormat) String() string {
+	return lineFormatNames[f]
+}
+
+// ParseLineFormat send back the format with the given name
+func ParseLineFormat(name string) (LineFormat, error) {
+	for format, formatName := range lineFormatNames {
+		if strings.EqualFold(name, formatName) {
+			return format, nil
+		}
+	}
+	return FormatAuto, fmt.Errorf("unknown format '%s'", name)
+}
+
+// Keys recognized when parsing entries
+var (
+	parsedMessageKeys = []string{MessageKey, "msg"}
+	parsedLevelKeys   = []string{LevelKey, "lvl", "severity", "log.level"}
+	parsedTimeKeys    = []string{TimeKey, "ts", "timestamp", "@timestamp"}
+)
+
+// basicLine match the lines written by BasicLog with the default flags of the Go logger: an optional date and time, the level between brackets, then the message
+var basicLine = regexp.MustCompile(`^(?:(\d{4}/\d{2}/\d{2}) )?(?:(\d{2}:\d{2}:\d{2}(?:\.\d+)?) )?(?:\S+:\d+: )?\[(PANIC|FATAL|ERROR|WARN|INFO|DEBUG|TRACE)\](.*)$`)
+
+// logrusLine match the lines written by logrus' TextFormatter on terminals (once colors removed): the level truncated to 4 characters, the time (or the number of seconds since the start of the program) and the message, padded to 44 characters, followed by the fields
+var logrusLine = regexp.MustCompile(`^(PANI|FATA|ERRO|WARN|INFO|DEBU)\[([^\]]*)\] (.*)$`)
+
+// logrusMessageWidth is the width of the messages written by logrus' TextFormatter on terminals
+const logrusMessageWidth = 44
+
+// ansiSequence match ANSI escape sequences
+var ansiSequence = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+// logfmtStart match the start of logfmt lines: a key directly followed by its value
+var logfmtStart = regexp.MustCompile(`^[^\s="]+=`)
+
+// errUnknownFormat is sent back when the format of a line can't be detected
+var errUnknownFormat = errors.New("unknown line format")
+
+// DetectFormat send back the format of the line, or FormatAuto if it can't be detected. Lines are only detected as logfmt if they start with a key=value pair.
+func DetectFormat(line string) LineFormat {
+	trimmed := strings.TrimSpace(line)
+	switch {
+	case strings.HasPrefix(trimmed, "{") && json.Valid([]byte(trimmed)):
+		return FormatJSON
+	case basicLine.MatchString(trimmed):
+		return FormatBasic
+	case logrusLine.MatchString(ansiSequence.ReplaceAllString(trimmed, "")):
+		return FormatLogrus
+	}
+	if !logfmtStart.MatchString(trimmed) {
+		return FormatAuto
+	}
+	if fields, err := ParseLogfmt(trimmed); nil == err && 0 != len(fields) {
+		return FormatLogfmt
+	}
+	return FormatAuto
+}
+
+// ParseLine parse a line written in the given format (detected if FormatAuto) back into an entry. The message, the level and the time are read from their usual keys (message or msg, level, lvl or severity, time, ts or timestamp), the other keys forming the Structure. Entries without level are INFO entries, and entries without time have a zero Time.
+func ParseLine(line string, format LineFormat) (Entry, error) {
+	if FormatAuto == format {
+		if format = DetectFormat(line); FormatAuto == format {
+			return Entry{}, errUnknownFormat
+		}
+	}
+	switch format {
+	case FormatJSON:
+		return parseJSONLine(line)
+	case FormatLogfmt:
+		fields, err := ParseLogfmt(line)
+		if nil != err {
+			return Entry{}, err
+		}
+		return entryOf(fields), nil
+	case FormatBasic:
+		return parseBasicLine(line)
+	case FormatLogrus:
+		return parseLogrusLine(line)
+	}
+	return Entry{}, fmt.Errorf("unsupported format %d", format)
+}
+
+func parseJSONLine(line string) (Entry, error) {
+	decoder := json.NewDecoder(strings.NewReader(line))
+	decoder.UseNumber()
+	var document map[string]interface{}
+	if err := decoder.Decode(&document); nil != err {
+		return Entry{}, err
+	}
+	if nil == document {
+		return Entry{}, errors.New("not a JSON object")
+	}
+	return entryOf(normalizeJSON(document).(map[string]interface{})), nil
+}
+
+// normalizeJSON convert the decoded numbers into int64 when possible, float64 otherwise
+func normalizeJSON(value interface{}) interface{} {
+	switch value := value.(type) {
+	case json.Number:
+		if integer, err := value.Int64(); nil == err {
+			return integer
+		}
+		float, _ := value.Float64()
+		return float
+	case map[string]interface{}:
+		for key, item := range value {
+			value[key] = normalizeJSON(item)
+		}
+	case []interface{}:
+		for i, item := range value {
+			value[i] = normalizeJSON(item)
+		}
+	}
+	return value
+}
+
+func parseBasicLine(line string) (Entry, error) {
+	match := basicLine.FindStringSubmatch(strings.TrimSpace(line))
+	if nil == match {
+		return Entry{}, errors.New("not a BasicLog line")
+	}
+	lvl, _ := ParseLevel(match[3])
+	entry := Entry{Level: lvl, Structure: Structure{}, Values: []interface{}{match[4]}}
+	if "" != match[2] {
+		date := match[1]
+		if "" == date {
+			date = time.Now().Format("2006/01/02")
+		}
+		if parsed, err := time.ParseInLocation("2006/01/02 15:04:05", date+" "+match[2], time.Local); nil == err {
+			entry.Time = parsed
+		}
+	} else if "" != match[1] {
+		entry.Time, _ = time.ParseInLocation("2006/01/02", match[1], time.Local)
+	}
+
+	// The fields are written by Structure.String, after the message: " [key:value;key:value]"
+	message := match[4]
+	if strings.HasSuffix(message, "]") {
+		if index := strings.LastIndex(message, " ["); -1 != index {
+			if fields, ok := parseStructureString(message[index+2 : len(message)-1]); ok {
+				entry.Structure = fields
+				entry.Values = []interface{}{message[:index]}
+			}
+		}
+	}
+	return entry, nil
+}
+
+func parseLogrusLine(line string) (Entry, error) {
+	match := logrusLine.FindStringSubmatch(strings.TrimSpace(ansiSequence.ReplaceAllString(line, "")))
+	if nil == match {
+		return Entry{}, errors.New("not a logrus line")
+	}
+	lvl, _ := ParseLevel(map[string]string{"PANI": "panic", "FATA": "fatal", "ERRO": "error", "WARN": "warn", "INFO": "info", "DEBU": "debug"}[match[1]])
+	entry := Entry{Level: lvl, Structure: Structure{}}
+	if parsed, err := time.Parse(time.RFC3339, match[2]); nil == err {
+		entry.Time = parsed
+	}
+
+	// The fields are separated from the message by two spaces at least, after its padding
+	text := match[3]
+	end := len(text)
+	if index := strings.Index(text[min(logrusMessageWidth, len(text)):], "  "); -1 != index {
+		end = min(logrusMessageWidth, len(text)) + index
+	}
+	entry.Values = []interface{}{strings.TrimRight(text[:end], " ")}
+
+	// Values are written without quotes, so words without '=' belong to the previous value
+	var key string
+	for _, word := range strings.Fields(text[end:]) {
+		index := strings.IndexByte(word, '=')
+		if index <= 0 {
+			if "" != key {
+				entry.Structure[key] = fmt.Sprint(entry.Structure[key], " ", word)
+			}
+			continue
+		}
+		key = word[:index]
+		entry.Structure[key] = word[index+1:]
+	}
+	return entry, nil
+}
+
+func parseStructureString(text string) (Structure, bool) {
+	fields := Structure{}
+	for _, pair := range strings.Split(text, ";") {
+		index := strings.IndexByte(pair, ':')
+		if index <= 0 {
+			return nil, false
+		}
+		fields[pair[:index]] = pair[index+1:]
+	}
+	return fields, true
+}
+
+// ParseLogfmt parse key=value pairs, values being either bare or quoted strings. Keys without value are set to true.
+func ParseLogfmt(line string) (Structure, error) {
+	fields := Structure{}
+	line = strings.TrimSpace(line)
+	for 0 != len(line) {
+		end := strings.IndexAny(line, "= ")
+		if -1 == end {
+			end = len(line)
+		}
+		key := line[:end]
+		if "" == key || strings.ContainsRune(key, '"') {
+			return nil, fmt.Errorf("invalid key at '%s'", line)
+		}
+		line = line[end:]
+		if !strings.HasPrefix(line, "=") {
+			fields[key] = true
+			line = strings.TrimLeft(line, " ")
+			continue
+		}
+		line = line[1:]
+
+		var value string
+		if strings.HasPrefix(line, `"`) {
+			quoted, err := strconv.QuotedPrefix(line)
+			if nil != err {
+				return nil, fmt.Errorf("invalid quoted value for key '%s'", key)
+			}
+			if value, err = strconv.Unquote(quoted); nil != err {
+				return nil, err
+			}
+			line = line[len(quoted):]
+			if 0 != len(line) && ' ' != line[0] {
+				return nil, fmt.Errorf("missing space after the value of key '%s'", key)
+			}
+		} else {
+			end := strings.IndexByte(line, ' ')
+			if -1 == end {
+				end = len(line)
+			}
+			value = line[:end]
+			line = line[end:]
+		}
+		fields[key] = value
+		line = strings.TrimLeft(line, " ")
+	}
+	return fields, nil
+}
+
+// entryOf build an entry from the parsed fields
+func entryOf(fields map[string]interface{}) Entry {
+	entry := Entry{Level: INFO, Structure: Structure(fields), Values: []interface{}{""}}
+	if value, key, ok := parsedField(fields, parsedMessageKeys); ok {
+		entry.Values = []interface{}{fmt.Sprint(value)}
+		delete(fields, key)
+	}
+	if value, key, ok := parsedField(fields, parsedLevelKeys); ok {
+		if lvl, err := ParseLevel(fmt.Sprint(value)); nil == err {
+			entry.Level = lvl
+			delete(fields, key)
+		}
+	}
+	if value, key, ok := parsedField(fields, parsedTimeKeys); ok {
+		if parsed, ok := parseTime(value); ok {
+			entry.Time = parsed
+			delete(fields, key)
+		}
+	}
+	return entry
+}
+
+func parsedField(fields map[string]interface{}, keys []string) (interface{}, string, bool) {
+	for _, key := range keys {
+		if value, ok := fields[key]; ok {
+			return value, key, true
+		}
+	}
+	return nil, "", false
+}
+
+// parseTime parse RFC 3339 times, and Unix times in seconds, milliseconds, microseconds or nanoseconds (see unixTime)
+func parseTime(value interface{}) (time.Time, bool) {
+	switch value := value.(type) {
+	case string:
+		for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05.999999999", "2006-01-02T15:04:05.999999999"} {
+			if parsed, err := time.Parse(layout, value); nil == err {
+				return parsed, true
+			}
+		}
+		if integer, err := strconv.ParseInt(value, 10, 64); nil == err {
+			return unixTime(integer), true
+		}
+		parsed, err := strconv.ParseFloat(value, 64)
+		if nil != err {
+			return time.Time{}, false
+		}
+		return unixFloatTime(parsed), true
+	case int64:
+		return unixTime(value), true
+	case float64:
+		return unixFloatTime(value), true
+	}
+	return time.Time{}, false
+}
+
+// unixTime convert a Unix time whose unit is picked from its magnitude: seconds below 1e11 (until year 5138), then milliseconds below 1e14, microseconds below 1e17 and nanoseconds above
+func unixTime(value int64) time.Time {
+	magnitude := value
+	if magnitude < 0 {
+		magnitude = -magnitude
+	}
+	switch {
+	case magnitude < 1e11:
+		return time.Unix(value, 0)
+	case magnitude < 1e14:
+		return time.UnixMilli(value)
+	case magnitude < 1e17:
+		return time.UnixMicro(value)
+	}
+	return time.Unix(0, value)
+}
+
+// unixFloatTime convert a Unix time holding a fraction, its unit being picked as by unixTime. It's rounded to the microsecond, float64 not being precise enough for nanoseconds.
+func unixFloatTime(value float64) time.Time {
+	switch magnitude := math.Abs(value); {
+	case magnitude >= 1e17:
+		value /= 1e9
+	case magnitude >= 1e14:
+		value /= 1e6
+	case magnitude >= 1e11:
+		value /= 1e3
+	}
+	seconds, fraction := math.Modf(value)
+	return time.Unix(int64(seconds), int64(math.Round(fraction*1e6))*1e3)
+}
+
+// ReadLines call the function for every line of the reader, line break included. The last line is sent even if it doesn't end with a line break. Lines aren't limited in size.
+func ReadLines(reader io.Reader, function func(line string)) error {
+	buffered := bufio.NewReader(reader)
+	for {
+		line, err := buffered.ReadString('\n')
+		if "" != line {
+			function(line)
+		}
+		if io.EOF == err {
+			return nil
+		}
+		if nil != err {
+			return err
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package log
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+)
+
+func TestDetectFormat(t *testing.T) {
+	cases := map[string]LineFormat{
+		`{"level":"info","message":"Test"}`:                  FormatJSON,
+		`level=info msg="Test message" user=john`:            FormatLogfmt,
+		`time="2016-08-13T15:40:05Z" level=warning msg=Test`: FormatLogfmt,
+		`[INFO]Message [user:john]`:                          FormatBasic,
+		`2016/08/13 15:40:05 [ERROR]Message`:                 FormatBasic,
+		`Just some text`:                                     FormatAuto,
+		`retry count = 3 failed`:                             FormatAuto,
+		`failed after retries=3`:                             FormatAuto,
+		`{"unterminated": `:                                  FormatAuto,
+	}
+	for line, expected := range cases {
+		if received := DetectFormat(line); expected != received {
+			t.Errorf("Error (Mismatched formats for '%s') [Expected: '%s'; Received: '%s']", line, expected, received)
+		}
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	expected := time.Date(2016, 8, 13, 15, 40, 5, 123456000, time.UTC)
+	cases := []struct {
+		Value    interface{}
+		Expected time.Time
+	}{
+		{Value: "2016-08-13T15:40:05.123456Z", Expected: expected},
+		{Value: "2016-08-13 15:40:05.123456", Expected: expected},
+		{Value: int64(1471102805), Expected: expected.Truncate(time.Second)},
+		{Value: float64(1471102805.123456), Expected: expected},
+		{Value: "1471102805", Expected: expected.Truncate(time.Second)},
+		{Value: int64(1471102805123), Expected: expected.Truncate(time.Millisecond)},
+		{Value: float64(1471102805123.456), Expected: expected},
+		{Value: "1471102805123", Expected: expected.Truncate(time.Millisecond)},
+		{Value: int64(1471102805123456), Expected: expected},
+		{Value: float64(1471102805123456), Expected: expected},
+		{Value: "1471102805123456", Expected: expected},
+		{Value: int64(1471102805123456789), Expected: expected.Add(789)},
+		{Value: "1471102805123456789", Expected: expected.Add(789)},
+		{Value: int64(-86400), Expected: time.Date(1969, 12, 31, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, test := range cases {
+		received, ok := parseTime(test.Value)
+		if !ok || !test.Expected.Equal(received) {
+			t.Errorf("Error (Mismatched times for '%v') [Expected: '%s'; Received: '%s']", test.Value, test.Expected, received)
+		}
+	}
+	if _, ok := parseTime("yesterday"); ok {
+		t.Error("Error (Invalid time parsed)")
+	}
+}
+
+func TestParseLine(t *testing.T) {
+	cases := []struct {
+		Line      string
+		Level     Level
+		Message   string
+		Time      time.Time
+		Structure Structure
+	}{
+		{
+			Line:      `{"level":"warn","message":"Test","time":"2016-08-13T15:40:05.123456Z","count":2,"ratio":0.5,"user":{"id":"42"}}`,
+			Level:     WARN,
+			Message:   "Test",
+			Time:      time.Date(2016, 8, 13, 15, 40, 5, 123456000, time.UTC),
+			Structure: Structure{"count": int64(2), "ratio": 0.5, "user": map[string]interface{}{"id": "42"}},
+		},
+		{
+			Line:      `{"severity":"ERROR","msg":"Test","ts":1471102805.5}`,
+			Level:     ERROR,
+			Message:   "Test",
+			Time:      time.Unix(1471102805, 500000000),
+			Structure: Structure{},
+		},
+		{
+			Line:      `time="2016-08-13T15:40:05Z" level=warning msg="Test message" user="john doe" empty="" flag`,
+			Level:     WARN,
+			Message:   "Test message",
+			Time:      time.Date(2016, 8, 13, 15, 40, 5, 0, time.UTC),
+			Structure: Structure{"user": "john doe", "empty": "", "flag": true},
+		},
+		{
+			Line:      `level=verbose message=Test`,
+			Level:     INFO,
+			Message:   "Test",
+			Structure: Structure{"level": "verbose"},
+		},
+		{
+			Line:      `2016/08/13 15:40:05 [DEBUG]Test message [user:john;count:2]`,
+			Level:     DEBUG,
+			Message:   "Test message",
+			Time:      time.Date(2016, 8, 13, 15, 40, 5, 0, time.Local),
+			Structure: Structure{"user": "john", "count": "2"},
+		},
+		{
+			Line:      `[TRACE]Array [1 2 3]`,
+			Level:     TRACE,
+			Message:   "Array [1 2 3]",
+			Structure: Structure{},
+		},
+	}
+
+	for _, test := range cases {
+		entry, err := ParseLine(test.Line, FormatAuto)
+		if nil != err {
+			t.Errorf("Error (Line '%s' not parsed) [Error: '%s']", test.Line, err)
+			continue
+		}
+		if test.Level != entry.Level || test.Message != entry.Message() || !test.Time.Equal(entry.Time) {
+			t.Errorf("Error (Mismatched entries for '%s') [Received: '%s' '%s' '%s']", test.Line, entry.Level, entry.Message(), entry.Time)
+		}
+		if !reflect.DeepEqual(test.Structure, entry.Structure) {
+			t.Errorf("Error (Mismatched structures for '%s') [Expected: '%v'; Received: '%v']", test.Line, test.Structure, entry.Structure)
+		}
+	}
+
+	for _, line := range []string{"Just some text", `key="unterminated`} {
+		if _, err := ParseLine(line, FormatAuto); nil == err {
+			t.Errorf("Error (Invalid line '%s' parsed)", line)
+		}
+	}
+}
+
+func TestParseLine_RoundTrip(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	str := Structure{"user": "john doe", "count": 2}
+	BasicLog{Logger: log.New(buffer, "", log.LstdFlags)}.Log(WARN, str, "Message")
+	JSONLog{Writer: buffer}.Log(WARN, str, "Message")
+	buffer.WriteString(NewEntry(WARN, Structure{LevelKey: "warn", MessageKey: "Message"}.With(str)).Structure.Logfmt() + "\n")
+
+	for _, line := range strings.Split(strings.TrimSpace(buffer.String()), "\n") {
+		entry, err := ParseLine(line, FormatAuto)
+		if nil != err {
+			t.Fatalf("Error (Line '%s' not parsed) [Error: '%s']", line, err)
+		}
+		if WARN != entry.Level || "Message" != entry.Message() || "john doe" != entry.Structure["user"] || "2" != fmt.Sprint(entry.Structure["count"]) {
+			t.Errorf("Error (Mismatched entries for '%s') [Received: '%+v']", line, entry)
+		}
+	}
+}
+
+func TestParseLineFormat(t *testing.T) {
+	for _, format := range []LineFormat{FormatAuto, FormatJSON, FormatLogfmt, FormatBasic} {
+		if parsed, err := ParseLineFormat(strings.ToUpper(format.String())); nil != err || format != parsed {
+			t.Errorf("Error (Format '%s' not parsed back) [Received: '%s'; Error: '%v']", format, parsed, err)
+		}
+	}
+	if _, err := ParseLineFormat("xml"); nil == err {
+		t.Error("Error (Unknown format parsed)")
+	}
+}
+
+func TestParseLine_Logrus(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	logger := logrus.New()
+	logger.Out = buffer
+	logger.Level = logrus.DebugLevel
+	fields := logrus.Fields{"user": "john doe", "count": 2}
+	for _, formatter := range []logrus.Formatter{
+		&logrus.TextFormatter{DisableColors: true},
+		&logrus.TextFormatter{ForceColors: true},
+		&logrus.TextFormatter{ForceColors: true, FullTimestamp: true, TimestampFormat: time.RFC3339},
+		&logrus.JSONFormatter{},
+	} {
+		logger.Formatter = formatter
+		logger.WithFields(fields).Warn("Test message")
+		logger.WithFields(fields).Debug("A message longer than the padding of the messages by logrus")
+	}
+
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	formats := []LineFormat{FormatLogfmt, FormatLogfmt, FormatLogrus, FormatLogrus, FormatLogrus, FormatLogrus, FormatJSON, FormatJSON}
+	for i, line := range lines {
+		if format := DetectFormat(line); formats[i] != format {
+			t.Errorf("Error (Mismatched formats for '%q') [Expected: '%s'; Received: '%s']", line, formats[i], format)
+		}
+		entry, err := ParseLine(line, FormatAuto)
+		if nil != err {
+			t.Fatalf("Error (Line '%q' not parsed) [Error: '%s']", line, err)
+		}
+		lvl, msg := WARN, "Test message"
+		if 1 == i%2 {
+			lvl, msg = DEBUG, "A message longer than the padding of the messages by logrus"
+		}
+		if lvl != entry.Level || msg != entry.Message() || "john doe" != entry.Structure["user"] || "2" != fmt.Sprint(entry.Structure["count"]) || 2 != len(entry.Structure) {
+			t.Errorf("Error (Mismatched entries for '%q') [Received: '%+v']", line, entry)
+		}
+		if (FormatLogrus != formats[i] || i >= 4) && time.Since(entry.Time) > time.Minute {
+			t.Errorf("Error (Time not parsed for '%q') [Received: '%s']", line, entry.Time)
+		}
+	}
+}
+
+func TestReadLines(t *testing.T) {
+	var lines []string
+	if err := ReadLines(strings.NewReader("first\n\nsecond\r\nlast"), func(line string) {
+		lines = append(lines, line)
+	}); nil != err {
+		t.Fatal(err)
+	}
+	expected := []string{"first\n", "\n", "second\r\n", "last"}
+	if fmt.Sprint(expected) != fmt.Sprint(lines) {
+		t.Errorf("Error (Mismatched lines) [Expected: '%q'; Received: '%q']", expected, lines)
+	}
+}
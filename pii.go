@@ -0,0 +1,164 @@
+package log
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// PIIDetector find personal data of a given kind in a text
+type PIIDetector interface {
+	// Kind send back the kind of data detected (eg: "email"), used as prefix of the tokens
+	Kind() string
+	// Detect send back the start and end indexes of every occurrence found in the text
+	Detect(text string) [][]int
+}
+
+// RegexpDetector is a PIIDetector based on a regular expression. When Validate is set, it's called on every match to discard false positives.
+type RegexpDetector struct {
+	Name     string
+	Pattern  *regexp.Regexp
+	Validate func(string) bool
+}
+
+// Kind send back the name of the detector
+func (d RegexpDetector) Kind() string {
+	return d.Name
+}
+
+// Detect send back the indexes of the valid matches of the pattern
+func (d RegexpDetector) Detect(text string) [][]int {
+	matches := d.Pattern.FindAllStringIndex(text, -1)
+	if nil == d.Validate {
+		return matches
+	}
+	toReturn := matches[:0]
+	for _, match := range matches {
+		if d.Validate(text[match[0]:match[1]]) {
+			toReturn = append(toReturn, match)
+		}
+	}
+	return toReturn
+}
+
+// DefaultPIIDetectors are the detectors used by NewPIILogger: emails, phone numbers, IPv4 and IPv6 addresses, and US/UK national IDs.
+var DefaultPIIDetectors = []PIIDetector{
+	RegexpDetector{Name: "email", Pattern: regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)},
+	RegexpDetector{Name: "ssn", Pattern: regexp.MustCompile(`\b\d{3}-\d{2}-\d{4}\b`)},
+	RegexpDetector{Name: "nino", Pattern: regexp.MustCompile(`\b[A-CEGHJ-PR-TW-Z]{2} ?\d{2} ?\d{2} ?\d{2} ?[A-D]\b`)},
+	RegexpDetector{Name: "phone", Pattern: regexp.MustCompile(`\+\d{1,3}(?:[ .-]?\(?\d{1,4}\)?){2,5}\b|\(\d{2,4}\)[ .-]?\d{3}[ .-]?\d{4}\b|\b\d{3}[.-]\d{3}[.-]\d{4}\b`)},
+	RegexpDetector{Name: "ip", Pattern: regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b`), Validate: isIP},
+	RegexpDetector{Name: "ip", Pattern: regexp.MustCompile(`(?i)\b(?:[0-9a-f]{0,4}:){2,7}[0-9a-f]{0,4}\b`), Validate: isIP},
+}
+
+func isIP(text string) bool {
+	return nil != net.ParseIP(text)
+}
+
+// PIILogger decorate an AgnosticLogger to replace the personal data found in messages and Structure values (including the ones of nested Structures and maps) by tokens. Tokens are built from a keyed hash of the data ("<kind:hash>"), so the same data always give the same token and entries can still be correlated, without exposing the data itself.
+//
+// Key must be kept secret: anyone knowing it can check if a token correspond to a given value.
+type PIILogger struct {
+	Logger    AgnosticLogger
+	Key       []byte
+	Detectors []PIIDetector
+}
+
+// NewPIILogger create a PIILogger using the default detectors
+func NewPIILogger(logger AgnosticLogger, key []byte) PIILogger {
+	return PIILogger{
+		Logger:    logger,
+		Key:       key,
+		Detectors: DefaultPIIDetectors,
+	}
+}
+
+// Log log the message, with its personal data tokenized, through the decorated logger.
+func (l PIILogger) Log(lvl Level, str Structure, v ...interface{}) {
+	if l.Enabled(lvl) {
+		l.Logger.Log(lvl, l.tokenizeStructure(str), l.tokenizeValues(v)...)
+	}
+}
+
+// Enabled send back true if the decorated logger is enabled for the level
+func (l PIILogger) Enabled(lvl Level) bool {
+	return Enabled(l.Logger, lvl)
+}
+
+// With send back a PIILogger decorating a logger containing the given fields, with their personal data tokenized.
+func (l PIILogger) With(str Structure) AgnosticLogger {
+	if nil != l.Logger {
+		l.Logger = l.Logger.With(l.tokenizeStructure(str))
+	}
+	return l
+}
+
+// Token send back the token replacing the given data
+func (l PIILogger) Token(kind string, data string) string {
+	mac := hmac.New(sha256.New, l.Key)
+	mac.Write([]byte(data))
+	return "<" + kind + ":" + hex.EncodeToString(mac.Sum(nil)[:8]) + ">"
+}
+
+// tokenizeStructure tokenize the personal data of the Structure, including the nested ones
+func (l PIILogger) tokenizeStructure(str Structure) Structure {
+	toReturn := make(Structure, len(str))
+	for key, value := range str {
+		switch nested := value.(type) {
+		case Structure:
+			toReturn[key] = l.tokenizeStructure(nested)
+		case map[string]interface{}:
+			toReturn[key] = map[string]interface{}(l.tokenizeStructure(nested))
+		default:
+			toReturn[key] = rewrite(value, l.tokenize)
+		}
+	}
+	return toReturn
+}
+
+func (l PIILogger) tokenizeValues(v []interface{}) []interface{} {
+	toReturn := make([]interface{}, len(v))
+	for i, value := range v {
+		toReturn[i] = rewrite(value, l.tokenize)
+	}
+	return toReturn
+}
+
+type piiMatch struct {
+	start int
+	end   int
+	kind  string
+}
+
+// tokenize replace every personal data found in the text by its token. When occurrences overlap, the first one found win.
+func (l PIILogger) tokenize(text string) string {
+	var matches []piiMatch
+	for _, detector := range l.Detectors {
+		for _, index := range detector.Detect(text) {
+			matches = append(matches, piiMatch{start: index[0], end: index[1], kind: detector.Kind()})
+		}
+	}
+	if 0 == len(matches) {
+		return text
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].start < matches[j].start
+	})
+
+	builder := strings.Builder{}
+	last := 0
+	for _, match := range matches {
+		if match.start < last {
+			continue
+		}
+		builder.WriteString(text[last:match.start])
+		builder.WriteString(l.Token(match.kind, text[match.start:match.end]))
+		last = match.end
+	}
+	builder.WriteString(text[last:])
+	return builder.String()
+}
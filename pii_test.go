@@ -0,0 +1,159 @@
+package log
+
+import (
+	"errors"
+	"io"
+	"log"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestPIILogger_Detection(t *testing.T) {
+	cases := []struct {
+		Text string
+		Kind string
+	}{
+		{Text: "contact john.doe@example.com now", Kind: "email"},
+		{Text: "call +32 475 12 34 56", Kind: "phone"},
+		{Text: "call (555) 123-4567", Kind: "phone"},
+		{Text: "call 555-123-4567", Kind: "phone"},
+		{Text: "from 192.168.1.10", Kind: "ip"},
+		{Text: "from 2001:db8::1", Kind: "ip"},
+		{Text: "ssn 123-45-6789", Kind: "ssn"},
+		{Text: "nino AB 12 34 56 C", Kind: "nino"},
+	}
+
+	for _, test := range cases {
+		recorder := newRecordingLogger()
+		NewPIILogger(recorder, []byte("key")).Log(INFO, Structure{}, test.Text)
+
+		msg := recorder.Entries()[0].Message
+		if !strings.Contains(msg, "<"+test.Kind+":") {
+			t.Errorf("Error (Data not tokenized) [Expected kind: '%s'; Received: '%s']", test.Kind, msg)
+		}
+	}
+}
+
+func TestPIILogger_NoFalsePositives(t *testing.T) {
+	cases := []string{
+		"2024-01-15",
+		"version 1.2.3",
+		"999.999.999.999",
+		"took 12:30",
+		"id 1234567",
+	}
+
+	for _, text := range cases {
+		recorder := newRecordingLogger()
+		NewPIILogger(recorder, []byte("key")).Log(INFO, Structure{}, text)
+
+		if msg := recorder.Entries()[0].Message; text != msg {
+			t.Errorf("Error (Mismatched strings) [Expected: '%s'; Received: '%s']", text, msg)
+		}
+	}
+}
+
+func TestPIILogger_Nested(t *testing.T) {
+	recorder := newRecordingLogger()
+	pii := NewPIILogger(recorder, []byte("key"))
+	pii.Log(INFO, Structure{
+		"user":    Structure{"email": "a@b.com", "id": 4},
+		"request": map[string]interface{}{"ip": "192.168.1.10", "path": "/login"},
+	}, "Message")
+
+	str := recorder.Entries()[0].Structure
+	user, ok := str["user"].(Structure)
+	if !ok || pii.Token("email", "a@b.com") != user["email"] || 4 != user["id"] {
+		t.Errorf("Error (Mismatched nested Structure) [Received: '%#v']", str["user"])
+	}
+	request, ok := str["request"].(map[string]interface{})
+	if !ok || pii.Token("ip", "192.168.1.10") != request["ip"] || "/login" != request["path"] {
+		t.Errorf("Error (Mismatched nested map) [Received: '%#v']", str["request"])
+	}
+}
+
+func TestPIILogger_StableTokens(t *testing.T) {
+	recorder := newRecordingLogger()
+	pii := NewPIILogger(recorder, []byte("key"))
+
+	pii.Log(INFO, Structure{"user": "john@example.com"}, "Login")
+	pii.Log(INFO, Structure{}, "Logout of ", "john@example.com")
+
+	entries := recorder.Entries()
+	token := pii.Token("email", "john@example.com")
+	if token != entries[0].Structure["user"] {
+		t.Errorf("Error (Mismatched strings) [Expected: '%s'; Received: '%v']", token, entries[0].Structure["user"])
+	}
+	if "Logout of "+token != entries[1].Message {
+		t.Errorf("Error (Mismatched strings) [Expected: '%s'; Received: '%s']", "Logout of "+token, entries[1].Message)
+	}
+	if token == NewPIILogger(recorder, []byte("other")).Token("email", "john@example.com") {
+		t.Errorf("Error (Token independent from the key) [Received: '%s']", token)
+	}
+}
+
+func TestPIILogger_Error(t *testing.T) {
+	recorder := newRecordingLogger()
+	NewPIILogger(recorder, []byte("key")).Log(ERROR, Structure{}.WithError(errors.New("unknown user john@example.com")), "Message")
+
+	detail, ok := recorder.Entries()[0].Structure[ErrorKey].(ErrorDetail)
+	if !ok {
+		t.Fatalf("Error (Error not converted) [Received: '%+v']", recorder.Entries()[0].Structure)
+	}
+	if strings.Contains(detail.Message, "john") {
+		t.Errorf("Error (Data not tokenized) [Received: '%s']", detail.Message)
+	}
+}
+
+func TestPIILogger_CustomDetector(t *testing.T) {
+	recorder := newRecordingLogger()
+	pii := PIILogger{
+		Logger:    recorder,
+		Key:       []byte("key"),
+		Detectors: []PIIDetector{RegexpDetector{Name: "customer", Pattern: regexp.MustCompile(`CUST-\d+`)}},
+	}
+
+	pii.With(Structure{"customer": "CUST-42"}).Log(INFO, Structure{"count": 2}, "Message")
+
+	str := recorder.Entries()[0].Structure
+	if pii.Token("customer", "CUST-42") != str["customer"] {
+		t.Errorf("Error (Mismatched strings) [Expected: '%s'; Received: '%v']", pii.Token("customer", "CUST-42"), str["customer"])
+	}
+	if 2 != str["count"] {
+		t.Errorf("Error (Value modified) [Expected: '%d'; Received: '%v']", 2, str["count"])
+	}
+}
+
+func TestPIILogger_NoLogger(t *testing.T) {
+	pii := PIILogger{}
+	pii.With(Structure{}).Log(INFO, Structure{}, "Message")
+}
+
+func benchmarkLogger() AgnosticLogger {
+	return BasicLog{Logger: log.New(io.Discard, "", 0), Level: TRACE}
+}
+
+func BenchmarkPIILogger_Baseline(b *testing.B) {
+	logger := benchmarkLogger()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		logger.Log(INFO, Structure{"user": "john@example.com", "count": i}, "Request from 192.168.1.10 handled")
+	}
+}
+
+func BenchmarkPIILogger(b *testing.B) {
+	logger := NewPIILogger(benchmarkLogger(), []byte("key"))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		logger.Log(INFO, Structure{"user": "john@example.com", "count": i}, "Request from 192.168.1.10 handled")
+	}
+}
+
+func BenchmarkPIILogger_NoPII(b *testing.B) {
+	logger := NewPIILogger(benchmarkLogger(), []byte("key"))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		logger.Log(INFO, Structure{"user": "john", "count": i}, "Request handled")
+	}
+}
@@ -0,0 +1,660 @@
+package log
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Query select entries, and optionally aggregate them. Queries are written as:
+//
+//	[aggregations] [LEVEL...] [by field,...] [where condition] [in the last duration | since time] [until time] [every duration]
+//
+// Aggregations are separated by commas: count, avg field, sum field, min field, max field, or pNN field for percentiles (p50, p95, p99.9...). The fields of the aggregations can be written between parentheses: avg(duration_ms).
+//
+// Conditions compare the level, the message, the time or the fields of the Structure (dotted keys reaching the fields of errors and nested structures) to values, with =, !=, >, >=, <, <=, ~ (regular expression), !~ or contains. They can be combined with and, or, not and parentheses. Values are compared as numbers, durations, levels or times when both sides are, as strings otherwise.
+//
+// For example: count ERROR by service where duration_ms > 500 in the last hour
+type Query struct {
+	Aggregations []Aggregation
+	Levels       []Level
+	GroupBy      []string
+	Since        time.Time
+	Until        time.Time
+	Every        time.Duration
+
+	filter queryCondition
+}
+
+// Aggregation is an aggregation function of a Query: count, avg, sum, min, max or percentile (of the values of Field)
+type Aggregation struct {
+	Function   string
+	Field      string
+	Percentile float64
+}
+
+// String send back the name of the aggregation, as written in queries: count, avg(field), p95(field)...
+func (a Aggregation) String() string {
+	switch a.Function {
+	case "count":
+		return a.Function
+	case "percentile":
+		return "p" + strconv.FormatFloat(a.Percentile, 'f', -1, 64) + "(" + a.Field + ")"
+	}
+	return a.Function + "(" + a.Field + ")"
+}
+
+// ParseQuery parse a query. Relative times (in the last ...) are computed from the current time.
+func ParseQuery(text string) (Query, error) {
+	tokens, err := tokenizeQuery(text)
+	if nil != err {
+		return Query{}, err
+	}
+	parser := &queryParser{tokens: tokens}
+	query, err := parser.parse()
+	if nil != err {
+		return Query{}, fmt.Errorf("invalid query '%s': %w", text, err)
+	}
+	return query, nil
+}
+
+// Match send back true if the entry is selected by the query
+func (q Query) Match(e Entry) bool {
+	if 0 != len(q.Levels) {
+		found := false
+		for _, lvl := range q.Levels {
+			found = found || lvl == e.Level
+		}
+		if !found {
+			return false
+		}
+	}
+	if !q.Since.IsZero() && e.Time.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && !e.Time.Before(q.Until) {
+		return false
+	}
+	return nil == q.filter || q.filter.match(e)
+}
+
+// Aggregated send back true if the query aggregates entries, false if it only select them
+func (q Query) Aggregated() bool {
+	return 0 != len(q.Aggregations)
+}
+
+// Columns send back the names of the columns of the rows of the query: the bucket (if any), the grouping fields and the aggregations
+func (q Query) Columns() []string {
+	var columns []string
+	if 0 != q.Every {
+		columns = append(columns, TimeKey)
+	}
+	columns = append(columns, q.GroupBy...)
+	for _, aggregation := range q.Aggregations {
+		columns = append(columns, aggregation.String())
+	}
+	return columns
+}
+
+// queryValue send back the value of the key in the entry: its level, message, time, or a field of the Structure
+func queryValue(e Entry, key string) (interface{}, bool) {
+	switch key {
+	case LevelKey:
+		return e.Level, true
+	case MessageKey:
+		return e.Message(), true
+	case TimeKey:
+		return e.Time, !e.Time.IsZero()
+	}
+	if value, ok := e.Structure[key]; ok {
+		return value, true
+	}
+	if !strings.Contains(key, ".") {
+		return nil, false
+	}
+	if value, ok := e.Structure.Flatten()[key]; ok {
+		return value, true
+	}
+
+	// Dotted keys also reach the fields of nested structures
+	var value interface{} = map[string]interface{}(e.Structure)
+	for _, name := range strings.Split(key, ".") {
+		switch current := value.(type) {
+		case Structure:
+			value = current[name]
+		case map[string]interface{}:
+			value = current[name]
+		default:
+			return nil, false
+		}
+		if nil == value {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// queryCondition is a condition of the where clause of a query
+type queryCondition interface {
+	match(e Entry) bool
+}
+
+type queryAnd []queryCondition
+
+func (c queryAnd) match(e Entry) bool {
+	for _, condition := range c {
+		if !condition.match(e) {
+			return false
+		}
+	}
+	return true
+}
+
+type queryOr []queryCondition
+
+func (c queryOr) match(e Entry) bool {
+	for _, condition := range c {
+		if condition.match(e) {
+			return true
+		}
+	}
+	return false
+}
+
+type queryNot struct {
+	condition queryCondition
+}
+
+func (c queryNot) match(e Entry) bool {
+	return !c.condition.match(e)
+}
+
+// queryComparison compare the value of a key to a literal
+type queryComparison struct {
+	key      string
+	operator string
+	literal  string
+	pattern  *regexp.Regexp
+}
+
+func (c queryComparison) match(e Entry) bool {
+	value, ok := queryValue(e, c.key)
+	if !ok {
+		return "!=" == c.operator || "!~" == c.operator
+	}
+	switch c.operator {
+	case "~":
+		return c.pattern.MatchString(queryString(value))
+	case "!~":
+		return !c.pattern.MatchString(queryString(value))
+	case "contains":
+		return strings.Contains(queryString(value), c.literal)
+	}
+
+	order, ok := compareQueryValues(value, c.literal)
+	if !ok {
+		return "!=" == c.operator
+	}
+	switch c.operator {
+	case "=":
+		return 0 == order
+	case "!=":
+		return 0 != order
+	case ">":
+		return order > 0
+	case ">=":
+		return order >= 0
+	case "<":
+		return order < 0
+	case "<=":
+		return order <= 0
+	}
+	return false
+}
+
+// compareQueryValues compare the value to the literal: as levels, times, numbers, durations, then strings. The order is negative, zero or positive when the value is lower, equal or greater. The values can't be compared if the literal isn't a level or a time but the value is.
+func compareQueryValues(value interface{}, literal string) (int, bool) {
+	switch value := value.(type) {
+	case Level:
+		lvl, err := ParseLevel(literal)
+		if nil != err {
+			return 0, false
+		}
+		return compareFloats(float64(value), float64(lvl)), true
+	case time.Time:
+		parsed, ok := parseTime(literal)
+		if !ok {
+			return 0, false
+		}
+		return value.Compare(parsed), true
+	}
+	if number, ok := queryNumber(value); ok {
+		if parsed, err := strconv.ParseFloat(literal, 64); nil == err {
+			return compareFloats(number, parsed), true
+		}
+	}
+	text := queryString(value)
+	if duration, err := time.ParseDuration(text); nil == err {
+		if parsed, err := time.ParseDuration(literal); nil == err {
+			return compareFloats(float64(duration), float64(parsed)), true
+		}
+	}
+	return strings.Compare(text, literal), true
+}
+
+func compareFloats(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// queryNumber convert the value into a number, if it's a number or a string holding one
+func queryNumber(value interface{}) (float64, bool) {
+	switch value := value.(type) {
+	case int:
+		return float64(value), true
+	case int8:
+		return float64(value), true
+	case int16:
+		return float64(value), true
+	case int32:
+		return float64(value), true
+	case int64:
+		return float64(value), true
+	case uint:
+		return float64(value), true
+	case uint8:
+		return float64(value), true
+	case uint16:
+		return float64(value), true
+	case uint32:
+		return float64(value), true
+	case uint64:
+		return float64(value), true
+	case float32:
+		return float64(value), true
+	case float64:
+		return value, true
+	case string:
+		number, err := strconv.ParseFloat(value, 64)
+		return number, nil == err && !math.IsNaN(number)
+	}
+	return 0, false
+}
+
+func queryString(value interface{}) string {
+	if lvl, ok := value.(Level); ok {
+		return strings.ToLower(lvl.String())
+	}
+	return fmt.Sprint(value)
+}
+
+// queryToken is a token of a query: a word, a quoted string or a symbol
+type queryToken struct {
+	text   string
+	quoted bool
+}
+
+// querySymbols are the symbols of the language, longest first
+var querySymbols = []string{"!=", ">=", "<=", "!~", "==", "=", ">", "<", "~", "(", ")", ","}
+
+func tokenizeQuery(text string) ([]queryToken, error) {
+	var tokens []queryToken
+	for text = strings.TrimSpace(text); 0 != len(text); text = strings.TrimSpace(text) {
+		if '"' == text[0] || '\'' == text[0] {
+			end := strings.IndexByte(text[1:], text[0])
+			if -1 == end {
+				return nil, fmt.Errorf("unterminated string at '%s'", text)
+			}
+			tokens = append(tokens, queryToken{text: text[1 : end+1], quoted: true})
+			text = text[end+2:]
+			continue
+		}
+		symbol := ""
+		for _, candidate := range querySymbols {
+			if strings.HasPrefix(text, candidate) {
+				symbol = candidate
+				break
+			}
+		}
+		if "" != symbol {
+			tokens = append(tokens, queryToken{text: symbol})
+			text = text[len(symbol):]
+			continue
+		}
+		end := strings.IndexAny(text, " \t\r\n\"'!=<>~(),")
+		if -1 == end {
+			end = len(text)
+		}
+		tokens = append(tokens, queryToken{text: text[:end]})
+		text = text[end:]
+	}
+	return tokens, nil
+}
+
+// queryKeywords can't be used as bare field names or values
+var queryKeywords = map[string]bool{"by": true, "where": true, "and": true, "or": true, "not": true, "in": true, "since": true, "until": true, "every": true}
+
+// queryUnits are the units of durations written in words
+var queryUnits = map[string]time.Duration{
+	"second": time.Second, "seconds": time.Second, "sec": time.Second, "secs": time.Second,
+	"minute": time.Minute, "minutes": time.Minute, "min": time.Minute, "mins": time.Minute,
+	"hour": time.Hour, "hours": time.Hour,
+	"day": 24 * time.Hour, "days": 24 * time.Hour,
+	"week": 7 * 24 * time.Hour, "weeks": 7 * 24 * time.Hour,
+}
+
+// queryPercentile match the percentile aggregations: p50, p95, p99.9...
+var queryPercentile = regexp.MustCompile(`^p(\d+(?:\.\d+)?)$`)
+
+type queryParser struct {
+	tokens   []queryToken
+	position int
+}
+
+func (p *queryParser) peek() (queryToken, bool) {
+	if p.position >= len(p.tokens) {
+		return queryToken{}, false
+	}
+	return p.tokens[p.position], true
+}
+
+func (p *queryParser) next() (queryToken, bool) {
+	token, ok := p.peek()
+	if ok {
+		p.position++
+	}
+	return token, ok
+}
+
+// keyword send back true (and consume it) if the next token is the keyword
+func (p *queryParser) keyword(name string) bool {
+	if token, ok := p.peek(); ok && !token.quoted && strings.EqualFold(name, token.text) {
+		p.position++
+		return true
+	}
+	return false
+}
+
+// word consume a bare word that isn't a keyword nor a symbol
+func (p *queryParser) word(expected string) (string, error) {
+	token, ok := p.next()
+	if !ok {
+		return "", fmt.Errorf("missing %s", expected)
+	}
+	if token.quoted || queryKeywords[strings.ToLower(token.text)] || isQuerySymbol(token.text) {
+		return "", fmt.Errorf("expected %s, found '%s'", expected, token.text)
+	}
+	return token.text, nil
+}
+
+func isQuerySymbol(text string) bool {
+	for _, symbol := range querySymbols {
+		if symbol == text {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *queryParser) parse() (Query, error) {
+	var query Query
+	if err := p.parseAggregations(&query); nil != err {
+		return Query{}, err
+	}
+	for {
+		token, ok := p.peek()
+		if !ok || token.quoted || queryKeywords[strings.ToLower(token.text)] {
+			break
+		}
+		lvl, err := ParseLevel(token.text)
+		if nil != err {
+			return Query{}, fmt.Errorf("unexpected '%s'", token.text)
+		}
+		query.Levels = append(query.Levels, lvl)
+		p.position++
+	}
+
+	for {
+		token, ok := p.peek()
+		if !ok {
+			return query, nil
+		}
+		var err error
+		switch {
+		case p.keyword("by"):
+			err = p.parseGroupBy(&query)
+		case p.keyword("where"):
+			query.filter, err = p.parseOr()
+		case p.keyword("in"):
+			p.keyword("the")
+			if !p.keyword("last") {
+				return Query{}, fmt.Errorf("expected 'in the last', found 'in'")
+			}
+			var duration time.Duration
+			if duration, err = p.parseDuration(); nil == err {
+				query.Since = time.Now().Add(-duration)
+			}
+		case p.keyword("since"):
+			query.Since, err = p.parseTime()
+		case p.keyword("until"):
+			query.Until, err = p.parseTime()
+		case p.keyword("every"):
+			query.Every, err = p.parseDuration()
+			if nil == err && 0 >= query.Every {
+				err = fmt.Errorf("invalid bucket duration %s", query.Every)
+			}
+		default:
+			return Query{}, fmt.Errorf("unexpected '%s'", token.text)
+		}
+		if nil != err {
+			return Query{}, err
+		}
+	}
+}
+
+func (p *queryParser) parseAggregations(query *Query) error {
+	for {
+		token, ok := p.peek()
+		if !ok || token.quoted {
+			return nil
+		}
+		name := strings.ToLower(token.text)
+		var aggregation Aggregation
+		switch name {
+		case "count":
+			p.position++
+			query.Aggregations = append(query.Aggregations, Aggregation{Function: name})
+		case "avg", "sum", "min", "max":
+			aggregation.Function = name
+		default:
+			match := queryPercentile.FindStringSubmatch(name)
+			if nil == match {
+				return nil
+			}
+			aggregation.Function = "percentile"
+			aggregation.Percentile, _ = strconv.ParseFloat(match[1], 64)
+			if aggregation.Percentile > 100 {
+				return fmt.Errorf("invalid percentile '%s'", token.text)
+			}
+		}
+		if "" != aggregation.Function {
+			p.position++
+			parenthesis := p.symbol("(")
+			var err error
+			if aggregation.Field, err = p.word("field of " + name); nil != err {
+				return err
+			}
+			if parenthesis && !p.symbol(")") {
+				return fmt.Errorf("missing ')' after %s(%s", name, aggregation.Field)
+			}
+			query.Aggregations = append(query.Aggregations, aggregation)
+		}
+		if !p.symbol(",") {
+			return nil
+		}
+	}
+}
+
+// symbol send back true (and consume it) if the next token is the symbol
+func (p *queryParser) symbol(symbol string) bool {
+	if token, ok := p.peek(); ok && !token.quoted && symbol == token.text {
+		p.position++
+		return true
+	}
+	return false
+}
+
+func (p *queryParser) parseGroupBy(query *Query) error {
+	for {
+		field, err := p.word("field to group by")
+		if nil != err {
+			return err
+		}
+		query.GroupBy = append(query.GroupBy, field)
+		if !p.symbol(",") {
+			return nil
+		}
+	}
+}
+
+// parseDuration parse durations written as Go durations (15m), numbers and units (15 minutes) or units alone (hour)
+func (p *queryParser) parseDuration() (time.Duration, error) {
+	text, err := p.word("duration")
+	if nil != err {
+		return 0, err
+	}
+	if unit, ok := queryUnits[strings.ToLower(text)]; ok {
+		return unit, nil
+	}
+	if duration, err := time.ParseDuration(text); nil == err {
+		return duration, nil
+	}
+	count, err := strconv.ParseFloat(text, 64)
+	if nil != err {
+		return 0, fmt.Errorf("invalid duration '%s'", text)
+	}
+	name, err := p.word("unit")
+	if nil != err {
+		return 0, err
+	}
+	unit, ok := queryUnits[strings.ToLower(name)]
+	if !ok {
+		return 0, fmt.Errorf("unknown unit '%s'", name)
+	}
+	return time.Duration(count * float64(unit)), nil
+}
+
+func (p *queryParser) parseTime() (time.Time, error) {
+	token, ok := p.next()
+	if !ok {
+		return time.Time{}, fmt.Errorf("missing time")
+	}
+	parsed, ok := parseTime(token.text)
+	if !ok {
+		return time.Time{}, fmt.Errorf("invalid time '%s'", token.text)
+	}
+	return parsed, nil
+}
+
+func (p *queryParser) parseOr() (queryCondition, error) {
+	conditions := queryOr{}
+	for {
+		condition, err := p.parseAnd()
+		if nil != err {
+			return nil, err
+		}
+		conditions = append(conditions, condition)
+		if !p.keyword("or") {
+			break
+		}
+	}
+	if 1 == len(conditions) {
+		return conditions[0], nil
+	}
+	return conditions, nil
+}
+
+func (p *queryParser) parseAnd() (queryCondition, error) {
+	conditions := queryAnd{}
+	for {
+		condition, err := p.parseUnary()
+		if nil != err {
+			return nil, err
+		}
+		conditions = append(conditions, condition)
+		if !p.keyword("and") {
+			break
+		}
+	}
+	if 1 == len(conditions) {
+		return conditions[0], nil
+	}
+	return conditions, nil
+}
+
+func (p *queryParser) parseUnary() (queryCondition, error) {
+	if p.keyword("not") {
+		condition, err := p.parseUnary()
+		if nil != err {
+			return nil, err
+		}
+		return queryNot{condition}, nil
+	}
+	if p.symbol("(") {
+		condition, err := p.parseOr()
+		if nil != err {
+			return nil, err
+		}
+		if !p.symbol(")") {
+			return nil, fmt.Errorf("missing ')'")
+		}
+		return condition, nil
+	}
+
+	key, err := p.word("field")
+	if nil != err {
+		return nil, err
+	}
+	switch strings.ToLower(key) {
+	case "msg":
+		key = MessageKey
+	case "lvl":
+		key = LevelKey
+	}
+	comparison := queryComparison{key: key}
+	token, ok := p.next()
+	switch {
+	case !ok:
+		return nil, fmt.Errorf("missing operator after '%s'", key)
+	case token.quoted:
+		return nil, fmt.Errorf("expected operator after '%s', found '%s'", key, token.text)
+	case "==" == token.text:
+		comparison.operator = "="
+	case "contains" == strings.ToLower(token.text):
+		comparison.operator = "contains"
+	case isQuerySymbol(token.text) && !strings.ContainsAny(token.text, "(),"):
+		comparison.operator = token.text
+	default:
+		return nil, fmt.Errorf("expected operator after '%s', found '%s'", key, token.text)
+	}
+
+	literal, ok := p.next()
+	if !ok || (!literal.quoted && (queryKeywords[strings.ToLower(literal.text)] || isQuerySymbol(literal.text))) {
+		return nil, fmt.Errorf("missing value after '%s %s'", key, comparison.operator)
+	}
+	comparison.literal = literal.text
+	if "~" == comparison.operator || "!~" == comparison.operator {
+		if comparison.pattern, err = regexp.Compile(literal.text); nil != err {
+			return nil, err
+		}
+	}
+	return comparison, nil
+}
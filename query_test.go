@@ -0,0 +1,127 @@
+package log
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseQuery(t *testing.T) {
+	query, err := ParseQuery("count, avg(duration_ms), p95 duration_ms ERROR WARN by service, host where duration_ms > 500 in the last hour every 5 minutes")
+	if nil != err {
+		t.Fatal(err)
+	}
+	expected := []string{"time", "service", "host", "count", "avg(duration_ms)", "p95(duration_ms)"}
+	columns := query.Columns()
+	if len(expected) != len(columns) {
+		t.Fatalf("Error (Mismatched columns) [Expected: '%v'; Received: '%v']", expected, columns)
+	}
+	for i := range expected {
+		if expected[i] != columns[i] {
+			t.Errorf("Error (Mismatched columns) [Expected: '%v'; Received: '%v']", expected, columns)
+		}
+	}
+	if 2 != len(query.Levels) || ERROR != query.Levels[0] || WARN != query.Levels[1] {
+		t.Errorf("Error (Mismatched levels) [Received: '%v']", query.Levels)
+	}
+	if 5*time.Minute != query.Every {
+		t.Errorf("Error (Mismatched buckets) [Expected: '%s'; Received: '%s']", 5*time.Minute, query.Every)
+	}
+	if since := time.Since(query.Since); since < time.Hour || since > time.Hour+time.Minute {
+		t.Errorf("Error (Mismatched time window) [Received: '%s']", query.Since)
+	}
+	if !query.Aggregated() {
+		t.Error("Error (Query not aggregated)")
+	}
+}
+
+func TestParseQuery_Errors(t *testing.T) {
+	for _, text := range []string{
+		"avg",
+		"avg(duration_ms",
+		"count VERBOSE",
+		"count by",
+		"where",
+		"where user",
+		"where user =",
+		"where (user = john",
+		"where user = 'john",
+		"where message ~ '('",
+		"in the last forever",
+		"in the 5 minutes",
+		"since yesterday",
+		"every 0s",
+		"p101 duration_ms",
+		"count by service where user = john limit 10",
+	} {
+		if _, err := ParseQuery(text); nil == err {
+			t.Errorf("Error (Invalid query parsed) [Query: '%s']", text)
+		}
+	}
+}
+
+func TestQuery_Match(t *testing.T) {
+	entry := NewEntry(ERROR, Structure{
+		"service":     "api",
+		"duration_ms": int64(750),
+		"latency":     "1.5s",
+		"status":      "503",
+		"error":       errors.New("connection refused"),
+		"user":        Structure{"name": "John Doe"},
+	}, "Request failed")
+
+	cases := []struct {
+		Query    string
+		Expected bool
+	}{
+		{"", true},
+		{"ERROR", true},
+		{"WARN INFO", false},
+		{"where duration_ms > 500", true},
+		{"where duration_ms <= 500", false},
+		{"where duration_ms = 750.0", true},
+		{"where status >= 500 and status < 600", true},
+		{"where service = api and not (status = 200 or status = 404)", true},
+		{"where service != api or duration_ms < 10", false},
+		{"where service == 'api'", true},
+		{"where level >= warn", true},
+		{"where level < error", false},
+		{"where lvl = err", true},
+		{"where message ~ '^Request (failed|timed out)$'", true},
+		{"where msg contains timeout", false},
+		{"where message !~ timeout", true},
+		{"where latency > 1s and latency < 2000ms", true},
+		{"where error.message contains refused", true},
+		{"where user.name = 'John Doe'", true},
+		{"where missing = value", false},
+		{"where missing != value", true},
+		{"where time > 2016-08-13T15:40:05Z", true},
+		{"in the last hour", true},
+		{"in the last 2 days", true},
+		{"since 2016-08-13T15:40:05Z until 2016-08-14T00:00:00Z", false},
+		{"count ERROR by service where duration_ms > 500 in the last hour", true},
+	}
+	for _, test := range cases {
+		query, err := ParseQuery(test.Query)
+		if nil != err {
+			t.Errorf("Error (Query not parsed) [Query: '%s'; Error: '%s']", test.Query, err)
+			continue
+		}
+		if received := query.Match(entry); test.Expected != received {
+			t.Errorf("Error (Mismatched match for '%s') [Expected: '%t'; Received: '%t']", test.Query, test.Expected, received)
+		}
+	}
+}
+
+func TestQuery_Match_Time(t *testing.T) {
+	query, err := ParseQuery("since 2016-08-13T15:00:00Z until '2016-08-13 16:00:00'")
+	if nil != err {
+		t.Fatal(err)
+	}
+	if !query.Match(testEntry(INFO, Structure{}, "Message")) {
+		t.Error("Error (Entry in the time window not matched)")
+	}
+	if query.Match(Entry{Level: INFO, Values: []interface{}{"Message"}}) {
+		t.Error("Error (Entry without time matched)")
+	}
+}
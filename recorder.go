@@ -0,0 +1,110 @@
+package log
+
+import (
+	"sync"
+	"time"
+)
+
+// BackfilledKey is the key used by FlightRecorder to mark the entries logged after the fact. It holds the time the entry was originally logged.
+const BackfilledKey = "backfilled"
+
+// FlightRecorder decorate an AgnosticLogger to keep the entries logged below Level in a ring buffer instead of logging them. When an entry is logged at or above Trigger, the buffered entries are logged first, marked with BackfilledKey, so the context leading to the error isn't lost.
+//
+// The decorated logger must accept the buffered levels, the filtering being done by the FlightRecorder. Every logger created with With get its own buffer, which allow to keep the context of a single request. Use NewFlightRecorder to create one.
+type FlightRecorder struct {
+	Logger  AgnosticLogger
+	Level   Level
+	Trigger Level
+	Size    int
+	buffer  *ringBuffer
+}
+
+// NewFlightRecorder create a FlightRecorder keeping the last size entries below the given level, and logging them when an ERROR (or above) occurs.
+func NewFlightRecorder(logger AgnosticLogger, lvl Level, size int) FlightRecorder {
+	return FlightRecorder{
+		Logger:  logger,
+		Level:   lvl,
+		Trigger: ERROR,
+		Size:    size,
+		buffer:  newRingBuffer(size),
+	}
+}
+
+// Log buffer the entry if it's below Level (and accepted by the decorated logger), evaluating its lazy values, or log it through the decorated logger, preceded by the buffered entries if it's at or above Trigger.
+func (l FlightRecorder) Log(lvl Level, str Structure, v ...interface{}) {
+	if nil == l.Logger {
+		return
+	}
+	if lvl < l.Level {
+		if nil != l.buffer && Enabled(l.Logger, lvl) {
+			// Lazy values are evaluated now, so the entries hold the values of the time they were logged
+			l.buffer.push(Entry{Time: time.Now(), Level: lvl, Structure: resolveStructure(Structure{}.With(str)), Values: resolveValues(v)})
+		}
+		return
+	}
+	if lvl >= l.Trigger && nil != l.buffer {
+		for _, entry := range l.buffer.drain() {
+			l.Logger.Log(entry.Level, entry.Structure.With(Structure{BackfilledKey: entry.Time}), entry.Values...)
+		}
+	}
+	l.Logger.Log(lvl, str, v...)
+}
+
+// Enabled send back true if the decorated logger is enabled for the level and, below Level, if entries are recorded
+func (l FlightRecorder) Enabled(lvl Level) bool {
+	if lvl < l.Level && nil == l.buffer {
+		return false
+	}
+	return Enabled(l.Logger, lvl)
+}
+
+// With send back a FlightRecorder decorating a logger containing the given fields, with a new, empty, buffer.
+func (l FlightRecorder) With(str Structure) AgnosticLogger {
+	if nil != l.Logger {
+		l.Logger = l.Logger.With(str)
+	}
+	l.buffer = newRingBuffer(l.Size)
+	return l
+}
+
+// ringBuffer keep the last entries pushed into it
+type ringBuffer struct {
+	mutex   sync.Mutex
+	entries []Entry
+	start   int
+	size    int
+}
+
+func newRingBuffer(capacity int) *ringBuffer {
+	if capacity <= 0 {
+		return nil
+	}
+	return &ringBuffer{entries: make([]Entry, capacity)}
+}
+
+func (b *ringBuffer) push(entry Entry) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.size < len(b.entries) {
+		b.entries[(b.start+b.size)%len(b.entries)] = entry
+		b.size++
+		return
+	}
+	b.entries[b.start] = entry
+	b.start = (b.start + 1) % len(b.entries)
+}
+
+// drain send back the buffered entries, oldest first, and empty the buffer
+func (b *ringBuffer) drain() []Entry {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	toReturn := make([]Entry, 0, b.size)
+	for i := 0; i < b.size; i++ {
+		index := (b.start + i) % len(b.entries)
+		toReturn = append(toReturn, b.entries[index])
+		b.entries[index] = Entry{}
+	}
+	b.start = 0
+	b.size = 0
+	return toReturn
+}
@@ -0,0 +1,79 @@
+package log
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFlightRecorder(t *testing.T) {
+	recorder := newRecordingLogger()
+	flight := NewFlightRecorder(recorder, INFO, 2)
+
+	flight.Log(DEBUG, Structure{}, "Debug 1")
+	flight.Log(TRACE, Structure{}, "Trace 2")
+	flight.Log(INFO, Structure{}, "Info")
+	flight.Log(DEBUG, Structure{}, "Debug 3")
+
+	entries := recorder.Entries()
+	if 1 != len(entries) || "Info" != entries[0].Message {
+		t.Fatalf("Error (Mismatched entries) [Received: '%+v']", entries)
+	}
+
+	flight.Log(ERROR, Structure{}, "Error")
+	entries = recorder.Entries()
+	expected := []string{"Info", "Trace 2", "Debug 3", "Error"}
+	if len(expected) != len(entries) {
+		t.Fatalf("Error (Mismatched number of entries) [Expected: '%d'; Received: '%d']", len(expected), len(entries))
+	}
+	for i, msg := range expected {
+		if msg != entries[i].Message {
+			t.Errorf("Error (Mismatched strings) [Expected: '%s'; Received: '%s']", msg, entries[i].Message)
+		}
+	}
+	if _, ok := entries[1].Structure[BackfilledKey].(time.Time); !ok {
+		t.Errorf("Error (Entry not marked as backfilled) [Received: '%+v']", entries[1].Structure)
+	}
+	if _, ok := entries[3].Structure[BackfilledKey]; ok {
+		t.Errorf("Error (Entry marked as backfilled) [Received: '%+v']", entries[3].Structure)
+	}
+
+	flight.Log(ERROR, Structure{}, "Error")
+	if 5 != len(recorder.Entries()) {
+		t.Errorf("Error (Buffer not emptied) [Expected: '%d'; Received: '%d']", 5, len(recorder.Entries()))
+	}
+}
+
+func TestFlightRecorder_With(t *testing.T) {
+	recorder := newRecordingLogger()
+	flight := NewFlightRecorder(recorder, INFO, 10)
+
+	flight.Log(DEBUG, Structure{}, "Other request")
+	request := flight.With(Structure{"request": 1})
+	request.Log(DEBUG, Structure{}, "Debug")
+	request.Log(ERROR, Structure{}, "Error")
+
+	entries := recorder.Entries()
+	if 2 != len(entries) {
+		t.Fatalf("Error (Mismatched number of entries) [Expected: '%d'; Received: '%d']", 2, len(entries))
+	}
+	if "Debug" != entries[0].Message || 1 != entries[0].Structure["request"] {
+		t.Errorf("Error (Mismatched entries) [Received: '%+v']", entries[0])
+	}
+}
+
+func TestFlightRecorder_NoBuffer(t *testing.T) {
+	recorder := newRecordingLogger()
+	flight := FlightRecorder{Logger: recorder, Level: INFO, Trigger: ERROR}
+
+	flight.Log(DEBUG, Structure{}, "Debug")
+	flight.Log(ERROR, Structure{}, "Error")
+
+	if 1 != len(recorder.Entries()) {
+		t.Errorf("Error (Mismatched number of entries) [Expected: '%d'; Received: '%d']", 1, len(recorder.Entries()))
+	}
+}
+
+func TestFlightRecorder_NoLogger(t *testing.T) {
+	flight := FlightRecorder{}
+	flight.With(Structure{}).Log(ERROR, Structure{}, "Message")
+}
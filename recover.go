@@ -0,0 +1,46 @@
+package log
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PanicKey is the key used by Recover to store the recovered value
+const PanicKey = "panic"
+
+// Recover log the panic unwinding the current goroutine, with its value and stack trace, through the given logger. It does nothing if the goroutine isn't panicking. Recover must be deferred directly:
+//
+//	defer log.Recover(logger, log.ERROR, log.Structure{"worker": id}, false)
+//
+// When repanic is true, the goroutine panic again with the recovered value once it has been logged. Keep in mind that most loggers panic by themselves when logging on PANIC level.
+func Recover(logger AgnosticLogger, lvl Level, str Structure, repanic bool) {
+	value := recover()
+	if nil == value {
+		return
+	}
+
+	if nil != logger {
+		fields := Structure{
+			PanicKey: fmt.Sprint(value),
+			StackKey: panicStack(CaptureStack(1)),
+		}
+		if err, ok := value.(error); ok {
+			fields = fields.WithError(err)
+		}
+		logger.Log(lvl, fields.With(str), "Recovered from panic: ", value)
+	}
+
+	if repanic {
+		panic(value)
+	}
+}
+
+// panicStack remove the frames of the runtime handling the panic, so the stack trace start where the panic was raised.
+func panicStack(stack StackTrace) StackTrace {
+	for i, frame := range stack {
+		if strings.HasPrefix(frame, "runtime.gopanic ") {
+			return stack[i+1:]
+		}
+	}
+	return stack
+}
@@ -0,0 +1,82 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"strings"
+	"testing"
+)
+
+func panicking() {
+	panic("boom")
+}
+
+func TestRecover(t *testing.T) {
+	logger := log.Logger{}
+	buffer := &bytes.Buffer{}
+	logger.SetOutput(buffer)
+
+	func() {
+		defer Recover(BasicLog{Logger: &logger, Level: DEBUG}, ERROR, Structure{"Test": "test"}, false)
+		panicking()
+	}()
+
+	logMsg := buffer.String()
+	for _, expect := range []string{"[ERROR]Recovered from panic: boom", "panic:boom", "Test:test", "panicking"} {
+		if !strings.Contains(logMsg, expect) {
+			t.Errorf("Error (Doesn't contains substring) [Expected: '%s'; Received: '%s']", expect, logMsg)
+		}
+	}
+	if strings.Contains(logMsg, "runtime.gopanic") {
+		t.Errorf("Error (Runtime frames not removed) [Received: '%s']", logMsg)
+	}
+}
+
+func TestRecover_Error(t *testing.T) {
+	logger := log.Logger{}
+	buffer := &bytes.Buffer{}
+	logger.SetOutput(buffer)
+
+	func() {
+		defer Recover(BasicLog{Logger: &logger, Level: DEBUG}, ERROR, Structure{}, false)
+		panic(errors.New("test"))
+	}()
+
+	logMsg := buffer.String()
+	expect := "error.message:test"
+	if !strings.Contains(logMsg, expect) {
+		t.Errorf("Error (Doesn't contains substring) [Expected: '%s'; Received: '%s']", expect, logMsg)
+	}
+}
+
+func TestRecover_Repanic(t *testing.T) {
+	logger := log.Logger{}
+	buffer := &bytes.Buffer{}
+	logger.SetOutput(buffer)
+
+	defer func() {
+		if err := recover(); "boom" != err {
+			t.Errorf("Error (Mismatched panic values) [Expected: '%s'; Received: '%v']", "boom", err)
+		}
+		if 0 == buffer.Len() {
+			t.Error("Error (Panic not logged)")
+		}
+	}()
+	defer Recover(BasicLog{Logger: &logger, Level: DEBUG}, ERROR, Structure{}, true)
+	panicking()
+}
+
+func TestRecover_NoPanic(t *testing.T) {
+	logger := log.Logger{}
+	buffer := &bytes.Buffer{}
+	logger.SetOutput(buffer)
+
+	func() {
+		defer Recover(BasicLog{Logger: &logger, Level: DEBUG}, ERROR, Structure{}, false)
+	}()
+
+	if 0 != buffer.Len() {
+		t.Errorf("Error (String not empty) [Received: '%s']", buffer.String())
+	}
+}
@@ -0,0 +1,224 @@
+package log
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// RedactedValue is the value replacing masked data
+const RedactedValue = "***"
+
+// Secret wrap a value that must never be logged. It always render as RedactedValue, whatever the logger used.
+type Secret struct {
+	// The value is kept behind a pointer: fmt print its address, not the value, when the Secret is in an unexported field and its methods can't be called
+	value *interface{}
+}
+
+// NewSecret wrap the given value in a Secret
+func NewSecret(value interface{}) Secret {
+	return Secret{value: &value}
+}
+
+// Value send back the wrapped value
+func (s Secret) Value() interface{} {
+	if nil == s.value {
+		return nil
+	}
+	return *s.value
+}
+
+// String always send back RedactedValue
+func (s Secret) String() string {
+	return RedactedValue
+}
+
+// GoString always send back RedactedValue, so the value doesn't leak with %#v
+func (s Secret) GoString() string {
+	return RedactedValue
+}
+
+// MarshalText always send back RedactedValue, so the value doesn't leak in JSON or text encodings
+func (s Secret) MarshalText() ([]byte, error) {
+	return []byte(RedactedValue), nil
+}
+
+// cardNumberPattern match credit card numbers, which are only masked if they pass the Luhn check
+var cardNumberPattern = regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`)
+
+// redactionValidators discard the false positives of some patterns of values
+var redactionValidators = map[*regexp.Regexp]func(string) bool{
+	cardNumberPattern: isCardNumber,
+}
+
+// Default patterns used by NewRedactingLogger. Credit card numbers are only masked when they pass the Luhn check.
+var (
+	DefaultRedactedKeys = []*regexp.Regexp{
+		regexp.MustCompile(`(?i)passw(or)?d`),
+		regexp.MustCompile(`(?i)secret`),
+		regexp.MustCompile(`(?i)token`),
+		regexp.MustCompile(`(?i)authorization`),
+		regexp.MustCompile(`(?i)api[-_]?key`),
+	}
+	DefaultRedactedValues = []*regexp.Regexp{
+		// Credit card numbers
+		cardNumberPattern,
+		// JSON Web Tokens
+		regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`),
+		// AWS access key IDs
+		regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`),
+	}
+)
+
+// RedactingLogger decorate an AgnosticLogger to mask sensitive data before it's logged. Structure values (including the ones of nested Structures and maps) whose key match one of Keys are replaced by RedactedValue, and parts of the values (and message arguments) matching one of Values are replaced by RedactedValue. Secret values are always masked.
+type RedactingLogger struct {
+	Logger AgnosticLogger
+	Keys   []*regexp.Regexp
+	Values []*regexp.Regexp
+}
+
+// NewRedactingLogger create a RedactingLogger using the default patterns
+func NewRedactingLogger(logger AgnosticLogger) RedactingLogger {
+	return RedactingLogger{
+		Logger: logger,
+		Keys:   DefaultRedactedKeys,
+		Values: DefaultRedactedValues,
+	}
+}
+
+// Log log the message, with its sensitive data masked, through the decorated logger.
+func (l RedactingLogger) Log(lvl Level, str Structure, v ...interface{}) {
+	if l.Enabled(lvl) {
+		l.Logger.Log(lvl, l.redactStructure(str), l.redactValues(v)...)
+	}
+}
+
+// Enabled send back true if the decorated logger is enabled for the level
+func (l RedactingLogger) Enabled(lvl Level) bool {
+	return Enabled(l.Logger, lvl)
+}
+
+// With send back a RedactingLogger decorating a logger containing the given fields, with their sensitive data masked.
+func (l RedactingLogger) With(str Structure) AgnosticLogger {
+	if nil != l.Logger {
+		l.Logger = l.Logger.With(l.redactStructure(str))
+	}
+	return l
+}
+
+// redactStructure mask the sensitive data of the Structure, including the nested ones
+func (l RedactingLogger) redactStructure(str Structure) Structure {
+	toReturn := make(Structure, len(str))
+	for key, value := range str {
+		if l.sensitiveKey(key) {
+			toReturn[key] = RedactedValue
+			continue
+		}
+		switch nested := value.(type) {
+		case Structure:
+			toReturn[key] = l.redactStructure(nested)
+		case map[string]interface{}:
+			toReturn[key] = map[string]interface{}(l.redactStructure(nested))
+		default:
+			toReturn[key] = l.redact(value)
+		}
+	}
+	return toReturn
+}
+
+func (l RedactingLogger) redactValues(v []interface{}) []interface{} {
+	toReturn := make([]interface{}, len(v))
+	for i, value := range v {
+		toReturn[i] = l.redact(value)
+	}
+	return toReturn
+}
+
+func (l RedactingLogger) sensitiveKey(key string) bool {
+	for _, pattern := range l.Keys {
+		if pattern.MatchString(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// redact mask the value if it's a Secret, or the parts of its string representation matching the Values patterns.
+func (l RedactingLogger) redact(value interface{}) interface{} {
+	value, _ = resolveValue(value)
+	switch value.(type) {
+	case Secret, *Secret:
+		return RedactedValue
+	}
+	if 0 == len(l.Values) {
+		return value
+	}
+	return rewrite(value, l.redactString)
+}
+
+func (l RedactingLogger) redactString(value string) string {
+	for _, pattern := range l.Values {
+		validate, ok := redactionValidators[pattern]
+		if !ok {
+			value = pattern.ReplaceAllString(value, RedactedValue)
+			continue
+		}
+		value = pattern.ReplaceAllStringFunc(value, func(match string) string {
+			if validate(match) {
+				return RedactedValue
+			}
+			return match
+		})
+	}
+	return value
+}
+
+// isCardNumber send back true if the digits of the text pass the Luhn check
+func isCardNumber(text string) bool {
+	sum, digits := 0, 0
+	for i := len(text) - 1; i >= 0; i-- {
+		if text[i] < '0' || text[i] > '9' {
+			continue
+		}
+		digit := int(text[i] - '0')
+		if 1 == digits%2 {
+			if digit *= 2; digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+		digits++
+	}
+	return 0 != digits && 0 == sum%10
+}
+
+// rewrite apply the replace function to the string representation of the value. Values left unchanged by the function are sent back as is, and errors are converted to an ErrorDetail whose messages have been rewritten.
+func rewrite(value interface{}, replace func(string) string) interface{} {
+	value, _ = resolveValue(value)
+	switch value := value.(type) {
+	case Secret, *Secret:
+		return value
+	case error:
+		return rewriteError(NewErrorDetail(value), replace)
+	case ErrorDetail:
+		return rewriteError(value, replace)
+	}
+
+	original := fmt.Sprint(value)
+	rewritten := replace(original)
+	if rewritten == original {
+		return value
+	}
+	return rewritten
+}
+
+func rewriteError(detail ErrorDetail, replace func(string) string) ErrorDetail {
+	detail.Message = replace(detail.Message)
+	if 0 != len(detail.Causes) {
+		causes := make([]ErrorDetail, 0, len(detail.Causes))
+		for _, cause := range detail.Causes {
+			causes = append(causes, rewriteError(cause, replace))
+		}
+		detail.Causes = causes
+	}
+	return detail
+}
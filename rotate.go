@@ -0,0 +1,280 @@
+package log
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backupTimeFormat is the format of the time added to the name of rotated files
+const backupTimeFormat = "2006-01-02T15-04-05.000000000"
+
+// RotatingWriter is an io.Writer writing into Filename and rotating it when it grows over MaxSize bytes, or when it has been opened for more than Interval (both being optional). It can be used as output of BasicLog's *log.Logger as well as logrus' Logger.Out.
+//
+// Rotated files are renamed by adding the rotation time to their name (app.log become app-2006-01-02T15-04-05.000000000.log), and optionally compressed with gzip. Only the last MaxBackups rotated files, younger than MaxAge, are kept (0 meaning no limit).
+//
+// It is safe for concurrent use. Its configuration must not be changed after the first write.
+type RotatingWriter struct {
+	Filename   string
+	MaxSize    int64
+	Interval   time.Duration
+	MaxBackups int
+	MaxAge     time.Duration
+	Compress   bool
+
+	mutex    sync.Mutex
+	file     *os.File
+	size     int64
+	openedAt time.Time
+	now      func() time.Time
+
+	cleaning sync.Mutex
+	pending  sync.WaitGroup
+}
+
+// Write write the given bytes into the current file, rotating it first if needed.
+func (w *RotatingWriter) Write(p []byte) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if nil == w.file {
+		if err := w.open(); nil != err {
+			return 0, err
+		}
+	}
+	if w.shouldRotate(int64(len(p))) {
+		if err := w.rotate(); nil != err {
+			return 0, err
+		}
+	}
+
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// Rotate rotate the current file, whatever its size and age.
+func (w *RotatingWriter) Rotate() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if nil == w.file {
+		if err := w.open(); nil != err {
+			return err
+		}
+	}
+	return w.rotate()
+}
+
+// Reopen close the current file and open Filename again. It's meant to be used with an external tool (like logrotate) moving the file away.
+func (w *RotatingWriter) Reopen() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if err := w.close(); nil != err {
+		return err
+	}
+	return w.open()
+}
+
+// ReopenOn reopen the file every time one of the given signals (typically syscall.SIGHUP) is received. The returned function stop listening to the signals.
+func (w *RotatingWriter) ReopenOn(signals ...os.Signal) func() {
+	received := make(chan os.Signal, 1)
+	signal.Notify(received, signals...)
+	stop := w.reopenOn(received)
+	return func() {
+		signal.Stop(received)
+		stop()
+	}
+}
+
+func (w *RotatingWriter) reopenOn(received <-chan os.Signal) func() {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		for {
+			select {
+			case <-received:
+				w.Reopen()
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// Close close the current file, and wait for the rotated files to be compressed and cleaned.
+func (w *RotatingWriter) Close() error {
+	w.mutex.Lock()
+	err := w.close()
+	w.mutex.Unlock()
+	w.pending.Wait()
+	return err
+}
+
+func (w *RotatingWriter) currentTime() time.Time {
+	if nil != w.now {
+		return w.now()
+	}
+	return time.Now()
+}
+
+func (w *RotatingWriter) open() error {
+	if err := os.MkdirAll(filepath.Dir(w.Filename), 0755); nil != err {
+		return err
+	}
+	file, err := os.OpenFile(w.Filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if nil != err {
+		return err
+	}
+	info, err := file.Stat()
+	if nil != err {
+		file.Close()
+		return err
+	}
+	w.file = file
+	w.size = info.Size()
+	w.openedAt = w.currentTime()
+	return nil
+}
+
+func (w *RotatingWriter) close() error {
+	if nil == w.file {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+func (w *RotatingWriter) shouldRotate(toWrite int64) bool {
+	if 0 != w.MaxSize && 0 != w.size && w.size+toWrite > w.MaxSize {
+		return true
+	}
+	return 0 != w.Interval && w.currentTime().Sub(w.openedAt) >= w.Interval
+}
+
+func (w *RotatingWriter) rotate() error {
+	if err := w.close(); nil != err {
+		return err
+	}
+	now := w.currentTime()
+	backup := w.backupName(now)
+	if err := os.Rename(w.Filename, backup); nil != err && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err := w.open(); nil != err {
+		return err
+	}
+
+	w.pending.Add(1)
+	go func() {
+		defer w.pending.Done()
+		w.cleaning.Lock()
+		defer w.cleaning.Unlock()
+		if w.Compress {
+			compress(backup)
+		}
+		w.clean(now)
+	}()
+	return nil
+}
+
+func (w *RotatingWriter) backupName(t time.Time) string {
+	ext := filepath.Ext(w.Filename)
+	prefix := strings.TrimSuffix(w.Filename, ext)
+	return prefix + "-" + t.UTC().Format(backupTimeFormat) + ext
+}
+
+type backup struct {
+	path string
+	time time.Time
+}
+
+// backups send back the rotated files, the most recent first
+func (w *RotatingWriter) backups() ([]backup, error) {
+	ext := filepath.Ext(w.Filename)
+	prefix := filepath.Base(strings.TrimSuffix(w.Filename, ext)) + "-"
+	dir := filepath.Dir(w.Filename)
+
+	entries, err := os.ReadDir(dir)
+	if nil != err {
+		return nil, err
+	}
+	var toReturn []backup
+	for _, entry := range entries {
+		name := strings.TrimSuffix(entry.Name(), ".gz")
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ext) {
+			continue
+		}
+		t, err := time.Parse(backupTimeFormat, strings.TrimSuffix(strings.TrimPrefix(name, prefix), ext))
+		if nil != err {
+			continue
+		}
+		toReturn = append(toReturn, backup{path: filepath.Join(dir, entry.Name()), time: t})
+	}
+	sort.Slice(toReturn, func(i, j int) bool {
+		return toReturn[i].time.After(toReturn[j].time)
+	})
+	return toReturn, nil
+}
+
+// clean remove the rotated files over MaxBackups or older than MaxAge
+func (w *RotatingWriter) clean(now time.Time) error {
+	if 0 == w.MaxBackups && 0 == w.MaxAge {
+		return nil
+	}
+	backups, err := w.backups()
+	if nil != err {
+		return err
+	}
+	limit := now.Add(-w.MaxAge)
+	for i, backup := range backups {
+		if (0 != w.MaxBackups && i >= w.MaxBackups) || (0 != w.MaxAge && backup.time.Before(limit)) {
+			if err := os.Remove(backup.path); nil != err {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// compress gzip the given file and remove it
+func compress(path string) error {
+	source, err := os.Open(path)
+	if nil != err {
+		return err
+	}
+	defer source.Close()
+
+	destination, err := os.OpenFile(path+".gz", os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if nil != err {
+		return err
+	}
+	writer := gzip.NewWriter(destination)
+	if _, err := io.Copy(writer, source); nil != err {
+		destination.Close()
+		os.Remove(path + ".gz")
+		return err
+	}
+	if err := writer.Close(); nil != err {
+		destination.Close()
+		os.Remove(path + ".gz")
+		return err
+	}
+	if err := destination.Close(); nil != err {
+		return err
+	}
+	source.Close()
+	return os.Remove(path)
+}
@@ -0,0 +1,191 @@
+package log
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+)
+
+func readFile(t *testing.T, path string) string {
+	content, err := os.ReadFile(path)
+	if nil != err {
+		t.Fatal(err)
+	}
+	return string(content)
+}
+
+func TestRotatingWriter_Size(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "app.log")
+	writer := &RotatingWriter{Filename: filename, MaxSize: 10}
+	defer writer.Close()
+
+	for _, line := range []string{"12345\n", "67890\n", "abcde\n"} {
+		if _, err := writer.Write([]byte(line)); nil != err {
+			t.Fatal(err)
+		}
+	}
+	writer.Close()
+
+	if content := readFile(t, filename); "abcde\n" != content {
+		t.Errorf("Error (Mismatched strings) [Expected: '%s'; Received: '%s']", "abcde\n", content)
+	}
+	backups, err := writer.backups()
+	if nil != err {
+		t.Fatal(err)
+	}
+	if 2 != len(backups) {
+		t.Fatalf("Error (Mismatched number of backups) [Expected: '%d'; Received: '%d']", 2, len(backups))
+	}
+	if content := readFile(t, backups[1].path); "12345\n" != content {
+		t.Errorf("Error (Mismatched strings) [Expected: '%s'; Received: '%s']", "12345\n", content)
+	}
+}
+
+func TestRotatingWriter_Interval(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "app.log")
+	now := time.Now()
+	writer := &RotatingWriter{Filename: filename, Interval: time.Hour, now: func() time.Time { return now }}
+	defer writer.Close()
+
+	writer.Write([]byte("first\n"))
+	now = now.Add(30 * time.Minute)
+	writer.Write([]byte("second\n"))
+	now = now.Add(30 * time.Minute)
+	writer.Write([]byte("third\n"))
+	writer.Close()
+
+	if content := readFile(t, filename); "third\n" != content {
+		t.Errorf("Error (Mismatched strings) [Expected: '%s'; Received: '%s']", "third\n", content)
+	}
+	backups, _ := writer.backups()
+	if 1 != len(backups) {
+		t.Fatalf("Error (Mismatched number of backups) [Expected: '%d'; Received: '%d']", 1, len(backups))
+	}
+	if content := readFile(t, backups[0].path); "first\nsecond\n" != content {
+		t.Errorf("Error (Mismatched strings) [Expected: '%s'; Received: '%s']", "first\nsecond\n", content)
+	}
+}
+
+func TestRotatingWriter_MaxBackups(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "app.log")
+	now := time.Now()
+	writer := &RotatingWriter{Filename: filename, MaxBackups: 2, now: func() time.Time { return now }}
+	defer writer.Close()
+
+	for i := 0; i < 5; i++ {
+		writer.Write([]byte("line\n"))
+		now = now.Add(time.Second)
+		if err := writer.Rotate(); nil != err {
+			t.Fatal(err)
+		}
+	}
+	writer.Close()
+
+	backups, _ := writer.backups()
+	if 2 != len(backups) {
+		t.Errorf("Error (Mismatched number of backups) [Expected: '%d'; Received: '%d']", 2, len(backups))
+	}
+}
+
+func TestRotatingWriter_MaxAge(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "app.log")
+	now := time.Now()
+	writer := &RotatingWriter{Filename: filename, MaxAge: time.Hour, now: func() time.Time { return now }}
+	defer writer.Close()
+
+	writer.Rotate()
+	now = now.Add(2 * time.Hour)
+	writer.Rotate()
+	writer.Close()
+
+	backups, _ := writer.backups()
+	if 1 != len(backups) {
+		t.Errorf("Error (Mismatched number of backups) [Expected: '%d'; Received: '%d']", 1, len(backups))
+	}
+}
+
+func TestRotatingWriter_Compress(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "app.log")
+	writer := &RotatingWriter{Filename: filename, Compress: true}
+	defer writer.Close()
+
+	writer.Write([]byte("compressed\n"))
+	writer.Rotate()
+	writer.Close()
+
+	backups, _ := writer.backups()
+	if 1 != len(backups) || !strings.HasSuffix(backups[0].path, ".gz") {
+		t.Fatalf("Error (Backup not compressed) [Received: '%+v']", backups)
+	}
+	file, err := os.Open(backups[0].path)
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	reader, err := gzip.NewReader(file)
+	if nil != err {
+		t.Fatal(err)
+	}
+	content, err := io.ReadAll(reader)
+	if nil != err {
+		t.Fatal(err)
+	}
+	if "compressed\n" != string(content) {
+		t.Errorf("Error (Mismatched strings) [Expected: '%s'; Received: '%s']", "compressed\n", content)
+	}
+}
+
+func TestRotatingWriter_Reopen(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "app.log")
+	writer := &RotatingWriter{Filename: filename}
+	defer writer.Close()
+
+	writer.Write([]byte("before\n"))
+	os.Rename(filename, filename+".1")
+
+	received := make(chan os.Signal)
+	stop := writer.reopenOn(received)
+	received <- os.Interrupt
+	stop()
+
+	writer.Write([]byte("after\n"))
+	writer.Close()
+
+	if content := readFile(t, filename); "after\n" != content {
+		t.Errorf("Error (Mismatched strings) [Expected: '%s'; Received: '%s']", "after\n", content)
+	}
+	if content := readFile(t, filename+".1"); "before\n" != content {
+		t.Errorf("Error (Mismatched strings) [Expected: '%s'; Received: '%s']", "before\n", content)
+	}
+}
+
+func TestRotatingWriter_Loggers(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "app.log")
+	writer := &RotatingWriter{Filename: filename}
+	defer writer.Close()
+
+	BasicLog{Logger: log.New(writer, "", 0), Level: DEBUG}.Log(INFO, Structure{}, "Basic")
+	logger := logrus.New()
+	logger.Out = writer
+	logger.Formatter = &logrus.TextFormatter{DisableColors: true, DisableTimestamp: true}
+	StructuredLog{Logger: logger}.Log(INFO, Structure{}, "Structured")
+	writer.Close()
+
+	content := readFile(t, filename)
+	for _, expect := range []string{"[INFO]Basic", "msg=Structured"} {
+		if !strings.Contains(content, expect) {
+			t.Errorf("Error (Doesn't contains substring) [Expected: '%s'; Received: '%s']", expect, content)
+		}
+	}
+	if 0 != bytes.Count([]byte(content), []byte("\n\n")) {
+		t.Errorf("Error (Unexpected empty line) [Received: '%s']", content)
+	}
+}
@@ -0,0 +1,194 @@
+package log
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// SuppressedKey is the key used by SamplingLogger to report how many entries were dropped since the previous one with the same message and level
+const SuppressedKey = "suppressed"
+
+// maxSamplingCounters is the number of counters kept by a SamplingLogger. When it's reached, the least recently used half is forgotten.
+const maxSamplingCounters = 10000
+
+// SamplingLogger decorate an AgnosticLogger to cap high-volume entries. For each message and level, the First entries of every Window are logged, then only every Thereafter-th one. Entries at or above Level are never sampled; Level is only used when LevelSet is true, ERROR being used otherwise.
+//
+// It's used through a pointer (&SamplingLogger{...} or NewSamplingLogger): its counters are created on first use, and shared by all the loggers created with With.
+type SamplingLogger struct {
+	Logger     AgnosticLogger
+	First      uint64
+	Thereafter uint64
+	Window     time.Duration
+	Level      Level
+	LevelSet   bool
+	once       sync.Once
+	sampler    *sampler
+}
+
+// NewSamplingLogger create a SamplingLogger that never samples ERROR and above.
+func NewSamplingLogger(logger AgnosticLogger, first, thereafter uint64, window time.Duration) *SamplingLogger {
+	return &SamplingLogger{
+		Logger:     logger,
+		First:      first,
+		Thereafter: thereafter,
+		Window:     window,
+		sampler:    newSampler(),
+	}
+}
+
+// counters send back the counters of the logger, creating them on first use
+func (l *SamplingLogger) counters() *sampler {
+	l.once.Do(func() {
+		if nil == l.sampler {
+			l.sampler = newSampler()
+		}
+	})
+	return l.sampler
+}
+
+// level send back the level from which entries are never sampled
+func (l *SamplingLogger) level() Level {
+	if l.LevelSet {
+		return l.Level
+	}
+	return ERROR
+}
+
+// Log log the message through the decorated logger, unless it has been sampled out.
+func (l *SamplingLogger) Log(lvl Level, str Structure, v ...interface{}) {
+	if !l.Enabled(lvl) {
+		return
+	}
+	if lvl >= l.level() {
+		l.Logger.Log(lvl, str, v...)
+		return
+	}
+
+	v = resolveValues(v)
+	keep, suppressed := l.counters().sample(samplingKey{level: lvl, message: fmt.Sprint(v...)}, l.First, l.Thereafter, l.Window)
+	if !keep {
+		return
+	}
+	if 0 != suppressed {
+		str = Structure{SuppressedKey: suppressed}.With(str)
+	}
+	l.Logger.Log(lvl, str, v...)
+}
+
+// Enabled send back true if the decorated logger is enabled for the level
+func (l *SamplingLogger) Enabled(lvl Level) bool {
+	return Enabled(l.Logger, lvl)
+}
+
+// With send back a SamplingLogger decorating a logger containing the given fields. The new logger shares its counters with the current one.
+func (l *SamplingLogger) With(str Structure) AgnosticLogger {
+	logger := l.Logger
+	if nil != logger {
+		logger = logger.With(str)
+	}
+	return &SamplingLogger{
+		Logger:     logger,
+		First:      l.First,
+		Thereafter: l.Thereafter,
+		Window:     l.Window,
+		Level:      l.Level,
+		LevelSet:   l.LevelSet,
+		sampler:    l.counters(),
+	}
+}
+
+// Suppressed send back the total number of entries dropped by the logger (and the loggers sharing its counters).
+func (l *SamplingLogger) Suppressed() uint64 {
+	s := l.counters()
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.suppressed
+}
+
+type samplingKey struct {
+	level   Level
+	message string
+}
+
+type samplingCounter struct {
+	start      time.Time
+	last       time.Time
+	count      uint64
+	suppressed uint64
+}
+
+type sampler struct {
+	mutex      sync.Mutex
+	counters   map[samplingKey]*samplingCounter
+	suppressed uint64
+	lastSweep  time.Time
+	now        func() time.Time
+}
+
+func newSampler() *sampler {
+	return &sampler{counters: make(map[samplingKey]*samplingCounter), now: time.Now}
+}
+
+// sample check if an entry should be kept. When it is, it also send back the number of entries suppressed since the previous entry kept for the same key.
+func (s *sampler) sample(key samplingKey, first, thereafter uint64, window time.Duration) (bool, uint64) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := s.now()
+	s.sweep(now, window)
+
+	counter, ok := s.counters[key]
+	if !ok {
+		if len(s.counters) >= maxSamplingCounters {
+			s.evict()
+		}
+		counter = &samplingCounter{start: now}
+		s.counters[key] = counter
+	}
+	counter.last = now
+	if 0 != window && now.Sub(counter.start) >= window {
+		counter.start = now
+		counter.count = 0
+	}
+
+	counter.count++
+	if counter.count <= first || (0 != thereafter && 0 == (counter.count-first)%thereafter) {
+		suppressed := counter.suppressed
+		counter.suppressed = 0
+		return true, suppressed
+	}
+	counter.suppressed++
+	s.suppressed++
+	return false, 0
+}
+
+// sweep forget the counters that haven't been used for two windows, so the number of counters doesn't grow forever.
+func (s *sampler) sweep(now time.Time, window time.Duration) {
+	if 0 == window || now.Sub(s.lastSweep) < window {
+		return
+	}
+	s.lastSweep = now
+	for key, counter := range s.counters {
+		if now.Sub(counter.start) >= 2*window {
+			delete(s.counters, key)
+		}
+	}
+}
+
+// evict forget the least recently used half of the counters, so the number of counters is capped even without Window.
+func (s *sampler) evict() {
+	uses := make([]time.Time, 0, len(s.counters))
+	for _, counter := range s.counters {
+		uses = append(uses, counter.last)
+	}
+	sort.Slice(uses, func(i, j int) bool { return uses[i].Before(uses[j]) })
+	// Counters used at the limit are forgotten too, as long as more than half are kept: they all share the same time when the clock is coarse
+	limit := uses[len(uses)/2]
+	for key, counter := range s.counters {
+		if counter.last.Before(limit) || (counter.last.Equal(limit) && len(s.counters) > len(uses)/2) {
+			delete(s.counters, key)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package log
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSamplingLogger(t *testing.T) {
+	recorder := newRecordingLogger()
+	sampling := NewSamplingLogger(recorder, 2, 3, time.Minute)
+
+	for i := 0; i < 11; i++ {
+		sampling.Log(INFO, Structure{}, "Message")
+	}
+
+	// Kept: 1, 2, then 5, 8, 11
+	entries := recorder.Entries()
+	if 5 != len(entries) {
+		t.Fatalf("Error (Mismatched number of entries) [Expected: '%d'; Received: '%d']", 5, len(entries))
+	}
+	if 6 != sampling.Suppressed() {
+		t.Errorf("Error (Mismatched number of suppressed entries) [Expected: '%d'; Received: '%d']", 6, sampling.Suppressed())
+	}
+	if _, ok := entries[1].Structure[SuppressedKey]; ok {
+		t.Errorf("Error (Unexpected suppressed count) [Received: '%+v']", entries[1].Structure)
+	}
+	if uint64(2) != entries[2].Structure[SuppressedKey] {
+		t.Errorf("Error (Mismatched suppressed count) [Expected: '%d'; Received: '%v']", 2, entries[2].Structure[SuppressedKey])
+	}
+}
+
+func TestSamplingLogger_KeyedByMessageAndLevel(t *testing.T) {
+	recorder := newRecordingLogger()
+	sampling := NewSamplingLogger(recorder, 1, 0, time.Minute)
+
+	sampling.Log(INFO, Structure{}, "Message")
+	sampling.Log(INFO, Structure{}, "Message")
+	sampling.Log(DEBUG, Structure{}, "Message")
+	sampling.Log(INFO, Structure{}, "Other message")
+
+	entries := recorder.Entries()
+	if 3 != len(entries) {
+		t.Errorf("Error (Mismatched number of entries) [Expected: '%d'; Received: '%d']", 3, len(entries))
+	}
+}
+
+func TestSamplingLogger_ErrorsNeverSampled(t *testing.T) {
+	recorder := newRecordingLogger()
+	sampling := NewSamplingLogger(recorder, 1, 0, time.Minute)
+
+	for i := 0; i < 5; i++ {
+		sampling.Log(ERROR, Structure{}, "Message")
+	}
+
+	entries := recorder.Entries()
+	if 5 != len(entries) {
+		t.Errorf("Error (Mismatched number of entries) [Expected: '%d'; Received: '%d']", 5, len(entries))
+	}
+}
+
+func TestSamplingLogger_Window(t *testing.T) {
+	recorder := newRecordingLogger()
+	sampling := NewSamplingLogger(recorder, 1, 0, time.Minute)
+	now := time.Now()
+	sampling.sampler.now = func() time.Time { return now }
+
+	sampling.Log(INFO, Structure{}, "Message")
+	sampling.Log(INFO, Structure{}, "Message")
+	now = now.Add(time.Minute)
+	sampling.Log(INFO, Structure{}, "Message")
+
+	entries := recorder.Entries()
+	if 2 != len(entries) {
+		t.Fatalf("Error (Mismatched number of entries) [Expected: '%d'; Received: '%d']", 2, len(entries))
+	}
+	if uint64(1) != entries[1].Structure[SuppressedKey] {
+		t.Errorf("Error (Mismatched suppressed count) [Expected: '%d'; Received: '%v']", 1, entries[1].Structure[SuppressedKey])
+	}
+}
+
+func TestSamplingLogger_With(t *testing.T) {
+	recorder := newRecordingLogger()
+	sampling := NewSamplingLogger(recorder, 1, 0, time.Minute)
+
+	sampling.With(Structure{"Test": "test"}).Log(INFO, Structure{}, "Message")
+	sampling.Log(INFO, Structure{}, "Message")
+
+	entries := recorder.Entries()
+	if 1 != len(entries) {
+		t.Fatalf("Error (Mismatched number of entries) [Expected: '%d'; Received: '%d']", 1, len(entries))
+	}
+	if "test" != entries[0].Structure["Test"] {
+		t.Errorf("Error (Mismatched strings) [Expected: '%s'; Received: '%v']", "test", entries[0].Structure["Test"])
+	}
+}
+
+func TestSamplingLogger_Literal(t *testing.T) {
+	recorder := newRecordingLogger()
+	sampling := &SamplingLogger{Logger: recorder, First: 2, Thereafter: 3}
+
+	for i := 0; i < 11; i++ {
+		sampling.Log(INFO, Structure{}, "Literal message")
+	}
+	sampling.With(Structure{"Test": "test"}).Log(INFO, Structure{}, "Literal message")
+
+	// Kept: 1, 2, then 5, 8, 11
+	if entries := recorder.Entries(); 5 != len(entries) {
+		t.Errorf("Error (Mismatched number of entries) [Expected: '%d'; Received: '%d']", 5, len(entries))
+	}
+	if 7 != sampling.Suppressed() {
+		t.Errorf("Error (Mismatched number of suppressed entries) [Expected: '%d'; Received: '%d']", 7, sampling.Suppressed())
+	}
+}
+
+func TestSamplingLogger_LiteralLevel(t *testing.T) {
+	recorder := newRecordingLogger()
+	sampling := &SamplingLogger{Logger: recorder, First: 1}
+	for _, lvl := range []Level{INFO, INFO, INFO, ERROR, ERROR} {
+		sampling.Log(lvl, Structure{}, "Message")
+	}
+	if entries := recorder.Entries(); 3 != len(entries) {
+		t.Errorf("Error (Mismatched number of entries) [Expected: '%d'; Received: '%d']", 3, len(entries))
+	}
+
+	recorder = newRecordingLogger()
+	sampling = &SamplingLogger{Logger: recorder, First: 1, Level: WARN, LevelSet: true}
+	for _, lvl := range []Level{INFO, INFO, WARN, WARN} {
+		sampling.Log(lvl, Structure{}, "Message")
+	}
+	if entries := recorder.Entries(); 3 != len(entries) {
+		t.Errorf("Error (Mismatched number of entries with Level) [Expected: '%d'; Received: '%d']", 3, len(entries))
+	}
+}
+
+func TestSamplingLogger_MaxCounters(t *testing.T) {
+	recorder := newRecordingLogger()
+	sampling := NewSamplingLogger(recorder, 1, 0, 0)
+	now := time.Now()
+	sampling.sampler.now = func() time.Time { return now }
+
+	for i := 0; i < maxSamplingCounters+10; i++ {
+		now = now.Add(time.Millisecond)
+		sampling.Log(INFO, Structure{}, "Message ", i)
+	}
+	if counters := len(sampling.sampler.counters); counters > maxSamplingCounters {
+		t.Errorf("Error (Counters not capped) [Maximum: '%d'; Received: '%d']", maxSamplingCounters, counters)
+	}
+
+	// The most recent counters are kept
+	sampling.Log(INFO, Structure{}, "Message ", maxSamplingCounters+9)
+	if entries := recorder.Entries(); maxSamplingCounters+10 != len(entries) {
+		t.Errorf("Error (Mismatched number of entries) [Expected: '%d'; Received: '%d']", maxSamplingCounters+10, len(entries))
+	}
+}
+
+func TestSamplingLogger_NoLogger(t *testing.T) {
+	sampling := &SamplingLogger{}
+	sampling.Log(INFO, Structure{}, "Message")
+	if 0 != sampling.Suppressed() {
+		t.Errorf("Error (Mismatched number of suppressed entries) [Expected: '%d'; Received: '%d']", 0, sampling.Suppressed())
+	}
+}
@@ -0,0 +1,80 @@
+package log
+
+import (
+	"encoding/binary"
+)
+
+// Snappy block format limits
+const (
+	snappyMaxOffset    = 1<<16 - 1
+	snappyMaxCopy      = 64
+	snappyMinMatch     = 4
+	snappyHashTableLog = 14
+)
+
+// snappyEncode compress the data using the Snappy block format (without framing), as expected by the Loki push API. It's a simple greedy encoder: literals and 2-bytes offset copies only.
+func snappyEncode(src []byte) []byte {
+	dst := binary.AppendUvarint(make([]byte, 0, len(src)+len(src)/6+16), uint64(len(src)))
+	table := make([]int32, 1<<snappyHashTableLog)
+	for i := range table {
+		table[i] = -1
+	}
+
+	literal := 0
+	for i := 0; i+snappyMinMatch <= len(src); {
+		hash := snappyHash(binary.LittleEndian.Uint32(src[i:]))
+		candidate := int(table[hash])
+		table[hash] = int32(i)
+		if candidate < 0 || i-candidate > snappyMaxOffset || binary.LittleEndian.Uint32(src[candidate:]) != binary.LittleEndian.Uint32(src[i:]) {
+			i++
+			continue
+		}
+
+		dst = appendSnappyLiteral(dst, src[literal:i])
+		length := snappyMinMatch
+		for i+length < len(src) && src[candidate+length] == src[i+length] {
+			length++
+		}
+		dst = appendSnappyCopy(dst, i-candidate, length)
+		i += length
+		literal = i
+	}
+	return appendSnappyLiteral(dst, src[literal:])
+}
+
+func snappyHash(value uint32) uint32 {
+	return (value * 0x1e35a7bd) >> (32 - snappyHashTableLog)
+}
+
+func appendSnappyLiteral(dst, literal []byte) []byte {
+	if 0 == len(literal) {
+		return dst
+	}
+	length := len(literal) - 1
+	switch {
+	case length < 60:
+		dst = append(dst, byte(length)<<2)
+	case length < 1<<8:
+		dst = append(dst, 60<<2, byte(length))
+	case length < 1<<16:
+		dst = append(dst, 61<<2, byte(length), byte(length>>8))
+	case length < 1<<24:
+		dst = append(dst, 62<<2, byte(length), byte(length>>8), byte(length>>16))
+	default:
+		dst = append(dst, 63<<2, byte(length), byte(length>>8), byte(length>>16), byte(length>>24))
+	}
+	return append(dst, literal...)
+}
+
+// appendSnappyCopy write the match as copies of at most 64 bytes
+func appendSnappyCopy(dst []byte, offset, length int) []byte {
+	for length > 0 {
+		size := length
+		if size > snappyMaxCopy {
+			size = snappyMaxCopy
+		}
+		dst = append(dst, byte(size-1)<<2|0x02, byte(offset), byte(offset>>8))
+		length -= size
+	}
+	return dst
+}
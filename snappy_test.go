@@ -0,0 +1,97 @@
+package log
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math/rand"
+	"testing"
+)
+
+// snappyDecode decode a Snappy block, supporting every element type so encoded data can be checked against the format
+func snappyDecode(src []byte) ([]byte, error) {
+	length, n := binary.Uvarint(src)
+	if n <= 0 {
+		return nil, errors.New("invalid length")
+	}
+	src = src[n:]
+	dst := make([]byte, 0, length)
+	for 0 != len(src) {
+		tag := src[0]
+		var size, offset int
+		switch tag & 0x03 {
+		case 0x00:
+			size = int(tag >> 2)
+			src = src[1:]
+			if size >= 60 {
+				extra := size - 59
+				if len(src) < extra {
+					return nil, errors.New("truncated literal length")
+				}
+				size = 0
+				for i := extra - 1; i >= 0; i-- {
+					size = size<<8 | int(src[i])
+				}
+				src = src[extra:]
+			}
+			size++
+			if len(src) < size {
+				return nil, errors.New("truncated literal")
+			}
+			dst = append(dst, src[:size]...)
+			src = src[size:]
+			continue
+		case 0x01:
+			size = int(tag>>2&0x07) + 4
+			offset = int(tag>>5)<<8 | int(src[1])
+			src = src[2:]
+		case 0x02:
+			size = int(tag>>2) + 1
+			offset = int(binary.LittleEndian.Uint16(src[1:]))
+			src = src[3:]
+		case 0x03:
+			size = int(tag>>2) + 1
+			offset = int(binary.LittleEndian.Uint32(src[1:]))
+			src = src[5:]
+		}
+		if 0 == offset || offset > len(dst) {
+			return nil, errors.New("invalid offset")
+		}
+		for i := 0; i < size; i++ {
+			dst = append(dst, dst[len(dst)-offset])
+		}
+	}
+	if uint64(len(dst)) != length {
+		return nil, errors.New("mismatched length")
+	}
+	return dst, nil
+}
+
+func TestSnappyEncode(t *testing.T) {
+	random := make([]byte, 100000)
+	rand.New(rand.NewSource(1)).Read(random)
+	cases := [][]byte{
+		{},
+		[]byte("a"),
+		[]byte("abcdabcdabcdabcdabcd"),
+		bytes.Repeat([]byte("level=info message=\"Test\" "), 1000),
+		bytes.Repeat([]byte{0}, 70000),
+		random,
+	}
+
+	for _, data := range cases {
+		encoded := snappyEncode(data)
+		decoded, err := snappyDecode(encoded)
+		if nil != err {
+			t.Errorf("Error (Invalid encoding of %d bytes) [Error: '%s']", len(data), err)
+			continue
+		}
+		if !bytes.Equal(data, decoded) {
+			t.Errorf("Error (Mismatched data after decoding %d bytes)", len(data))
+		}
+	}
+
+	if repeated := bytes.Repeat([]byte("abcd"), 1000); len(snappyEncode(repeated)) > len(repeated)/10 {
+		t.Errorf("Error (Repeated data not compressed) [Size: '%d']", len(snappyEncode(repeated)))
+	}
+}
@@ -0,0 +1,72 @@
+package log
+
+import (
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// StackKey is the key used to store stack traces in a Structure
+const StackKey = "stack"
+
+// maxStackDepth limits the number of frames captured by CaptureStack
+const maxStackDepth = 64
+
+// StackTrace is a list of frames, formatted as "function file:line", the innermost frame first.
+type StackTrace []string
+
+// CaptureStack capture the stack trace of the current goroutine. skip is the number of frames to skip, 0 being the caller of CaptureStack.
+func CaptureStack(skip int) StackTrace {
+	pcs := make([]uintptr, maxStackDepth)
+	n := runtime.Callers(skip+2, pcs)
+	return framesOf(pcs[:n])
+}
+
+// String representation of a StackTrace, frames being separated by '|'
+func (s StackTrace) String() string {
+	return strings.Join(s, "|")
+}
+
+func framesOf(pcs []uintptr) StackTrace {
+	var toReturn StackTrace
+	frames := runtime.CallersFrames(pcs)
+	for {
+		frame, more := frames.Next()
+		if "" != frame.Function {
+			toReturn = append(toReturn, frame.Function+" "+frame.File+":"+strconv.Itoa(frame.Line))
+		}
+		if !more {
+			break
+		}
+	}
+	return toReturn
+}
+
+// StackTraceLogger decorate an AgnosticLogger to attach the stack trace of the caller, under StackKey, to every entry logged at or above Level.
+type StackTraceLogger struct {
+	Logger AgnosticLogger
+	Level  Level
+}
+
+// Log log the message through the decorated logger, adding the stack trace when the level is high enough.
+func (l StackTraceLogger) Log(lvl Level, str Structure, v ...interface{}) {
+	if l.Enabled(lvl) {
+		if lvl >= l.Level {
+			str = Structure{StackKey: CaptureStack(1)}.With(str)
+		}
+		l.Logger.Log(lvl, str, v...)
+	}
+}
+
+// Enabled send back true if the decorated logger is enabled for the level
+func (l StackTraceLogger) Enabled(lvl Level) bool {
+	return Enabled(l.Logger, lvl)
+}
+
+// With send back a StackTraceLogger decorating a logger containing the given fields
+func (l StackTraceLogger) With(str Structure) AgnosticLogger {
+	if nil != l.Logger {
+		l.Logger = l.Logger.With(str)
+	}
+	return l
+}
@@ -0,0 +1,53 @@
+package log
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"testing"
+)
+
+func TestCaptureStack(t *testing.T) {
+	stack := CaptureStack(0)
+	if 0 == len(stack) {
+		t.Fatal("Error (No stack trace captured)")
+	}
+	expect := "TestCaptureStack"
+	if !strings.Contains(stack[0], expect) {
+		t.Errorf("Error (Doesn't contains substring) [Expected: '%s'; Received: '%s']", expect, stack[0])
+	}
+}
+
+func TestStackTraceLogger(t *testing.T) {
+	cases := []struct {
+		Level     Level
+		WithStack bool
+	}{
+		{Level: DEBUG, WithStack: false},
+		{Level: INFO, WithStack: false},
+		{Level: ERROR, WithStack: true},
+	}
+
+	for _, test := range cases {
+		logger := log.Logger{}
+		buffer := &bytes.Buffer{}
+		logger.SetOutput(buffer)
+
+		stackLogger := StackTraceLogger{Logger: BasicLog{Logger: &logger, Level: TRACE}, Level: WARN}
+		stackLogger.Log(test.Level, Structure{}, "Message")
+
+		logMsg := buffer.String()
+		expect := StackKey + ":"
+		if test.WithStack != strings.Contains(logMsg, expect) {
+			t.Errorf("Error (Stack trace presence) [Level: '%s'; Expected: '%t'; Received: '%s']", test.Level, test.WithStack, logMsg)
+		}
+		if test.WithStack && !strings.Contains(logMsg, "TestStackTraceLogger") {
+			t.Errorf("Error (Doesn't contains substring) [Expected: '%s'; Received: '%s']", "TestStackTraceLogger", logMsg)
+		}
+	}
+}
+
+func TestStackTraceLogger_NoLogger(t *testing.T) {
+	stackLogger := StackTraceLogger{}
+	stackLogger.With(Structure{"Test": "test"}).Log(ERROR, Structure{}, "Message")
+}
@@ -21,10 +21,37 @@ func (s Structure) With(str Structure) Structure {
 	return toReturn
 }
 
+// WithError add the given error to the Structure, under ErrorKey.
+func (s Structure) WithError(err error) Structure {
+	return s.With(Structure{ErrorKey: err})
+}
+
+// WithMetrics add the given metrics to the Structure, under MetricsKey, to be sent to CloudWatch by an EMFFormatter.
+func (s Structure) WithMetrics(m Metrics) Structure {
+	return s.With(Structure{MetricsKey: m})
+}
+
+// Flatten send back a copy of the Structure where errors are replaced by the flattened keys of their ErrorDetail.
+func (s Structure) Flatten() Structure {
+	toReturn := make(Structure, len(s))
+	for key, value := range s {
+		switch value := value.(type) {
+		case error:
+			toReturn.With(NewErrorDetail(value).Flatten(key))
+		case ErrorDetail:
+			toReturn.With(value.Flatten(key))
+		default:
+			toReturn[key] = value
+		}
+	}
+	return toReturn
+}
+
 // String representation of a Structure
 func (s Structure) String() string {
 	var toJoin []string
 	if len(s) != 0 {
+		s = s.Flatten()
 		first := true
 		toJoin = append(toJoin, "[")
 		for key, value := range s {
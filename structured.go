@@ -9,13 +9,9 @@ type StructuredLog struct {
 
 // Log log a message to the output defined in logrus.
 func (l StructuredLog) Log(lvl Level, str Structure, v ...interface{}) {
-	if nil != l.Logger {
-		fields := logrus.Fields{}
-		for key, value := range str {
-			fields[key] = value
-		}
-
-		logger := l.Logger.WithFields(fields)
+	if l.Enabled(lvl) {
+		v = resolveValues(v)
+		logger := l.Logger.WithFields(toFields(l.Logger, resolveStructure(str)))
 		switch lvl {
 		case PANIC:
 			logger.Panic(v...)
@@ -35,13 +31,86 @@ func (l StructuredLog) Log(lvl Level, str Structure, v ...interface{}) {
 	}
 }
 
+// Enabled send back true if the level of the logrus logger let entries of the level through. Loggers other than *logrus.Logger and *logrus.Entry are assumed to write every entry.
+func (l StructuredLog) Enabled(lvl Level) bool {
+	var logger *logrus.Logger
+	switch source := l.Logger.(type) {
+	case nil:
+		return false
+	case *logrus.Logger:
+		logger = source
+	case *logrus.Entry:
+		logger = source.Logger
+	}
+	if nil == logger {
+		return true
+	}
+	return logrusLevel(lvl) <= logger.Level
+}
+
+// logrusLevel send back the logrus level used to log entries of the level. TRACE entries are printed, at the INFO level.
+func logrusLevel(lvl Level) logrus.Level {
+	switch {
+	case lvl >= PANIC:
+		return logrus.PanicLevel
+	case lvl >= FATAL:
+		return logrus.FatalLevel
+	case lvl >= ERROR:
+		return logrus.ErrorLevel
+	case lvl >= WARN:
+		return logrus.WarnLevel
+	case lvl >= INFO, lvl <= TRACE:
+		return logrus.InfoLevel
+	}
+	return logrus.DebugLevel
+}
+
 // With send back a logger containing the fields in the given structure
 func (l StructuredLog) With(str Structure) AgnosticLogger {
+	l.Logger = l.Logger.WithFields(toFields(l.Logger, str))
+	return l
+}
+
+// toFields convert a Structure into logrus fields. Errors are stored as nested objects when logrus output JSON, and as flattened keys otherwise. The error stored under ErrorKey is stored under logrus' own ErrorKey, as logrus' WithError would.
+func toFields(logger logrus.FieldLogger, str Structure) logrus.Fields {
 	fields := logrus.Fields{}
+	json := isJSON(logger)
 	for key, value := range str {
-		fields[key] = value
+		if ErrorKey == key {
+			key = logrus.ErrorKey
+		}
+		var detail ErrorDetail
+		switch value := value.(type) {
+		case error:
+			detail = NewErrorDetail(value)
+		case ErrorDetail:
+			detail = value
+		default:
+			fields[key] = value
+			continue
+		}
+		if json {
+			fields[key] = detail.Map()
+		} else {
+			for flatKey, flatValue := range detail.Flatten(key) {
+				fields[flatKey] = flatValue
+			}
+		}
 	}
+	return fields
+}
 
-	l.Logger = l.Logger.WithFields(fields)
-	return l
+// isJSON check if the formatter used by the logrus logger produce JSON
+func isJSON(logger logrus.FieldLogger) bool {
+	var formatter logrus.Formatter
+	switch logger := logger.(type) {
+	case *logrus.Logger:
+		formatter = logger.Formatter
+	case *logrus.Entry:
+		if nil != logger.Logger {
+			formatter = logger.Logger.Formatter
+		}
+	}
+	_, ok := formatter.(*logrus.JSONFormatter)
+	return ok
 }
@@ -0,0 +1,279 @@
+package log
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SyslogFormat is the format of the messages sent by a SyslogWriter
+type SyslogFormat int
+
+// Supported syslog formats
+const (
+	RFC5424 SyslogFormat = iota
+	RFC3164
+)
+
+// SyslogFacility is the syslog facility of the messages
+type SyslogFacility int
+
+// Syslog facilities
+const (
+	FacilityKern SyslogFacility = iota
+	FacilityUser
+	FacilityMail
+	FacilityDaemon
+	FacilityAuth
+	FacilitySyslog
+	FacilityLPR
+	FacilityNews
+	FacilityUUCP
+	FacilityCron
+	FacilityAuthPriv
+	FacilityFTP
+	FacilityLocal0 SyslogFacility = iota + 4
+	FacilityLocal1
+	FacilityLocal2
+	FacilityLocal3
+	FacilityLocal4
+	FacilityLocal5
+	FacilityLocal6
+	FacilityLocal7
+)
+
+// DefaultStructuredDataID is the SD-ID used to send the Structure as RFC 5424 STRUCTURED-DATA when none is configured. 32473 is the private enterprise number reserved for documentation.
+const DefaultStructuredDataID = "fields@32473"
+
+// localSyslogSockets are the paths where the local syslog daemon usually listen
+var localSyslogSockets = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+// SyslogWriter send entries to a syslog server. Network can be "udp", "tcp", "tls" (using TLSConfig), "unix", "unixgram", or empty to use the local syslog daemon. Messages sent over TCP and TLS are framed using octet-counting (RFC 6587).
+//
+// When the server can't be reached, up to BufferSize messages are kept and sent again with the next message (or on Flush). SyslogLog send entries from a background goroutine, through a queue of QueueSize entries (DefaultQueueSize by default): entries logged while it's full are dropped. Hostname, AppName and ProcID default to the values of the current process, Facility to FacilityUser (FacilityKern being reserved to the kernel).
+type SyslogWriter struct {
+	Network          string
+	Address          string
+	TLSConfig        *tls.Config
+	Format           SyslogFormat
+	Facility         SyslogFacility
+	Hostname         string
+	AppName          string
+	ProcID           string
+	MsgID            string
+	StructuredDataID string
+	BufferSize       int
+	QueueSize        int
+	Timeout          time.Duration
+
+	once      sync.Once
+	transport *transport
+	sender    *sender
+}
+
+func (w *SyslogWriter) init() {
+	w.once.Do(func() {
+		if FacilityKern == w.Facility {
+			w.Facility = FacilityUser
+		}
+		if "" == w.Hostname {
+			w.Hostname, _ = os.Hostname()
+		}
+		if "" == w.AppName {
+			w.AppName = filepath.Base(os.Args[0])
+		}
+		if "" == w.ProcID {
+			w.ProcID = strconv.Itoa(os.Getpid())
+		}
+		if "" == w.StructuredDataID {
+			w.StructuredDataID = DefaultStructuredDataID
+		}
+		w.transport = &transport{
+			network:    w.Network,
+			address:    w.Address,
+			tlsConfig:  w.TLSConfig,
+			timeout:    w.Timeout,
+			bufferSize: w.BufferSize,
+		}
+		if "" == w.Network {
+			w.transport.dial = dialLocalSyslog
+		}
+		w.sender = newSender("syslog", w.QueueSize, w.WriteEntry)
+	})
+}
+
+// WriteEntry send the entry to the syslog server
+func (w *SyslogWriter) WriteEntry(e Entry) error {
+	w.init()
+	return w.transport.write(w.frame(w.Encode(e)))
+}
+
+// Flush send the queued entries, and try to send the messages kept while the server was unreachable
+func (w *SyslogWriter) Flush() error {
+	w.init()
+	w.sender.drain()
+	return w.transport.retry()
+}
+
+// Close send the queued entries and close the connection to the syslog server. Entries logged afterward are dropped.
+func (w *SyslogWriter) Close() error {
+	w.init()
+	w.sender.close()
+	return w.transport.close()
+}
+
+// Encode format the entry as a syslog message, without framing
+func (w *SyslogWriter) Encode(e Entry) []byte {
+	w.init()
+	priority := int(w.Facility)*8 + e.Level.Severity()
+	buffer := &bytes.Buffer{}
+	if RFC3164 == w.Format {
+		fmt.Fprintf(buffer, "<%d>%s %s %s[%s]: %s", priority, e.Time.Format(time.Stamp), syslogHeader(w.Hostname, 255), syslogTag(w.AppName), syslogHeader(w.ProcID, 128), e.Message())
+		if 0 != len(e.Structure) {
+			buffer.WriteString(" " + e.Structure.String())
+		}
+		return buffer.Bytes()
+	}
+
+	fmt.Fprintf(buffer, "<%d>1 %s %s %s %s %s ", priority, e.Time.Format("2006-01-02T15:04:05.000000Z07:00"), syslogHeader(w.Hostname, 255), syslogHeader(w.AppName, 48), syslogHeader(w.ProcID, 128), syslogHeader(w.MsgID, 32))
+	writeStructuredData(buffer, w.StructuredDataID, e.Structure)
+	if msg := e.Message(); "" != msg {
+		buffer.WriteString(" " + msg)
+	}
+	return buffer.Bytes()
+}
+
+// frame prepare the message to be sent on the network used
+func (w *SyslogWriter) frame(msg []byte) []byte {
+	switch w.Network {
+	case "tcp", "tcp4", "tcp6", "tls":
+		return append([]byte(strconv.Itoa(len(msg))+" "), msg...)
+	case "udp", "udp4", "udp6":
+		return msg
+	}
+	if !bytes.HasSuffix(msg, []byte("\n")) {
+		msg = append(msg, '\n')
+	}
+	return msg
+}
+
+func dialLocalSyslog() (net.Conn, error) {
+	for _, path := range localSyslogSockets {
+		for _, network := range []string{"unixgram", "unix"} {
+			if conn, err := net.DialTimeout(network, path, defaultTimeout); nil == err {
+				return conn, nil
+			}
+		}
+	}
+	return nil, errors.New("no local syslog socket found")
+}
+
+// writeStructuredData write the Structure as a single RFC 5424 SD-ELEMENT, or the NILVALUE if it's empty
+func writeStructuredData(buffer *bytes.Buffer, id string, str Structure) {
+	if 0 == len(str) {
+		buffer.WriteString("-")
+		return
+	}
+	flat := str.Flatten()
+	keys := make([]string, 0, len(flat))
+	for key := range flat {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	buffer.WriteString("[" + syslogName(id))
+	for _, key := range keys {
+		buffer.WriteString(" " + syslogName(key) + `="`)
+		syslogEscaper.WriteString(buffer, fmt.Sprint(flat[key]))
+		buffer.WriteString(`"`)
+	}
+	buffer.WriteString("]")
+}
+
+// syslogEscaper escape the characters not allowed in PARAM-VALUE
+var syslogEscaper = strings.NewReplacer(`"`, `\"`, `\`, `\\`, `]`, `\]`)
+
+// syslogHeader sanitize a header field: only printable US-ASCII characters are allowed and empty values are replaced by the NILVALUE.
+func syslogHeader(value string, maxLength int) string {
+	value = strings.Map(func(r rune) rune {
+		if r < 33 || r > 126 {
+			return '_'
+		}
+		return r
+	}, value)
+	if len(value) > maxLength {
+		value = value[:maxLength]
+	}
+	if "" == value {
+		return "-"
+	}
+	return value
+}
+
+// syslogName sanitize a SD-NAME (SD-ID or PARAM-NAME)
+func syslogName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if r < 33 || r > 126 || '=' == r || ']' == r || '"' == r {
+			return '_'
+		}
+		return r
+	}, name)
+	if len(name) > 32 {
+		name = name[:32]
+	}
+	if "" == name {
+		return "_"
+	}
+	return name
+}
+
+// syslogTag sanitize a RFC 3164 TAG, made of at most 32 alphanumeric characters
+func syslogTag(tag string) string {
+	tag = strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || '-' == r || '_' == r || '.' == r {
+			return r
+		}
+		return -1
+	}, tag)
+	if len(tag) > 32 {
+		tag = tag[:32]
+	}
+	return tag
+}
+
+// SyslogLog is an AgnosticLogger sending entries at or above Level to a syslog server through Writer.
+type SyslogLog struct {
+	Writer    *SyslogWriter
+	Level     Level
+	structure Structure
+}
+
+// Log send the message to the syslog server in the background, with the Structure as STRUCTURED-DATA.
+func (l SyslogLog) Log(lvl Level, str Structure, v ...interface{}) {
+	if l.Enabled(lvl) {
+		entry := NewEntry(lvl, Structure{}.With(l.structure).With(str), v...)
+		l.Writer.init()
+		l.Writer.sender.log(entry)
+		terminate(lvl, entry.Message())
+	}
+}
+
+// Enabled send back true if entries of the level are sent
+func (l SyslogLog) Enabled(lvl Level) bool {
+	return nil != l.Writer && lvl >= l.Level
+}
+
+// With add some fields to a new logger created from the source and return it
+func (l SyslogLog) With(str Structure) AgnosticLogger {
+	l.structure = Structure{}.With(l.structure).With(str)
+	return l
+}
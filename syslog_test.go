@@ -0,0 +1,279 @@
+package log
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"math/big"
+	"net"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testEntry(lvl Level, str Structure, v ...interface{}) Entry {
+	entry := NewEntry(lvl, str, v...)
+	entry.Time = time.Date(2016, 8, 13, 15, 40, 5, 123456000, time.UTC)
+	return entry
+}
+
+func testSyslogWriter(network, address string) *SyslogWriter {
+	return &SyslogWriter{Network: network, Address: address, Hostname: "host", AppName: "app", ProcID: "42", Timeout: time.Second}
+}
+
+// readOctetCounted read a message framed using octet-counting
+func readOctetCounted(reader *bufio.Reader) (string, error) {
+	length, err := reader.ReadString(' ')
+	if nil != err {
+		return "", err
+	}
+	size, err := strconv.Atoi(strings.TrimSpace(length))
+	if nil != err {
+		return "", err
+	}
+	msg := make([]byte, size)
+	_, err = io.ReadFull(reader, msg)
+	return string(msg), err
+}
+
+// listenSyslogStream start a stream syslog listener, sending the messages received on the returned channel
+func listenSyslogStream(t *testing.T, listener net.Listener) <-chan string {
+	messages := make(chan string, 10)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if nil != err {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				reader := bufio.NewReader(conn)
+				for {
+					msg, err := readOctetCounted(reader)
+					if nil != err {
+						return
+					}
+					messages <- msg
+				}
+			}()
+		}
+	}()
+	return messages
+}
+
+func receive(t *testing.T, messages <-chan string) string {
+	select {
+	case msg := <-messages:
+		return msg
+	case <-time.After(5 * time.Second):
+		t.Fatal("Error (No message received)")
+	}
+	return ""
+}
+
+func selfSignedCertificate(t *testing.T) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if nil != err {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if nil != err {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func TestSyslogWriter_EncodeRFC5424(t *testing.T) {
+	writer := testSyslogWriter("udp", "")
+	writer.MsgID = "ID47"
+
+	msg := writer.Encode(testEntry(ERROR, Structure{"user": `jo"hn]\`, "bad key=": 2}, "Message"))
+	expect := `<11>1 2016-08-13T15:40:05.123456Z host app 42 ID47 [fields@32473 bad_key_="2" user="jo\"hn\]\\"] Message`
+	if expect != string(msg) {
+		t.Errorf("Error (Mismatched strings) [Expected: '%s'; Received: '%s']", expect, msg)
+	}
+}
+
+func TestSyslogWriter_EncodeRFC5424_Empty(t *testing.T) {
+	writer := testSyslogWriter("udp", "")
+	writer.Facility = FacilityLocal0
+
+	msg := writer.Encode(testEntry(DEBUG, Structure{}))
+	expect := `<135>1 2016-08-13T15:40:05.123456Z host app 42 - -`
+	if expect != string(msg) {
+		t.Errorf("Error (Mismatched strings) [Expected: '%s'; Received: '%s']", expect, msg)
+	}
+}
+
+func TestSyslogWriter_EncodeRFC3164(t *testing.T) {
+	writer := testSyslogWriter("udp", "")
+	writer.Format = RFC3164
+	writer.AppName = "my app!"
+
+	msg := writer.Encode(testEntry(WARN, Structure{"user": "john"}, "Message"))
+	expect := `<12>Aug 13 15:40:05 host myapp[42]: Message [user:john]`
+	if expect != string(msg) {
+		t.Errorf("Error (Mismatched strings) [Expected: '%s'; Received: '%s']", expect, msg)
+	}
+}
+
+func TestSyslogLog_UDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	writer := testSyslogWriter("udp", conn.LocalAddr().String())
+	defer writer.Close()
+	syslog := SyslogLog{Writer: writer, Level: INFO}
+	syslog.Log(DEBUG, Structure{}, "Filtered")
+	syslog.With(Structure{"user": "john"}).Log(INFO, Structure{}, "Message")
+
+	buffer := make([]byte, 1024)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := conn.ReadFrom(buffer)
+	if nil != err {
+		t.Fatal(err)
+	}
+	msg := string(buffer[:n])
+	if !strings.HasPrefix(msg, "<14>1 ") || !strings.HasSuffix(msg, `[fields@32473 user="john"] Message`) {
+		t.Errorf("Error (Mismatched message) [Received: '%s']", msg)
+	}
+}
+
+func TestSyslogLog_TCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	messages := listenSyslogStream(t, listener)
+
+	writer := testSyslogWriter("tcp", listener.Addr().String())
+	defer writer.Close()
+	syslog := SyslogLog{Writer: writer, Level: INFO}
+	syslog.Log(INFO, Structure{}, "First")
+	syslog.Log(INFO, Structure{}, "Second\nline")
+
+	for _, expect := range []string{"First", "Second\nline"} {
+		if msg := receive(t, messages); !strings.HasSuffix(msg, " "+expect) {
+			t.Errorf("Error (Mismatched message) [Expected suffix: '%s'; Received: '%s']", expect, msg)
+		}
+	}
+}
+
+func TestSyslogLog_TLS(t *testing.T) {
+	certificate := selfSignedCertificate(t)
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{certificate}})
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	messages := listenSyslogStream(t, listener)
+
+	pool := x509.NewCertPool()
+	parsed, _ := x509.ParseCertificate(certificate.Certificate[0])
+	pool.AddCert(parsed)
+	writer := testSyslogWriter("tls", listener.Addr().String())
+	writer.TLSConfig = &tls.Config{RootCAs: pool}
+	defer writer.Close()
+	SyslogLog{Writer: writer, Level: INFO}.Log(INFO, Structure{}, "Secured")
+
+	if msg := receive(t, messages); !strings.HasSuffix(msg, " Secured") {
+		t.Errorf("Error (Mismatched message) [Received: '%s']", msg)
+	}
+}
+
+func TestSyslogLog_Unixgram(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "syslog.sock")
+	conn, err := net.ListenPacket("unixgram", path)
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	writer := testSyslogWriter("unixgram", path)
+	defer writer.Close()
+	SyslogLog{Writer: writer, Level: INFO}.Log(INFO, Structure{}, "Local")
+
+	buffer := make([]byte, 1024)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := conn.ReadFrom(buffer)
+	if nil != err {
+		t.Fatal(err)
+	}
+	if msg := string(buffer[:n]); !strings.HasSuffix(msg, " Local\n") {
+		t.Errorf("Error (Mismatched message) [Received: '%s']", msg)
+	}
+}
+
+func TestSyslogLog_Buffering(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if nil != err {
+		t.Fatal(err)
+	}
+	address := listener.Addr().String()
+	listener.Close()
+
+	writer := testSyslogWriter("tcp", address)
+	writer.BufferSize = 1
+	defer writer.Close()
+
+	for _, msg := range []string{"Dropped", "Buffered"} {
+		if err := writer.WriteEntry(testEntry(INFO, Structure{}, msg)); nil == err {
+			t.Fatal("Error (Entry sent without server)")
+		}
+	}
+
+	listener, err = net.Listen("tcp", address)
+	if nil != err {
+		t.Skipf("Port reused before the test could listen again: %v", err)
+	}
+	defer listener.Close()
+	messages := listenSyslogStream(t, listener)
+
+	if err := writer.WriteEntry(testEntry(INFO, Structure{}, "Sent")); nil != err {
+		t.Fatal(err)
+	}
+	for _, expect := range []string{"Buffered", "Sent"} {
+		if msg := receive(t, messages); !strings.HasSuffix(msg, " "+expect) {
+			t.Errorf("Error (Mismatched message) [Expected suffix: '%s'; Received: '%s']", expect, msg)
+		}
+	}
+}
+
+func TestSyslogLog_Panic(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	writer := testSyslogWriter("udp", conn.LocalAddr().String())
+	defer writer.Close()
+	defer func() {
+		if err := recover(); "Message" != err {
+			t.Errorf("Error (Mismatched panic values) [Expected: '%s'; Received: '%v']", "Message", err)
+		}
+	}()
+	SyslogLog{Writer: writer}.Log(PANIC, Structure{}, "Message")
+}
+
+func TestSyslogLog_NoWriter(t *testing.T) {
+	SyslogLog{}.With(Structure{}).Log(PANIC, Structure{}, "Message")
+}
@@ -0,0 +1,138 @@
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+	"unicode/utf8"
+)
+
+// TemplateData is the data given to the LineTemplate of a BasicLog
+type TemplateData struct {
+	Time    time.Time
+	Level   Level
+	Message string
+	Fields  Structure
+}
+
+// namedColors are the colors available in the line templates
+var namedColors = map[string]string{
+	"red":     ansiRed,
+	"green":   ansiGreen,
+	"yellow":  ansiYellow,
+	"blue":    ansiBlue,
+	"magenta": ansiMagenta,
+	"cyan":    ansiCyan,
+	"gray":    ansiGray,
+	"bold":    ansiBold,
+	"faint":   ansiFaint,
+}
+
+// templateFuncs are the helpers available in the line templates
+var templateFuncs = template.FuncMap{
+	"upper": func(value interface{}) string {
+		return strings.ToUpper(fmt.Sprint(value))
+	},
+	"lower": func(value interface{}) string {
+		return strings.ToLower(fmt.Sprint(value))
+	},
+	"pad": func(width int, value interface{}) string {
+		return fmt.Sprintf("%-*s", width, fmt.Sprint(value))
+	},
+	"padLeft": func(width int, value interface{}) string {
+		return fmt.Sprintf("%*s", width, fmt.Sprint(value))
+	},
+	"truncate": func(length int, value interface{}) string {
+		text := fmt.Sprint(value)
+		if utf8.RuneCountInString(text) <= length {
+			return text
+		}
+		return string([]rune(text)[:length])
+	},
+	"color": func(name string, value interface{}) string {
+		return colorize(namedColors[name], fmt.Sprint(value))
+	},
+	"levelColor": func(lvl Level, value interface{}) string {
+		return colorize(levelColors[lvl], fmt.Sprint(value))
+	},
+	"date": func(layout string, t time.Time) string {
+		return t.Format(layout)
+	},
+	"select": func(fields Structure, keys ...string) Structure {
+		selected := make(Structure, len(keys))
+		for _, key := range keys {
+			if value, ok := fields[key]; ok {
+				selected[key] = value
+			}
+		}
+		return selected
+	},
+	"omit": func(fields Structure, keys ...string) Structure {
+		omitted := Structure{}.With(fields)
+		for _, key := range keys {
+			delete(omitted, key)
+		}
+		return omitted
+	},
+	"logfmt": func(fields Structure) string {
+		return fields.Logfmt()
+	},
+	"json": func(value interface{}) (string, error) {
+		if fields, ok := value.(Structure); ok {
+			value = Entry{Structure: fields}.Document()
+			delete(value.(map[string]interface{}), LevelKey)
+			delete(value.(map[string]interface{}), MessageKey)
+		}
+		encoded, err := json.Marshal(value)
+		return string(encoded), err
+	},
+}
+
+// colorize color the text, unless the NO_COLOR environment variable is set
+func colorize(color, text string) string {
+	if "" != os.Getenv("NO_COLOR") {
+		return text
+	}
+	return ansiPainter(true).paint(color, text)
+}
+
+// LineTemplate is a line layout for BasicLog, defined by a text/template executed on TemplateData. It's compiled once, when created. Besides the standard functions, templates can use:
+//
+//	upper, lower        {{.Level | upper}}
+//	pad, padLeft        {{.Level | upper | pad 5}}: align the value on the given width
+//	truncate            {{.Message | truncate 80}}: keep the given number of characters
+//	color, levelColor   {{.Message | color "red"}}, {{.Level | levelColor .Level}} (disabled by NO_COLOR)
+//	date                {{.Time | date "15:04:05.000"}}
+//	select, omit        {{select .Fields "user" "id"}}, {{omit .Fields "password"}}
+//	logfmt, json        {{.Fields | logfmt}}, {{.Fields | json}}
+type LineTemplate struct {
+	template *template.Template
+}
+
+// NewLineTemplate compile the layout, like "{{.Time | date "15:04:05"}} {{.Level | upper}} {{.Message}} {{.Fields | logfmt}}"
+func NewLineTemplate(layout string) (*LineTemplate, error) {
+	compiled, err := template.New("line").Funcs(templateFuncs).Parse(layout)
+	if nil != err {
+		return nil, err
+	}
+	return &LineTemplate{template: compiled}, nil
+}
+
+// MustLineTemplate compile the layout, panicking if it's invalid. It's meant for the initialization of global variables.
+func MustLineTemplate(layout string) *LineTemplate {
+	compiled, err := NewLineTemplate(layout)
+	if nil != err {
+		panic(err)
+	}
+	return compiled
+}
+
+// Format send back the line of the entry
+func (t *LineTemplate) Format(e Entry) (string, error) {
+	builder := &strings.Builder{}
+	err := t.template.Execute(builder, TemplateData{Time: e.Time, Level: e.Level, Message: e.Message(), Fields: e.Structure})
+	return builder.String(), err
+}
@@ -0,0 +1,84 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"log"
+	"testing"
+)
+
+func TestLineTemplate_Format(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+	entry := testEntry(WARN, Structure{"user": "john doe", "id": 42, "password": "secret"}, "A long message")
+	cases := []struct {
+		Layout   string
+		Expected string
+	}{
+		{Layout: `{{.Time | date "15:04:05.000"}} {{.Level | upper}} {{.Message}} {{.Fields | logfmt}}`, Expected: `15:40:05.123 WARN A long message id=42 password=secret user="john doe"`},
+		{Layout: `[{{.Level | upper | pad 5}}] [{{.Level | lower | padLeft 5}}]`, Expected: `[WARN ] [ warn]`},
+		{Layout: `{{.Message | truncate 6}}|{{.Message | truncate 50}}`, Expected: `A long|A long message`},
+		{Layout: `{{select .Fields "user" "unknown" | logfmt}}`, Expected: `user="john doe"`},
+		{Layout: `{{omit .Fields "password" | json}}`, Expected: `{"id":42,"user":"john doe"}`},
+		{Layout: `{{.Message | color "red"}} {{.Level | upper | levelColor .Level}}`, Expected: ansiRed + "A long message" + ansiReset + " " + ansiYellow + "WARN" + ansiReset},
+		{Layout: `{{.Message | color "unknown"}}`, Expected: `A long message`},
+	}
+
+	for _, test := range cases {
+		template, err := NewLineTemplate(test.Layout)
+		if nil != err {
+			t.Fatal(err)
+		}
+		received, err := template.Format(entry)
+		if nil != err {
+			t.Errorf("Error (Template '%s' failed) [Error: '%s']", test.Layout, err)
+		}
+		if test.Expected != received {
+			t.Errorf("Error (Mismatched strings) [Expected: '%q'; Received: '%q']", test.Expected, received)
+		}
+	}
+
+	t.Setenv("NO_COLOR", "1")
+	if received, _ := MustLineTemplate(`{{.Message | color "red"}}`).Format(entry); "A long message" != received {
+		t.Errorf("Error (Colors used despite NO_COLOR) [Received: '%q']", received)
+	}
+}
+
+func TestLineTemplate_Invalid(t *testing.T) {
+	if _, err := NewLineTemplate("{{.Message"); nil == err {
+		t.Error("Error (Invalid layout compiled)")
+	}
+	defer func() {
+		if nil == recover() {
+			t.Error("Error (MustLineTemplate didn't panic)")
+		}
+	}()
+	MustLineTemplate("{{.Message")
+}
+
+func TestBasicLog_Template(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	basic := BasicLog{Logger: log.New(buffer, "", 0), Template: MustLineTemplate(`{{.Level | upper | pad 5}} {{.Message}} {{.Fields | logfmt}}`)}
+	basic.With(Structure{"user": "john"}).Log(INFO, Structure{"err": errors.New("failure")}, "Message")
+
+	expected := "INFO  Message err.message=failure err.type=*errors.errorString user=john\n"
+	if expected != buffer.String() {
+		t.Errorf("Error (Mismatched strings) [Expected: '%s'; Received: '%s']", expected, buffer)
+	}
+
+	buffer.Reset()
+	basic.Template = MustLineTemplate(`{{.Unknown}}`)
+	basic.Log(INFO, Structure{}, "Message")
+	if "[INFO]Message\n" != buffer.String() {
+		t.Errorf("Error (Default layout not used when the template fails) [Received: '%s']", buffer)
+	}
+}
+
+func BenchmarkBasicLog_Template(b *testing.B) {
+	basic := BasicLog{Logger: log.New(io.Discard, "", 0), Template: MustLineTemplate(`{{.Time | date "15:04:05"}} {{.Level | upper}} {{.Message}} {{.Fields | logfmt}}`)}
+	str := Structure{"user": "john", "id": 42}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		basic.Log(INFO, str, "Message")
+	}
+}
@@ -0,0 +1,282 @@
+package log
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// defaultTimeout is the timeout used to connect and write to remote servers when none is configured
+const defaultTimeout = 5 * time.Second
+
+// DefaultQueueSize is the number of entries waiting to be sent in the background by the syslog, GELF and Fluent loggers, when none is configured
+const DefaultQueueSize = 1000
+
+// errorReportInterval is the minimum time between two errors reported by a sender
+const errorReportInterval = 10 * time.Second
+
+// transport send messages over a network connection, reconnecting when the connection is lost. Messages that couldn't be sent are buffered (up to bufferSize messages, the oldest being dropped first) and sent again, in order, with the next message.
+type transport struct {
+	network    string
+	address    string
+	tlsConfig  *tls.Config
+	timeout    time.Duration
+	bufferSize int
+	// dial replace the default dialer when set
+	dial func() (net.Conn, error)
+
+	mutex  sync.Mutex
+	conn   net.Conn
+	buffer [][]byte
+}
+
+// write send the message, after the buffered ones
+func (t *transport) write(msg []byte) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if err := t.flush(); nil != err {
+		t.enqueue(msg)
+		return err
+	}
+	if err := t.send(msg); nil != err {
+		t.enqueue(msg)
+		return err
+	}
+	return nil
+}
+
+// retry try to send the buffered messages
+func (t *transport) retry() error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.flush()
+}
+
+// close close the connection. Buffered messages are lost.
+func (t *transport) close() error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.buffer = nil
+	return t.disconnect()
+}
+
+func (t *transport) flush() error {
+	for 0 != len(t.buffer) {
+		if err := t.send(t.buffer[0]); nil != err {
+			return err
+		}
+		t.buffer[0] = nil
+		t.buffer = t.buffer[1:]
+	}
+	return nil
+}
+
+func (t *transport) enqueue(msg []byte) {
+	if 0 >= t.bufferSize {
+		return
+	}
+	if len(t.buffer) >= t.bufferSize {
+		t.buffer[0] = nil
+		t.buffer = t.buffer[1:]
+	}
+	t.buffer = append(t.buffer, msg)
+}
+
+// send write the message on the connection, reconnecting once if it fails
+func (t *transport) send(msg []byte) error {
+	var err error
+	for attempt := 0; attempt < 2; attempt++ {
+		if nil == t.conn {
+			if t.conn, err = t.connect(); nil != err {
+				continue
+			}
+		}
+		t.conn.SetWriteDeadline(time.Now().Add(t.currentTimeout()))
+		if _, err = t.conn.Write(msg); nil == err {
+			return nil
+		}
+		t.disconnect()
+	}
+	return err
+}
+
+func (t *transport) disconnect() error {
+	if nil == t.conn {
+		return nil
+	}
+	err := t.conn.Close()
+	t.conn = nil
+	return err
+}
+
+func (t *transport) currentTimeout() time.Duration {
+	if 0 == t.timeout {
+		return defaultTimeout
+	}
+	return t.timeout
+}
+
+func (t *transport) connect() (net.Conn, error) {
+	if nil != t.dial {
+		return t.dial()
+	}
+	dialer := &net.Dialer{Timeout: t.currentTimeout()}
+	if "tls" == t.network {
+		return tls.DialWithDialer(dialer, "tcp", t.address, t.tlsConfig)
+	}
+	return dialer.Dial(t.network, t.address)
+}
+
+// sender send entries from a background goroutine, so loggers don't wait for remote servers (up to twice their timeout when they are down). Up to size entries wait to be sent, in order: entries added while the queue is full are dropped. Errors are reported at most once per errorReportInterval, with the number of errors and dropped entries since the previous report.
+type sender struct {
+	name string
+	size int
+	send func(Entry) error
+
+	once     sync.Once
+	mutex    sync.Mutex
+	entries  []Entry
+	dropped  int
+	failures int
+	reported time.Time
+	sending  sync.Mutex
+	wakeup   chan struct{}
+	done     chan struct{}
+	stopped  chan struct{}
+}
+
+func newSender(name string, size int, send func(Entry) error) *sender {
+	if 0 >= size {
+		size = DefaultQueueSize
+	}
+	return &sender{name: name, size: size, send: send}
+}
+
+// add queue the entry, or drop it if the queue is full
+func (s *sender) add(e Entry) {
+	s.start()
+	s.mutex.Lock()
+	if len(s.entries) >= s.size {
+		s.dropped++
+		s.mutex.Unlock()
+		return
+	}
+	s.entries = append(s.entries, e)
+	s.mutex.Unlock()
+
+	select {
+	case s.wakeup <- struct{}{}:
+	default:
+	}
+}
+
+// log send the entry in the background. FATAL and PANIC entries are sent before returning instead (after the queued ones), as the process is about to stop.
+func (s *sender) log(e Entry) {
+	if FATAL == e.Level || PANIC == e.Level {
+		reportError(s.name, s.write(e))
+		return
+	}
+	s.add(e)
+}
+
+// write send the queued entries then the given one, without waiting for the background goroutine. It's used for the entries written just before the process exit.
+func (s *sender) write(e Entry) error {
+	s.sending.Lock()
+	defer s.sending.Unlock()
+	s.flush()
+	return s.send(e)
+}
+
+// flush send the queued entries, reporting the errors. The sending mutex must be held.
+func (s *sender) flush() {
+	for {
+		s.mutex.Lock()
+		entries := s.entries
+		s.entries = nil
+		s.mutex.Unlock()
+
+		if 0 == len(entries) {
+			return
+		}
+		for _, entry := range entries {
+			s.report(s.send(entry))
+		}
+	}
+}
+
+// report report the error, unless another one was reported less than errorReportInterval ago. Skipped errors and dropped entries are counted, and their numbers added to the next report.
+func (s *sender) report(err error) {
+	s.mutex.Lock()
+	if nil != err {
+		s.failures++
+	}
+	if (nil == err && 0 == s.dropped) || time.Since(s.reported) < errorReportInterval {
+		s.mutex.Unlock()
+		return
+	}
+	failures, dropped := s.failures, s.dropped
+	s.failures, s.dropped = 0, 0
+	s.reported = time.Now()
+	s.mutex.Unlock()
+
+	if nil == err {
+		err = fmt.Errorf("%d entries dropped: queue full", dropped)
+	} else if 1 != failures || 0 != dropped {
+		err = fmt.Errorf("%w (since the last report: errors: %d, dropped entries: %d)", err, failures, dropped)
+	}
+	reportError(s.name, err)
+}
+
+// close stop sending entries in the background and send the queued entries
+func (s *sender) close() {
+	s.start()
+	s.mutex.Lock()
+	select {
+	case <-s.done:
+	default:
+		close(s.done)
+	}
+	s.mutex.Unlock()
+	<-s.stopped
+	s.drain()
+}
+
+// drain send the queued entries, without waiting for the background goroutine
+func (s *sender) drain() {
+	s.sending.Lock()
+	defer s.sending.Unlock()
+	s.flush()
+}
+
+func (s *sender) start() {
+	s.once.Do(func() {
+		s.wakeup = make(chan struct{}, 1)
+		s.done = make(chan struct{})
+		s.stopped = make(chan struct{})
+		go s.loop()
+	})
+}
+
+func (s *sender) loop() {
+	defer close(s.stopped)
+	for {
+		select {
+		case <-s.wakeup:
+		case <-s.done:
+			return
+		}
+		s.sending.Lock()
+		s.flush()
+		s.sending.Unlock()
+	}
+}
+
+// reportError report the errors of the backends, which can't be sent back to the caller of Log
+func reportError(backend string, err error) {
+	if nil != err {
+		fmt.Fprintf(os.Stderr, "Failed to send entry to %s: %v\n", backend, err)
+	}
+}
@@ -0,0 +1,48 @@
+package log
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestSender_ServerDown(t *testing.T) {
+	writer := testSyslogWriter("tcp", "127.0.0.1:1")
+	writer.QueueSize = 2
+	writer.init()
+	unblock := make(chan struct{})
+	writer.transport.dial = func() (net.Conn, error) {
+		<-unblock
+		return nil, errors.New("unreachable")
+	}
+
+	syslog := SyslogLog{Writer: writer, Level: INFO}
+	start := time.Now()
+	for i := 0; i < 10; i++ {
+		syslog.Log(INFO, Structure{}, "Message")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Error (Log blocked by the server) [Elapsed: '%s']", elapsed)
+	}
+
+	writer.sender.mutex.Lock()
+	queued, dropped := len(writer.sender.entries), writer.sender.dropped
+	writer.sender.mutex.Unlock()
+	if 2 < queued || 7 > dropped {
+		t.Errorf("Error (Mismatched queue) [Queued: '%d'; Dropped: '%d']", queued, dropped)
+	}
+	close(unblock)
+	writer.Close()
+}
+
+func TestSender_ReportLimit(t *testing.T) {
+	s := newSender("test", 0, nil)
+	for i := 0; i < 5; i++ {
+		s.report(errors.New("unreachable"))
+	}
+	// The first error is reported, the next ones are counted for the next report
+	if 4 != s.failures {
+		t.Errorf("Error (Mismatched number of errors not reported) [Expected: '%d'; Received: '%d']", 4, s.failures)
+	}
+}
@@ -0,0 +1,308 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+// DefaultWebhookTemplate is the payload sent when no template is given: the documents of the entries, as a JSON object
+const DefaultWebhookTemplate = `{"entries":{{json .Documents}}}`
+
+// Default circuit breaker parameters of the webhook backend
+const (
+	defaultBreakerThreshold = 5
+	defaultBreakerTimeout   = 30 * time.Second
+)
+
+// ErrCircuitOpen is sent back when requests aren't sent because the destination failed too many times in a row
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// WebhookBatch is the data given to the payload template of a WebhookWriter
+type WebhookBatch struct {
+	Entries   []Entry
+	formatter Formatter
+}
+
+// Documents send back the entries as documents, built by the Formatter of the writer (Entry.Document by default)
+func (b WebhookBatch) Documents() ([]map[string]interface{}, error) {
+	documents := make([]map[string]interface{}, 0, len(b.Entries))
+	for _, e := range b.Entries {
+		document, err := format(b.formatter, e)
+		if nil != err {
+			return nil, err
+		}
+		documents = append(documents, document)
+	}
+	return documents, nil
+}
+
+// webhookFuncs are the functions available in the payload templates, in addition to the standard ones
+var webhookFuncs = template.FuncMap{
+	"json": func(value interface{}) (string, error) {
+		encoded, err := json.Marshal(value)
+		return string(encoded), err
+	},
+	"upper": func(value interface{}) string {
+		return strings.ToUpper(fmt.Sprint(value))
+	},
+	"lower": func(value interface{}) string {
+		return strings.ToLower(fmt.Sprint(value))
+	},
+}
+
+// WebhookWriter POST entries to an HTTP endpoint, meant for the few entries worth an alert. Entries are collected during BatchWait (1 second by default) or until BatchSize are waiting, and sent together as a payload rendered by Template: a text/template executed on a WebhookBatch, with json, upper and lower as additional functions. For example:
+//
+//	{"text": "{{range .Entries}}{{.Level}}: {{.Message}} (user {{index .Structure "user"}})\n{{end}}"}
+//
+// Sending happens in the background, so writing entries never blocks. Requests are limited to RateLimit per second (with bursts of Burst requests, unlimited by default) and failed ones are retried up to MaxRetries times. After BreakerThreshold batches failed in a row, the destination is considered dead: batches are dropped for BreakerTimeout, after which a single batch is tried again.
+type WebhookWriter struct {
+	URL              string
+	Header           http.Header
+	Template         string
+	Formatter        Formatter
+	BatchSize        int
+	BatchWait        time.Duration
+	MaxRetries       int
+	MinBackoff       time.Duration
+	MaxBackoff       time.Duration
+	RateLimit        float64
+	Burst            int
+	BreakerThreshold int
+	BreakerTimeout   time.Duration
+	Client           *http.Client
+
+	once        sync.Once
+	template    *template.Template
+	templateErr error
+	batcher     *batcher
+	limiter     *rateLimiter
+	breaker     *circuitBreaker
+}
+
+func (w *WebhookWriter) init() {
+	w.once.Do(func() {
+		text := w.Template
+		if "" == text {
+			text = DefaultWebhookTemplate
+		}
+		w.template, w.templateErr = template.New("webhook").Funcs(webhookFuncs).Parse(text)
+		if 0 == w.MaxRetries {
+			w.MaxRetries = defaultMaxRetries
+		}
+		if 0 == w.MinBackoff {
+			w.MinBackoff = defaultMinBackoff
+		}
+		if 0 == w.MaxBackoff {
+			w.MaxBackoff = defaultMaxBackoff
+		}
+		if 0 == w.BreakerThreshold {
+			w.BreakerThreshold = defaultBreakerThreshold
+		}
+		if 0 == w.BreakerTimeout {
+			w.BreakerTimeout = defaultBreakerTimeout
+		}
+		if nil == w.Client {
+			w.Client = &http.Client{Timeout: defaultTimeout}
+		}
+		w.limiter = newRateLimiter(w.RateLimit, w.Burst)
+		w.breaker = newCircuitBreaker(w.BreakerThreshold, w.BreakerTimeout)
+		w.batcher = newBatcher("webhook", w.BatchSize, w.BatchWait, w.send)
+	})
+}
+
+// WriteEntry queue the entry, to be sent with the next batch. It never blocks.
+func (w *WebhookWriter) WriteEntry(e Entry) error {
+	w.init()
+	if nil != w.templateErr {
+		return w.templateErr
+	}
+	w.batcher.add(e)
+	return nil
+}
+
+// Flush send the queued entries
+func (w *WebhookWriter) Flush() error {
+	w.init()
+	return w.batcher.flush()
+}
+
+// Close stop sending entries in the background and send the queued ones
+func (w *WebhookWriter) Close() error {
+	w.init()
+	return w.batcher.close()
+}
+
+// Encode render the payload of the batch
+func (w *WebhookWriter) Encode(entries []Entry) ([]byte, error) {
+	w.init()
+	if nil != w.templateErr {
+		return nil, w.templateErr
+	}
+	buffer := &bytes.Buffer{}
+	if err := w.template.Execute(buffer, WebhookBatch{Entries: entries, formatter: w.Formatter}); nil != err {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+func (w *WebhookWriter) send(entries []Entry) error {
+	body, err := w.Encode(entries)
+	if nil != err {
+		return err
+	}
+	if !w.breaker.allow() {
+		return ErrCircuitOpen
+	}
+	err = retry(w.MaxRetries, w.MinBackoff, w.MaxBackoff, func() error {
+		w.limiter.wait()
+		req, err := http.NewRequest(http.MethodPost, w.URL, bytes.NewReader(body))
+		if nil != err {
+			return permanentError{err}
+		}
+		for name, values := range w.Header {
+			req.Header[name] = values
+		}
+		if "" == req.Header.Get("Content-Type") {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		resp, err := w.Client.Do(req)
+		if nil != err {
+			return err
+		}
+		return checkResponse(resp)
+	})
+	w.breaker.record(err)
+	return err
+}
+
+// rateLimiter is a token bucket, allowing rate events per second with bursts of burst events. A zero rate means no limit.
+type rateLimiter struct {
+	rate  float64
+	burst float64
+	now   func() time.Time
+	sleep func(time.Duration)
+
+	mutex  sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	if 0 >= burst {
+		burst = 1
+	}
+	return &rateLimiter{rate: rate, burst: float64(burst), tokens: float64(burst), now: time.Now, sleep: time.Sleep}
+}
+
+// wait block until an event is allowed
+func (r *rateLimiter) wait() {
+	if 0 >= r.rate {
+		return
+	}
+	for {
+		r.mutex.Lock()
+		now := r.now()
+		if !r.last.IsZero() {
+			r.tokens += now.Sub(r.last).Seconds() * r.rate
+			if r.tokens > r.burst {
+				r.tokens = r.burst
+			}
+		}
+		r.last = now
+		if r.tokens >= 1 {
+			r.tokens--
+			r.mutex.Unlock()
+			return
+		}
+		delay := time.Duration((1 - r.tokens) / r.rate * float64(time.Second))
+		r.mutex.Unlock()
+		r.sleep(delay)
+	}
+}
+
+// circuitBreaker stop calls after threshold failures in a row. Once timeout elapsed, a single call is allowed to check if things are back to normal.
+type circuitBreaker struct {
+	threshold int
+	timeout   time.Duration
+	now       func() time.Time
+
+	mutex    sync.Mutex
+	failures int
+	openedAt time.Time
+}
+
+func newCircuitBreaker(threshold int, timeout time.Duration) *circuitBreaker {
+	return &circuitBreaker{threshold: threshold, timeout: timeout, now: time.Now}
+}
+
+// allow tell if a call can be made
+func (b *circuitBreaker) allow() bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.failures < b.threshold {
+		return true
+	}
+	now := b.now()
+	if now.Sub(b.openedAt) < b.timeout {
+		return false
+	}
+	// Half-open: the other calls wait for the result of this one, for another timeout at most
+	b.openedAt = now
+	return true
+}
+
+// record register the result of a call
+func (b *circuitBreaker) record(err error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if nil == err {
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.failures >= b.threshold {
+		b.openedAt = b.now()
+	}
+}
+
+// WebhookLog is an AgnosticLogger sending entries at or above Level to an HTTP endpoint through Writer. Log doesn't wait for the entries to be sent, except for PANIC and FATAL entries, sent before the program ends.
+type WebhookLog struct {
+	Writer    *WebhookWriter
+	Level     Level
+	structure Structure
+}
+
+// NewWebhookLog create a WebhookLog sending ERROR entries and above
+func NewWebhookLog(writer *WebhookWriter) WebhookLog {
+	return WebhookLog{Writer: writer, Level: ERROR}
+}
+
+// Log queue the entry, to be sent with the next batch.
+func (l WebhookLog) Log(lvl Level, str Structure, v ...interface{}) {
+	if l.Enabled(lvl) {
+		entry := NewEntry(lvl, Structure{}.With(l.structure).With(str), v...)
+		reportError("webhook", l.Writer.WriteEntry(entry))
+		if lvl >= FATAL {
+			reportError("webhook", l.Writer.Flush())
+		}
+		terminate(lvl, entry.Message())
+	}
+}
+
+// Enabled send back true if entries of the level are sent
+func (l WebhookLog) Enabled(lvl Level) bool {
+	return nil != l.Writer && lvl >= l.Level
+}
+
+// With add some fields to a new logger created from the source and return it
+func (l WebhookLog) With(str Structure) AgnosticLogger {
+	l.structure = Structure{}.With(l.structure).With(str)
+	return l
+}
@@ -0,0 +1,195 @@
+package log
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newFakeWebhook(t *testing.T, handler func(w http.ResponseWriter, body string)) (*httptest.Server, <-chan string) {
+	payloads := make(chan string, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if nil != err {
+			t.Error(err)
+		}
+		if "token" != r.Header.Get("Authorization") {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		payloads <- string(body)
+		if nil != handler {
+			handler(w, string(body))
+		}
+	}))
+	return server, payloads
+}
+
+func receivePayload(t *testing.T, payloads <-chan string) string {
+	select {
+	case payload := <-payloads:
+		return payload
+	case <-time.After(5 * time.Second):
+		t.Fatal("Error (No payload received)")
+	}
+	return ""
+}
+
+func TestWebhookLog(t *testing.T) {
+	server, payloads := newFakeWebhook(t, nil)
+	defer server.Close()
+
+	writer := &WebhookWriter{
+		URL:       server.URL,
+		Header:    http.Header{"Authorization": {"token"}},
+		Template:  `{{range .Entries}}{{.Level | upper}}: {{.Message}} [{{index .Structure "user"}}]{{"\n"}}{{end}}`,
+		BatchWait: 20 * time.Millisecond,
+	}
+	defer writer.Close()
+	webhook := NewWebhookLog(writer).With(Structure{"user": "john"})
+	webhook.Log(WARN, Structure{}, "Filtered")
+	webhook.Log(ERROR, Structure{}, "First")
+	webhook.Log(ERROR, Structure{"user": "jane"}, "Second")
+
+	expected := "ERROR: First [john]\nERROR: Second [jane]\n"
+	if payload := receivePayload(t, payloads); expected != payload {
+		t.Errorf("Error (Mismatched payloads) [Expected: '%s'; Received: '%s']", expected, payload)
+	}
+}
+
+func TestWebhookWriter_DefaultTemplate(t *testing.T) {
+	writer := &WebhookWriter{}
+	payload, err := writer.Encode([]Entry{testEntry(ERROR, Structure{"user": "john"}, "Message")})
+	if nil != err {
+		t.Fatal(err)
+	}
+	expected := `{"entries":[{"level":"error","message":"Message","user":"john"}]}`
+	if expected != string(payload) {
+		t.Errorf("Error (Mismatched payloads) [Expected: '%s'; Received: '%s']", expected, payload)
+	}
+}
+
+func TestWebhookWriter_InvalidTemplate(t *testing.T) {
+	writer := &WebhookWriter{Template: "{{.Unknown"}
+	if err := writer.WriteEntry(testEntry(ERROR, Structure{}, "Message")); nil == err {
+		t.Error("Error (Invalid template not reported)")
+	}
+}
+
+func TestWebhookWriter_Retry(t *testing.T) {
+	var mutex sync.Mutex
+	failures := 1
+	server, payloads := newFakeWebhook(t, func(w http.ResponseWriter, body string) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		if 0 < failures {
+			failures--
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	})
+	defer server.Close()
+
+	writer := &WebhookWriter{URL: server.URL, Header: http.Header{"Authorization": {"token"}}, MinBackoff: time.Millisecond}
+	defer writer.Close()
+	writer.WriteEntry(testEntry(ERROR, Structure{}, "Message"))
+	if err := writer.Flush(); nil != err {
+		t.Fatal(err)
+	}
+	if receivePayload(t, payloads) != receivePayload(t, payloads) {
+		t.Error("Error (Mismatched payloads sent again)")
+	}
+}
+
+func TestWebhookLog_NonBlocking(t *testing.T) {
+	block := make(chan struct{})
+	server, _ := newFakeWebhook(t, func(w http.ResponseWriter, body string) {
+		<-block
+	})
+	defer server.Close()
+	defer close(block)
+
+	writer := &WebhookWriter{URL: server.URL, Header: http.Header{"Authorization": {"token"}}, BatchSize: 1}
+	webhook := NewWebhookLog(writer)
+	start := time.Now()
+	for i := 0; i < 100; i++ {
+		webhook.Log(ERROR, Structure{}, "Message")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Error (Log blocked by the endpoint) [Elapsed: '%s']", elapsed)
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Unix(0, 0)
+	var slept time.Duration
+	limiter := newRateLimiter(2, 2)
+	limiter.now = func() time.Time { return now }
+	limiter.sleep = func(d time.Duration) {
+		slept += d
+		now = now.Add(d)
+	}
+
+	for i := 0; i < 4; i++ {
+		limiter.wait()
+	}
+	if time.Second != slept {
+		t.Errorf("Error (Mismatched waiting time) [Expected: '%s'; Received: '%s']", time.Second, slept)
+	}
+
+	now = now.Add(time.Hour)
+	slept = 0
+	limiter.wait()
+	limiter.wait()
+	if 0 != slept {
+		t.Errorf("Error (Burst not allowed after a pause) [Slept: '%s']", slept)
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Unix(0, 0)
+	breaker := newCircuitBreaker(2, time.Minute)
+	breaker.now = func() time.Time { return now }
+	failure := errors.New("failure")
+
+	breaker.record(failure)
+	if !breaker.allow() {
+		t.Fatal("Error (Opened before threshold)")
+	}
+	breaker.record(failure)
+	if breaker.allow() {
+		t.Fatal("Error (Not opened after threshold)")
+	}
+
+	now = now.Add(time.Minute)
+	if !breaker.allow() {
+		t.Fatal("Error (Not half-opened after timeout)")
+	}
+	if breaker.allow() {
+		t.Error("Error (More than one call allowed when half-opened)")
+	}
+	breaker.record(nil)
+	if !breaker.allow() {
+		t.Error("Error (Not closed after success)")
+	}
+}
+
+func TestWebhookWriter_CircuitOpen(t *testing.T) {
+	writer := &WebhookWriter{URL: "http://127.0.0.1:1", MaxRetries: -1, BreakerThreshold: 1}
+	defer writer.Close()
+	writer.WriteEntry(testEntry(ERROR, Structure{}, "First"))
+	if err := writer.Flush(); nil == err || errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Error (Mismatched errors) [Received: '%v']", err)
+	}
+	writer.WriteEntry(testEntry(ERROR, Structure{}, "Second"))
+	if err := writer.Flush(); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Error (Circuit not opened) [Received: '%v']", err)
+	}
+}
+
+func TestWebhookLog_NoWriter(t *testing.T) {
+	WebhookLog{}.With(Structure{}).Log(PANIC, Structure{}, "Message")
+}